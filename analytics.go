@@ -0,0 +1,402 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// analyticsURLHeuristic derives a likely Analytics service URL from the
+// device-service address. Used only when service discovery reported none.
+func analyticsURLHeuristic(address string) string {
+	url := strings.Replace(address, "/device_service", "/analytics_service", 1)
+	if !strings.Contains(url, "analytics_service") {
+		url = strings.Replace(address, "/onvif/device_service", "/onvif/analytics_service", 1)
+	}
+	return url
+}
+
+// resolveAnalyticsURL returns the Analytics service URL, discovering it if
+// needed and falling back to a heuristic rewrite of the device-service address.
+func (c *Client) resolveAnalyticsURL(ctx context.Context, camera *Camera) string {
+	if camera.AnalyticsURL == "" {
+		c.discoverServices(ctx, camera)
+	}
+	if camera.AnalyticsURL != "" {
+		return camera.AnalyticsURL
+	}
+	return analyticsURLHeuristic(getFirstAddress(camera.Address))
+}
+
+// analyticsCall sends an Analytics service (ver20) operation and checks the
+// response for a SOAP fault.
+func (c *Client) analyticsCall(ctx context.Context, camera *Camera, op, body string) ([]byte, error) {
+	analyticsURL := c.resolveAnalyticsURL(ctx, camera)
+	resp, err := c.sendSOAPRequest(ctx, analyticsURL, "http://www.onvif.org/ver20/analytics/wsdl/"+op, body)
+	if err != nil {
+		return nil, fmt.Errorf("analytics %s failed: %v", op, err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, fmt.Errorf("analytics %s failed: %w", op, err)
+	}
+	return resp, nil
+}
+
+// NewPolylineItem returns a tt:Polyline element item, e.g. the "Segments"
+// parameter of a tt:LineDetector rule.
+func NewPolylineItem(name string, points ...Point) ElementItem {
+	return ElementItem{Name: name, XML: "<tt:Polyline>" + buildPointsXML(points) + "</tt:Polyline>"}
+}
+
+// NewPolygonItem returns a tt:Polygon element item, e.g. the "Field"
+// parameter of a tt:FieldDetector rule.
+func NewPolygonItem(name string, points ...Point) ElementItem {
+	return ElementItem{Name: name, XML: "<tt:Polygon>" + buildPointsXML(points) + "</tt:Polygon>"}
+}
+
+func buildPointsXML(points []Point) string {
+	var b strings.Builder
+	for _, p := range points {
+		fmt.Fprintf(&b, `<tt:Point x="%s" y="%s"/>`,
+			strconv.FormatFloat(p.X, 'f', -1, 64), strconv.FormatFloat(p.Y, 'f', -1, 64))
+	}
+	return b.String()
+}
+
+// Points returns the tt:Point vertices of a polyline or polygon item, in
+// order. It returns nil for items that hold no points.
+func (e ElementItem) Points() []Point {
+	root, err := e.node()
+	if err != nil {
+		return nil
+	}
+	var points []Point
+	var walk func(n xmlNode)
+	walk = func(n xmlNode) {
+		for _, child := range n.Nodes {
+			if child.XMLName.Local == "Point" {
+				x, _ := strconv.ParseFloat(child.attr("x"), 64)
+				y, _ := strconv.ParseFloat(child.attr("y"), 64)
+				points = append(points, Point{X: x, Y: y})
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(root)
+	return points
+}
+
+// node parses the item's content as the children of an xmlNode.
+func (e ElementItem) node() (xmlNode, error) {
+	var root xmlNode
+	err := xml.Unmarshal([]byte("<ElementItem>"+e.XML+"</ElementItem>"), &root)
+	return root, err
+}
+
+// analyticsConfigXML is the parsed form of a tt:Config (a rule or module
+// instance). Element items keep their content for the round trip.
+type analyticsConfigXML struct {
+	Name       string `xml:"Name,attr"`
+	Type       string `xml:"Type,attr"`
+	Parameters struct {
+		simpleItemsXML
+		Elements []struct {
+			Name  string `xml:"Name,attr"`
+			Inner string `xml:",innerxml"`
+		} `xml:"ElementItem"`
+	} `xml:"Parameters"`
+}
+
+// config converts a to an AnalyticsConfig, resolving the prefixes it uses
+// against namespaces, the declarations of the response it came from.
+func (a analyticsConfigXML) config(namespaces map[string]string) AnalyticsConfig {
+	cfg := AnalyticsConfig{
+		Name:        a.Name,
+		Type:        strings.TrimSpace(a.Type),
+		SimpleItems: a.Parameters.items(),
+	}
+	if prefix, _, ok := strings.Cut(cfg.Type, ":"); ok {
+		cfg.TypeSpace = namespaces[prefix]
+	}
+	for _, e := range a.Parameters.Elements {
+		cfg.ElementItems = append(cfg.ElementItems, ElementItem{Name: e.Name, XML: withNamespaces(strings.TrimSpace(e.Inner), namespaces)})
+	}
+	return cfg
+}
+
+// itemDescriptionsXML is a list of Simple/ElementItemDescription elements.
+type itemDescriptionsXML struct {
+	Simple []struct {
+		Name string `xml:"Name,attr"`
+		Type string `xml:"Type,attr"`
+	} `xml:"SimpleItemDescription"`
+	Element []struct {
+		Name string `xml:"Name,attr"`
+		Type string `xml:"Type,attr"`
+	} `xml:"ElementItemDescription"`
+}
+
+func (d itemDescriptionsXML) simple() []SimpleItemDescription {
+	var out []SimpleItemDescription
+	for _, it := range d.Simple {
+		out = append(out, SimpleItemDescription{Name: it.Name, Type: it.Type})
+	}
+	return out
+}
+
+func (d itemDescriptionsXML) element() []SimpleItemDescription {
+	var out []SimpleItemDescription
+	for _, it := range d.Element {
+		out = append(out, SimpleItemDescription{Name: it.Name, Type: it.Type})
+	}
+	return out
+}
+
+// all returns the simple and element item descriptions together, the way
+// event message descriptions report them.
+func (d itemDescriptionsXML) all() []SimpleItemDescription {
+	return append(d.simple(), d.element()...)
+}
+
+// configDescriptionXML is the parsed form of a tt:ConfigDescription (a rule
+// or module type).
+type configDescriptionXML struct {
+	Name         string              `xml:"Name,attr"`
+	Fixed        bool                `xml:"fixed,attr"`
+	MaxInstances int                 `xml:"maxInstances,attr"`
+	Parameters   itemDescriptionsXML `xml:"Parameters"`
+	Messages     []struct {
+		IsProperty  bool                `xml:"IsProperty,attr"`
+		ParentTopic string              `xml:"ParentTopic"`
+		Source      itemDescriptionsXML `xml:"Source"`
+		Key         itemDescriptionsXML `xml:"Key"`
+		Data        itemDescriptionsXML `xml:"Data"`
+	} `xml:"Messages"`
+}
+
+func (d configDescriptionXML) description() AnalyticsConfigDescription {
+	desc := AnalyticsConfigDescription{
+		Name:         strings.TrimSpace(d.Name),
+		Fixed:        d.Fixed,
+		MaxInstances: d.MaxInstances,
+		SimpleItems:  d.Parameters.simple(),
+		ElementItems: d.Parameters.element(),
+	}
+	for _, m := range d.Messages {
+		desc.Messages = append(desc.Messages, AnalyticsMessageDescription{
+			ParentTopic: strings.TrimSpace(m.ParentTopic),
+			EventMessageDescription: EventMessageDescription{
+				IsProperty: m.IsProperty,
+				Source:     m.Source.all(),
+				Key:        m.Key.all(),
+				Data:       m.Data.all(),
+			},
+		})
+	}
+	return desc
+}
+
+// analyticsType returns cfg.Type as a QName to send and the xmlns
+// declaration its prefix needs. A prefix that would rebind tt or tan, which
+// the config element itself uses, is renamed.
+func analyticsType(cfg AnalyticsConfig) (qname, decl string) {
+	prefix, local, ok := strings.Cut(cfg.Type, ":")
+	if !ok || cfg.TypeSpace == "" || (prefix == "tt" && cfg.TypeSpace == "http://www.onvif.org/ver10/schema") {
+		return cfg.Type, ""
+	}
+	if prefix == "tt" || prefix == "tan" {
+		prefix = "ns1"
+	}
+	return prefix + ":" + local, fmt.Sprintf(` xmlns:%s="%s"`, prefix, escapeXML(cfg.TypeSpace))
+}
+
+// buildAnalyticsConfigXML renders cfg as the tt:Config element elem (e.g.
+// "tan:Rule"), declaring the namespace of a vendor type.
+func buildAnalyticsConfigXML(elem string, cfg AnalyticsConfig) string {
+	var b strings.Builder
+	qname, decl := analyticsType(cfg)
+	fmt.Fprintf(&b, `<%s Name="%s" Type="%s"%s><tt:Parameters>`, elem, escapeXML(cfg.Name), escapeXML(qname), decl)
+	for _, it := range cfg.SimpleItems {
+		fmt.Fprintf(&b, `<tt:SimpleItem Name="%s" Value="%s"/>`, escapeXML(it.Name), escapeXML(it.Value))
+	}
+	for _, it := range cfg.ElementItems {
+		fmt.Fprintf(&b, `<tt:ElementItem Name="%s">%s</tt:ElementItem>`, escapeXML(it.Name), it.XML)
+	}
+	fmt.Fprintf(&b, `</tt:Parameters></%s>`, elem)
+	return b.String()
+}
+
+// buildAnalyticsBody renders an analytics request for the video analytics
+// configuration configToken, followed by extra.
+func buildAnalyticsBody(op, configToken, extra string) string {
+	return fmt.Sprintf(`<tan:%s><tan:ConfigurationToken>%s</tan:ConfigurationToken>%s</tan:%s>`,
+		op, configToken, extra, op)
+}
+
+// getConfigDescriptions runs GetSupportedRules or GetSupportedAnalyticsModules.
+func (c *Client) getConfigDescriptions(ctx context.Context, camera *Camera, op, configToken string) ([]AnalyticsConfigDescription, error) {
+	resp, err := c.analyticsCall(ctx, camera, op, buildAnalyticsBody(op, configToken, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Rules   []configDescriptionXML `xml:"Body>GetSupportedRulesResponse>SupportedRules>RuleDescription"`
+		Modules []configDescriptionXML `xml:"Body>GetSupportedAnalyticsModulesResponse>SupportedAnalyticsModules>AnalyticsModuleDescription"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %v", op, err)
+	}
+	var descs []AnalyticsConfigDescription
+	for _, d := range append(parsed.Rules, parsed.Modules...) {
+		descs = append(descs, d.description())
+	}
+	return descs, nil
+}
+
+// getConfigs runs GetRules or GetAnalyticsModules.
+func (c *Client) getConfigs(ctx context.Context, camera *Camera, op, configToken string) ([]AnalyticsConfig, error) {
+	resp, err := c.analyticsCall(ctx, camera, op, buildAnalyticsBody(op, configToken, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Rules   []analyticsConfigXML `xml:"Body>GetRulesResponse>Rule"`
+		Modules []analyticsConfigXML `xml:"Body>GetAnalyticsModulesResponse>AnalyticsModule"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %v", op, err)
+	}
+	namespaces := declaredNamespaces(resp)
+	var configs []AnalyticsConfig
+	for _, a := range append(parsed.Rules, parsed.Modules...) {
+		configs = append(configs, a.config(namespaces))
+	}
+	return configs, nil
+}
+
+// GetSupportedRules returns the rule types (e.g. tt:LineDetector,
+// tt:FieldDetector) the video analytics configuration configToken accepts,
+// with their parameters and the events they raise. The token is a profile's
+// AnalyticsConfigToken.
+func (c *Client) GetSupportedRules(camera *Camera, configToken string) ([]AnalyticsConfigDescription, error) {
+	return c.GetSupportedRulesContext(context.Background(), camera, configToken)
+}
+
+// GetSupportedRulesContext is like GetSupportedRules but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetSupportedRulesContext(ctx context.Context, camera *Camera, configToken string) ([]AnalyticsConfigDescription, error) {
+	return c.getConfigDescriptions(ctx, camera, "GetSupportedRules", configToken)
+}
+
+// GetRules returns the rules of the video analytics configuration
+// configToken.
+func (c *Client) GetRules(camera *Camera, configToken string) ([]AnalyticsConfig, error) {
+	return c.GetRulesContext(context.Background(), camera, configToken)
+}
+
+// GetRulesContext is like GetRules but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetRulesContext(ctx context.Context, camera *Camera, configToken string) ([]AnalyticsConfig, error) {
+	return c.getConfigs(ctx, camera, "GetRules", configToken)
+}
+
+// CreateRules adds rules to the video analytics configuration configToken.
+// Rule names must be unique within the configuration.
+func (c *Client) CreateRules(camera *Camera, configToken string, rules ...AnalyticsConfig) error {
+	return c.CreateRulesContext(context.Background(), camera, configToken, rules...)
+}
+
+// CreateRulesContext is like CreateRules but uses ctx for cancellation and
+// deadlines.
+func (c *Client) CreateRulesContext(ctx context.Context, camera *Camera, configToken string, rules ...AnalyticsConfig) error {
+	return c.writeConfigs(ctx, camera, "CreateRules", "tan:Rule", configToken, rules)
+}
+
+// ModifyRules replaces the parameters of existing rules, matched by name. Each
+// rule is sent whole, so start from the GetRules result.
+func (c *Client) ModifyRules(camera *Camera, configToken string, rules ...AnalyticsConfig) error {
+	return c.ModifyRulesContext(context.Background(), camera, configToken, rules...)
+}
+
+// ModifyRulesContext is like ModifyRules but uses ctx for cancellation and
+// deadlines.
+func (c *Client) ModifyRulesContext(ctx context.Context, camera *Camera, configToken string, rules ...AnalyticsConfig) error {
+	return c.writeConfigs(ctx, camera, "ModifyRules", "tan:Rule", configToken, rules)
+}
+
+// DeleteRules removes the named rules from the video analytics configuration
+// configToken.
+func (c *Client) DeleteRules(camera *Camera, configToken string, names ...string) error {
+	return c.DeleteRulesContext(context.Background(), camera, configToken, names...)
+}
+
+// DeleteRulesContext is like DeleteRules but uses ctx for cancellation and
+// deadlines.
+func (c *Client) DeleteRulesContext(ctx context.Context, camera *Camera, configToken string, names ...string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("no rule names given")
+	}
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, `<tan:RuleName>%s</tan:RuleName>`, escapeXML(name))
+	}
+	_, err := c.analyticsCall(ctx, camera, "DeleteRules", buildAnalyticsBody("DeleteRules", configToken, b.String()))
+	return err
+}
+
+// writeConfigs sends op, a create or modify request, with configs rendered
+// as elem.
+func (c *Client) writeConfigs(ctx context.Context, camera *Camera, op, elem, configToken string, configs []AnalyticsConfig) error {
+	if len(configs) == 0 {
+		return fmt.Errorf("%s: no configurations given", op)
+	}
+	var b strings.Builder
+	for _, cfg := range configs {
+		b.WriteString(buildAnalyticsConfigXML(elem, cfg))
+	}
+	_, err := c.analyticsCall(ctx, camera, op, buildAnalyticsBody(op, configToken, b.String()))
+	return err
+}
+
+// GetSupportedAnalyticsModules returns the analytics module types (e.g.
+// tt:CellMotionEngine) the video analytics configuration configToken
+// accepts.
+func (c *Client) GetSupportedAnalyticsModules(camera *Camera, configToken string) ([]AnalyticsConfigDescription, error) {
+	return c.GetSupportedAnalyticsModulesContext(context.Background(), camera, configToken)
+}
+
+// GetSupportedAnalyticsModulesContext is like GetSupportedAnalyticsModules but
+// uses ctx for cancellation and deadlines.
+func (c *Client) GetSupportedAnalyticsModulesContext(ctx context.Context, camera *Camera, configToken string) ([]AnalyticsConfigDescription, error) {
+	return c.getConfigDescriptions(ctx, camera, "GetSupportedAnalyticsModules", configToken)
+}
+
+// GetAnalyticsModules returns the analytics modules of the video analytics
+// configuration configToken.
+func (c *Client) GetAnalyticsModules(camera *Camera, configToken string) ([]AnalyticsConfig, error) {
+	return c.GetAnalyticsModulesContext(context.Background(), camera, configToken)
+}
+
+// GetAnalyticsModulesContext is like GetAnalyticsModules but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetAnalyticsModulesContext(ctx context.Context, camera *Camera, configToken string) ([]AnalyticsConfig, error) {
+	return c.getConfigs(ctx, camera, "GetAnalyticsModules", configToken)
+}
+
+// ModifyAnalyticsModules replaces the parameters of existing analytics
+// modules, matched by name. Each module is sent whole, so start from the
+// GetAnalyticsModules result.
+func (c *Client) ModifyAnalyticsModules(camera *Camera, configToken string, modules ...AnalyticsConfig) error {
+	return c.ModifyAnalyticsModulesContext(context.Background(), camera, configToken, modules...)
+}
+
+// ModifyAnalyticsModulesContext is like ModifyAnalyticsModules but uses ctx
+// for cancellation and deadlines.
+func (c *Client) ModifyAnalyticsModulesContext(ctx context.Context, camera *Camera, configToken string, modules ...AnalyticsConfig) error {
+	return c.writeConfigs(ctx, camera, "ModifyAnalyticsModules", "tan:AnalyticsModule", configToken, modules)
+}
@@ -0,0 +1,143 @@
+package onvif
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyticsRules(t *testing.T) {
+	var gotAction, gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotAction, gotBody = action, body
+		switch action {
+		case "http://www.onvif.org/ver10/device/wsdl/GetCapabilities":
+			return `<tds:GetCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"><tds:Capabilities>
+<tt:Analytics><tt:XAddr>http://192.0.2.10/onvif/analytics</tt:XAddr><tt:RuleSupport>true</tt:RuleSupport></tt:Analytics>
+<tt:Media><tt:XAddr>http://192.0.2.10/onvif/media</tt:XAddr></tt:Media>
+</tds:Capabilities></tds:GetCapabilitiesResponse>`
+		case "http://www.onvif.org/ver20/analytics/wsdl/GetSupportedRules":
+			return `<tan:GetSupportedRulesResponse xmlns:tan="http://www.onvif.org/ver20/analytics/wsdl"><tan:SupportedRules>
+<tt:RuleDescription Name="tt:LineDetector" maxInstances="4">
+<tt:Parameters><tt:SimpleItemDescription Name="Direction" Type="tt:Direction"/><tt:ElementItemDescription Name="Segments" Type="tt:Polyline"/></tt:Parameters>
+<tt:Messages IsProperty="false">
+<tt:Source><tt:SimpleItemDescription Name="VideoSourceConfigurationToken" Type="tt:ReferenceToken"/><tt:SimpleItemDescription Name="Rule" Type="xs:string"/></tt:Source>
+<tt:Data><tt:SimpleItemDescription Name="ObjectId" Type="tt:ObjectRefType"/></tt:Data>
+<tt:ParentTopic>tns1:RuleEngine/LineDetector/Crossed</tt:ParentTopic>
+</tt:Messages></tt:RuleDescription>
+</tan:SupportedRules></tan:GetSupportedRulesResponse>`
+		case "http://www.onvif.org/ver20/analytics/wsdl/GetRules":
+			return `<tan:GetRulesResponse xmlns:tan="http://www.onvif.org/ver20/analytics/wsdl">
+<tan:Rule Name="Gate" Type="tt:LineDetector"><tt:Parameters>
+<tt:SimpleItem Name="Direction" Value="Any"/>
+<tt:ElementItem Name="Segments"><tt:Polyline><tt:Point x="-0.5" y="0"/><tt:Point x="0.5" y="0.25"/></tt:Polyline></tt:ElementItem>
+</tt:Parameters></tan:Rule></tan:GetRulesResponse>`
+		case "http://www.onvif.org/ver20/analytics/wsdl/GetAnalyticsModules":
+			return `<tan:GetAnalyticsModulesResponse xmlns:tan="http://www.onvif.org/ver20/analytics/wsdl" xmlns:v="http://vendor.example/analytics">
+<tan:AnalyticsModule Name="MyCellMotion" Type="tt:CellMotionEngine"><tt:Parameters><tt:SimpleItem Name="Sensitivity" Value="60"/></tt:Parameters></tan:AnalyticsModule>
+<tan:AnalyticsModule Name="Tamper" Type="v:TamperEngine"><tt:Parameters><tt:ElementItem Name="Zone"><v:Zone Shape="v:Rect"/></tt:ElementItem></tt:Parameters></tan:AnalyticsModule>
+</tan:GetAnalyticsModulesResponse>`
+		}
+		return `<tan:Response xmlns:tan="http://www.onvif.org/ver20/analytics/wsdl"/>`
+	})
+	camera := &Camera{Address: "http://192.0.2.10/onvif/device_service"}
+
+	rules, err := c.GetSupportedRules(camera, "vac0")
+	if err != nil {
+		t.Fatalf("GetSupportedRules() error = %v", err)
+	}
+	if !camera.AnalyticsSupport || camera.AnalyticsURL != "http://192.0.2.10/onvif/analytics" {
+		t.Errorf("analytics discovery: support = %v, URL = %q", camera.AnalyticsSupport, camera.AnalyticsURL)
+	}
+	wantDesc := []AnalyticsConfigDescription{{
+		Name:         "tt:LineDetector",
+		MaxInstances: 4,
+		SimpleItems:  []SimpleItemDescription{{Name: "Direction", Type: "tt:Direction"}},
+		ElementItems: []SimpleItemDescription{{Name: "Segments", Type: "tt:Polyline"}},
+		Messages: []AnalyticsMessageDescription{{
+			ParentTopic: "tns1:RuleEngine/LineDetector/Crossed",
+			EventMessageDescription: EventMessageDescription{
+				Source: []SimpleItemDescription{{"VideoSourceConfigurationToken", "tt:ReferenceToken"}, {"Rule", "xs:string"}},
+				Data:   []SimpleItemDescription{{"ObjectId", "tt:ObjectRefType"}},
+			},
+		}},
+	}}
+	if !reflect.DeepEqual(rules, wantDesc) {
+		t.Errorf("GetSupportedRules() = %+v\nwant %+v", rules, wantDesc)
+	}
+	if !strings.Contains(gotBody, `<tan:GetSupportedRules><tan:ConfigurationToken>vac0</tan:ConfigurationToken></tan:GetSupportedRules>`) {
+		t.Errorf("GetSupportedRules body = %s", gotBody)
+	}
+
+	current, err := c.GetRules(camera, "vac0")
+	if err != nil {
+		t.Fatalf("GetRules() error = %v", err)
+	}
+	if len(current) != 1 || current[0].Name != "Gate" || current[0].Type != "tt:LineDetector" ||
+		!reflect.DeepEqual(current[0].SimpleItems, []SimpleItem{{Name: "Direction", Value: "Any"}}) ||
+		len(current[0].ElementItems) != 1 ||
+		!reflect.DeepEqual(current[0].ElementItems[0].Points(), []Point{{-0.5, 0}, {0.5, 0.25}}) {
+		t.Errorf("GetRules() = %+v", current)
+	}
+
+	field := AnalyticsConfig{
+		Name:         "Yard",
+		Type:         "tt:FieldDetector",
+		ElementItems: []ElementItem{NewPolygonItem("Field", Point{-1, -1}, Point{1, -1}, Point{0, 0.5})},
+	}
+	if err := c.CreateRules(camera, "vac0", field); err != nil {
+		t.Fatalf("CreateRules() error = %v", err)
+	}
+	wantBody := `<tan:CreateRules><tan:ConfigurationToken>vac0</tan:ConfigurationToken>` +
+		`<tan:Rule Name="Yard" Type="tt:FieldDetector"><tt:Parameters><tt:ElementItem Name="Field">` +
+		`<tt:Polygon><tt:Point x="-1" y="-1"/><tt:Point x="1" y="-1"/><tt:Point x="0" y="0.5"/></tt:Polygon>` +
+		`</tt:ElementItem></tt:Parameters></tan:Rule></tan:CreateRules>`
+	if gotAction != "http://www.onvif.org/ver20/analytics/wsdl/CreateRules" || !strings.Contains(gotBody, wantBody) {
+		t.Errorf("CreateRules body = %s\nwant %s", gotBody, wantBody)
+	}
+
+	gate := current[0]
+	gate.SimpleItems[0].Value = "Right"
+	if err := c.ModifyRules(camera, "vac0", gate); err != nil {
+		t.Fatalf("ModifyRules() error = %v", err)
+	}
+	if !strings.Contains(gotBody, `<tt:SimpleItem Name="Direction" Value="Right"/><tt:ElementItem Name="Segments"><tt:Polyline xmlns:tt="http://www.onvif.org/ver10/schema"><tt:Point x="-0.5" y="0"/>`) {
+		t.Errorf("ModifyRules body = %s", gotBody)
+	}
+
+	if err := c.DeleteRules(camera, "vac0", "Gate", "Yard"); err != nil {
+		t.Fatalf("DeleteRules() error = %v", err)
+	}
+	if !strings.Contains(gotBody, `</tan:ConfigurationToken><tan:RuleName>Gate</tan:RuleName><tan:RuleName>Yard</tan:RuleName></tan:DeleteRules>`) {
+		t.Errorf("DeleteRules body = %s", gotBody)
+	}
+	if err := c.DeleteRules(camera, "vac0"); err == nil {
+		t.Error("DeleteRules() with no names returned nil error")
+	}
+
+	modules, err := c.GetAnalyticsModules(camera, "vac0")
+	if err != nil || len(modules) != 2 || modules[0].Type != "tt:CellMotionEngine" || modules[0].SimpleItems[0].Value != "60" ||
+		modules[1].Type != "v:TamperEngine" || modules[1].TypeSpace != "http://vendor.example/analytics" {
+		t.Fatalf("GetAnalyticsModules() = %+v, %v", modules, err)
+	}
+
+	// Vendor prefixes are not bound by the request envelope, so they are
+	// declared where they are used.
+	if err := c.ModifyAnalyticsModules(camera, "vac0", modules[1]); err != nil {
+		t.Fatalf("ModifyAnalyticsModules() error = %v", err)
+	}
+	wantBody = `<tan:AnalyticsModule Name="Tamper" Type="v:TamperEngine" xmlns:v="http://vendor.example/analytics"><tt:Parameters>` +
+		`<tt:ElementItem Name="Zone"><v:Zone Shape="v:Rect" xmlns:v="http://vendor.example/analytics"/></tt:ElementItem>`
+	if !strings.Contains(gotBody, wantBody) {
+		t.Errorf("ModifyAnalyticsModules body = %s\nwant %s", gotBody, wantBody)
+	}
+
+	// A vendor type whose prefix clashes with one the request uses is renamed.
+	modules[1].Type = "tan:TamperEngine"
+	if err := c.ModifyAnalyticsModules(camera, "vac0", modules[1]); err != nil {
+		t.Fatalf("ModifyAnalyticsModules() error = %v", err)
+	}
+	if !strings.Contains(gotBody, `<tan:AnalyticsModule Name="Tamper" Type="ns1:TamperEngine" xmlns:ns1="http://vendor.example/analytics">`) {
+		t.Errorf("ModifyAnalyticsModules body = %s", gotBody)
+	}
+}
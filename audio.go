@@ -0,0 +1,600 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// audioSourceConfigXML is the parsed form of a tt:AudioSourceConfiguration.
+type audioSourceConfigXML struct {
+	Token       string `xml:"token,attr"`
+	Name        string `xml:"Name"`
+	UseCount    int    `xml:"UseCount"`
+	SourceToken string `xml:"SourceToken"`
+}
+
+func (a audioSourceConfigXML) config() AudioSourceConfig {
+	return AudioSourceConfig{Token: a.Token, Name: a.Name, UseCount: a.UseCount, SourceToken: strings.TrimSpace(a.SourceToken)}
+}
+
+// audioEncoderXML is the parsed form of a tt:AudioEncoderConfiguration. As
+// with video, SetAudioEncoderConfiguration replaces the whole configuration,
+// so the session timeout and multicast settings are kept for the round trip.
+type audioEncoderXML struct {
+	Token          string       `xml:"token,attr"`
+	Name           string       `xml:"Name"`
+	UseCount       int          `xml:"UseCount"`
+	Encoding       string       `xml:"Encoding"`
+	Bitrate        int          `xml:"Bitrate"`
+	SampleRate     int          `xml:"SampleRate"`
+	Multicast      multicastXML `xml:"Multicast"`
+	SessionTimeout string       `xml:"SessionTimeout"`
+}
+
+func (a audioEncoderXML) config() AudioEncoderConfig {
+	return AudioEncoderConfig{
+		Token:      a.Token,
+		Name:       a.Name,
+		UseCount:   a.UseCount,
+		Encoding:   a.Encoding,
+		Bitrate:    a.Bitrate,
+		SampleRate: a.SampleRate,
+		Multicast:  a.Multicast.config(),
+	}
+}
+
+// apply overlays the non-zero fields of cfg onto a.
+func (a *audioEncoderXML) apply(cfg AudioEncoderConfig) {
+	if cfg.Name != "" {
+		a.Name = cfg.Name
+	}
+	if cfg.Encoding != "" {
+		a.Encoding = cfg.Encoding
+	}
+	if cfg.Bitrate > 0 {
+		a.Bitrate = cfg.Bitrate
+	}
+	if cfg.SampleRate > 0 {
+		a.SampleRate = cfg.SampleRate
+	}
+	if cfg.Multicast != nil {
+		a.Multicast = newMulticastXML(*cfg.Multicast)
+	}
+}
+
+// audioOutputConfigXML is the parsed form of a tt:AudioOutputConfiguration.
+type audioOutputConfigXML struct {
+	Token       string `xml:"token,attr"`
+	Name        string `xml:"Name"`
+	UseCount    int    `xml:"UseCount"`
+	OutputToken string `xml:"OutputToken"`
+	SendPrimacy string `xml:"SendPrimacy"`
+	OutputLevel int    `xml:"OutputLevel"`
+}
+
+func (a audioOutputConfigXML) config() AudioOutputConfig {
+	level := a.OutputLevel
+	return AudioOutputConfig{
+		Token:       a.Token,
+		Name:        a.Name,
+		UseCount:    a.UseCount,
+		OutputToken: strings.TrimSpace(a.OutputToken),
+		SendPrimacy: strings.TrimSpace(a.SendPrimacy),
+		OutputLevel: &level,
+	}
+}
+
+// audioDecoderConfigXML is the parsed form of a tt:AudioDecoderConfiguration.
+type audioDecoderConfigXML struct {
+	Token    string `xml:"token,attr"`
+	Name     string `xml:"Name"`
+	UseCount int    `xml:"UseCount"`
+}
+
+// intListXML is the parsed form of a tt:IntList, a space separated list.
+type intListXML struct {
+	Items string `xml:"Items"`
+}
+
+func (l intListXML) ints() []int {
+	var out []int
+	for _, f := range strings.Fields(l.Items) {
+		if v, err := strconv.Atoi(f); err == nil {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// GetAudioSources returns the physical audio inputs of the device.
+func (c *Client) GetAudioSources(camera *Camera) ([]AudioSource, error) {
+	return c.GetAudioSourcesContext(context.Background(), camera)
+}
+
+// GetAudioSourcesContext is like GetAudioSources but uses ctx for cancellation
+// and deadlines.
+func (c *Client) GetAudioSourcesContext(ctx context.Context, camera *Camera) ([]AudioSource, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioSources", `<trt:GetAudioSources/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Sources []struct {
+			Token    string `xml:"token,attr"`
+			Channels int    `xml:"Channels"`
+		} `xml:"Body>GetAudioSourcesResponse>AudioSources"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio sources: %v", err)
+	}
+
+	sources := make([]AudioSource, 0, len(parsed.Sources))
+	for _, s := range parsed.Sources {
+		sources = append(sources, AudioSource{Token: s.Token, Channels: s.Channels})
+	}
+	return sources, nil
+}
+
+// getAudioSourceConfigurations fetches the raw audio source configurations.
+func (c *Client) getAudioSourceConfigurations(ctx context.Context, camera *Camera) ([]audioSourceConfigXML, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioSourceConfigurations", `<trt:GetAudioSourceConfigurations/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Configs []audioSourceConfigXML `xml:"Body>GetAudioSourceConfigurationsResponse>Configurations"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio source configurations: %v", err)
+	}
+	return parsed.Configs, nil
+}
+
+// GetAudioSourceConfigurations returns every audio source configuration on
+// the device.
+func (c *Client) GetAudioSourceConfigurations(camera *Camera) ([]AudioSourceConfig, error) {
+	return c.GetAudioSourceConfigurationsContext(context.Background(), camera)
+}
+
+// GetAudioSourceConfigurationsContext is like GetAudioSourceConfigurations but
+// uses ctx for cancellation and deadlines.
+func (c *Client) GetAudioSourceConfigurationsContext(ctx context.Context, camera *Camera) ([]AudioSourceConfig, error) {
+	raw, err := c.getAudioSourceConfigurations(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]AudioSourceConfig, 0, len(raw))
+	for _, a := range raw {
+		configs = append(configs, a.config())
+	}
+	return configs, nil
+}
+
+// GetAudioSourceConfigurationOptions returns the audio source tokens a
+// configuration may use. Either token may be empty.
+func (c *Client) GetAudioSourceConfigurationOptions(camera *Camera, configToken, profileToken string) ([]string, error) {
+	return c.GetAudioSourceConfigurationOptionsContext(context.Background(), camera, configToken, profileToken)
+}
+
+// GetAudioSourceConfigurationOptionsContext is like
+// GetAudioSourceConfigurationOptions but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetAudioSourceConfigurationOptionsContext(ctx context.Context, camera *Camera, configToken, profileToken string) ([]string, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioSourceConfigurationOptions",
+		buildOptionsBody("trt", "GetAudioSourceConfigurationOptions", configToken, profileToken))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Tokens []string `xml:"Body>GetAudioSourceConfigurationOptionsResponse>Options>InputTokensAvailable"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio source options: %v", err)
+	}
+	return trimAll(parsed.Tokens), nil
+}
+
+// SetAudioSourceConfiguration updates the audio source configuration
+// cfg.Token, changing only cfg's non-empty Name and SourceToken.
+func (c *Client) SetAudioSourceConfiguration(camera *Camera, cfg AudioSourceConfig) error {
+	return c.SetAudioSourceConfigurationContext(context.Background(), camera, cfg)
+}
+
+// SetAudioSourceConfigurationContext is like SetAudioSourceConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) SetAudioSourceConfigurationContext(ctx context.Context, camera *Camera, cfg AudioSourceConfig) error {
+	raw, err := c.getAudioSourceConfigurations(ctx, camera)
+	if err != nil {
+		return err
+	}
+	for _, a := range raw {
+		if a.Token != cfg.Token {
+			continue
+		}
+		if cfg.Name != "" {
+			a.Name = cfg.Name
+		}
+		if cfg.SourceToken != "" {
+			a.SourceToken = cfg.SourceToken
+		}
+		body := fmt.Sprintf(`<trt:SetAudioSourceConfiguration><trt:Configuration token="%s"><tt:Name>%s</tt:Name><tt:UseCount>%d</tt:UseCount>`+
+			`<tt:SourceToken>%s</tt:SourceToken></trt:Configuration><trt:ForcePersistence>true</trt:ForcePersistence></trt:SetAudioSourceConfiguration>`,
+			a.Token, escapeXML(a.Name), a.UseCount, strings.TrimSpace(a.SourceToken))
+		_, err := c.mediaCall(ctx, camera, "SetAudioSourceConfiguration", body)
+		return err
+	}
+	return fmt.Errorf("audio source configuration %q not found", cfg.Token)
+}
+
+// getAudioEncoderConfigurations fetches the raw audio encoder configurations.
+func (c *Client) getAudioEncoderConfigurations(ctx context.Context, camera *Camera) ([]audioEncoderXML, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioEncoderConfigurations", `<trt:GetAudioEncoderConfigurations/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Configs []audioEncoderXML `xml:"Body>GetAudioEncoderConfigurationsResponse>Configurations"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio encoder configurations: %v", err)
+	}
+	return parsed.Configs, nil
+}
+
+// GetAudioEncoderConfigurations returns every audio encoder configuration on
+// the device.
+func (c *Client) GetAudioEncoderConfigurations(camera *Camera) ([]AudioEncoderConfig, error) {
+	return c.GetAudioEncoderConfigurationsContext(context.Background(), camera)
+}
+
+// GetAudioEncoderConfigurationsContext is like GetAudioEncoderConfigurations
+// but uses ctx for cancellation and deadlines.
+func (c *Client) GetAudioEncoderConfigurationsContext(ctx context.Context, camera *Camera) ([]AudioEncoderConfig, error) {
+	raw, err := c.getAudioEncoderConfigurations(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]AudioEncoderConfig, 0, len(raw))
+	for _, a := range raw {
+		configs = append(configs, a.config())
+	}
+	return configs, nil
+}
+
+// GetAudioEncoderConfigurationOptions returns the encodings, bitrates and
+// sample rates an audio encoder configuration accepts. Either token may be
+// empty.
+func (c *Client) GetAudioEncoderConfigurationOptions(camera *Camera, configToken, profileToken string) ([]AudioEncodingOptions, error) {
+	return c.GetAudioEncoderConfigurationOptionsContext(context.Background(), camera, configToken, profileToken)
+}
+
+// GetAudioEncoderConfigurationOptionsContext is like
+// GetAudioEncoderConfigurationOptions but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetAudioEncoderConfigurationOptionsContext(ctx context.Context, camera *Camera, configToken, profileToken string) ([]AudioEncodingOptions, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioEncoderConfigurationOptions",
+		buildOptionsBody("trt", "GetAudioEncoderConfigurationOptions", configToken, profileToken))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Options []struct {
+			Encoding    string     `xml:"Encoding"`
+			Bitrates    intListXML `xml:"BitrateList"`
+			SampleRates intListXML `xml:"SampleRateList"`
+		} `xml:"Body>GetAudioEncoderConfigurationOptionsResponse>Options>Options"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio encoder options: %v", err)
+	}
+
+	opts := make([]AudioEncodingOptions, 0, len(parsed.Options))
+	for _, o := range parsed.Options {
+		opts = append(opts, AudioEncodingOptions{
+			Encoding:    strings.TrimSpace(o.Encoding),
+			Bitrates:    o.Bitrates.ints(),
+			SampleRates: o.SampleRates.ints(),
+		})
+	}
+	return opts, nil
+}
+
+// buildSetAudioEncoderBody renders a SetAudioEncoderConfiguration request in
+// schema order (Name, UseCount, Encoding, Bitrate, SampleRate, Multicast,
+// SessionTimeout).
+func buildSetAudioEncoderBody(a audioEncoderXML) string {
+	sessionTimeout := a.SessionTimeout
+	if sessionTimeout == "" {
+		sessionTimeout = "PT60S"
+	}
+	return fmt.Sprintf(`<trt:SetAudioEncoderConfiguration><trt:Configuration token="%s">`+
+		`<tt:Name>%s</tt:Name><tt:UseCount>%d</tt:UseCount><tt:Encoding>%s</tt:Encoding>`+
+		`<tt:Bitrate>%d</tt:Bitrate><tt:SampleRate>%d</tt:SampleRate>%s<tt:SessionTimeout>%s</tt:SessionTimeout>`+
+		`</trt:Configuration><trt:ForcePersistence>true</trt:ForcePersistence></trt:SetAudioEncoderConfiguration>`,
+		a.Token, escapeXML(a.Name), a.UseCount, a.Encoding, a.Bitrate, a.SampleRate,
+		buildMulticastXML(a.Multicast), sessionTimeout)
+}
+
+// SetAudioEncoderConfiguration updates the audio encoder configuration
+// cfg.Token. The current configuration is read first and only cfg's non-zero
+// fields are changed.
+func (c *Client) SetAudioEncoderConfiguration(camera *Camera, cfg AudioEncoderConfig) error {
+	return c.SetAudioEncoderConfigurationContext(context.Background(), camera, cfg)
+}
+
+// SetAudioEncoderConfigurationContext is like SetAudioEncoderConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) SetAudioEncoderConfigurationContext(ctx context.Context, camera *Camera, cfg AudioEncoderConfig) error {
+	raw, err := c.getAudioEncoderConfigurations(ctx, camera)
+	if err != nil {
+		return err
+	}
+	for _, a := range raw {
+		if a.Token == cfg.Token {
+			a.apply(cfg)
+			_, err := c.mediaCall(ctx, camera, "SetAudioEncoderConfiguration", buildSetAudioEncoderBody(a))
+			return err
+		}
+	}
+	return fmt.Errorf("audio encoder configuration %q not found", cfg.Token)
+}
+
+// GetAudioOutputs returns the tokens of the device's audio outputs.
+func (c *Client) GetAudioOutputs(camera *Camera) ([]string, error) {
+	return c.GetAudioOutputsContext(context.Background(), camera)
+}
+
+// GetAudioOutputsContext is like GetAudioOutputs but uses ctx for cancellation
+// and deadlines.
+func (c *Client) GetAudioOutputsContext(ctx context.Context, camera *Camera) ([]string, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioOutputs", `<trt:GetAudioOutputs/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Outputs []tokenRef `xml:"Body>GetAudioOutputsResponse>AudioOutputs"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio outputs: %v", err)
+	}
+
+	tokens := make([]string, 0, len(parsed.Outputs))
+	for _, o := range parsed.Outputs {
+		tokens = append(tokens, o.Token)
+	}
+	return tokens, nil
+}
+
+// getAudioOutputConfigurations fetches the raw audio output configurations.
+func (c *Client) getAudioOutputConfigurations(ctx context.Context, camera *Camera) ([]audioOutputConfigXML, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioOutputConfigurations", `<trt:GetAudioOutputConfigurations/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Configs []audioOutputConfigXML `xml:"Body>GetAudioOutputConfigurationsResponse>Configurations"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio output configurations: %v", err)
+	}
+	return parsed.Configs, nil
+}
+
+// GetAudioOutputConfigurations returns every audio output configuration on
+// the device.
+func (c *Client) GetAudioOutputConfigurations(camera *Camera) ([]AudioOutputConfig, error) {
+	return c.GetAudioOutputConfigurationsContext(context.Background(), camera)
+}
+
+// GetAudioOutputConfigurationsContext is like GetAudioOutputConfigurations but
+// uses ctx for cancellation and deadlines.
+func (c *Client) GetAudioOutputConfigurationsContext(ctx context.Context, camera *Camera) ([]AudioOutputConfig, error) {
+	raw, err := c.getAudioOutputConfigurations(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]AudioOutputConfig, 0, len(raw))
+	for _, a := range raw {
+		configs = append(configs, a.config())
+	}
+	return configs, nil
+}
+
+// GetAudioOutputConfigurationOptions returns the outputs, send primacy modes
+// and output level range an audio output configuration accepts. Either token
+// may be empty.
+func (c *Client) GetAudioOutputConfigurationOptions(camera *Camera, configToken, profileToken string) (*AudioOutputOptions, error) {
+	return c.GetAudioOutputConfigurationOptionsContext(context.Background(), camera, configToken, profileToken)
+}
+
+// GetAudioOutputConfigurationOptionsContext is like
+// GetAudioOutputConfigurationOptions but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetAudioOutputConfigurationOptionsContext(ctx context.Context, camera *Camera, configToken, profileToken string) (*AudioOutputOptions, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioOutputConfigurationOptions",
+		buildOptionsBody("trt", "GetAudioOutputConfigurationOptions", configToken, profileToken))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Options struct {
+			OutputTokens []string    `xml:"OutputTokensAvailable"`
+			SendPrimacy  []string    `xml:"SendPrimacyOptions"`
+			OutputLevel  intRangeXML `xml:"OutputLevelRange"`
+		} `xml:"Body>GetAudioOutputConfigurationOptionsResponse>Options"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio output options: %v", err)
+	}
+	o := parsed.Options
+	return &AudioOutputOptions{
+		OutputTokens:     trimAll(o.OutputTokens),
+		SendPrimacy:      trimAll(o.SendPrimacy),
+		OutputLevelRange: o.OutputLevel.intRange(),
+	}, nil
+}
+
+// SetAudioOutputConfiguration updates the audio output configuration
+// cfg.Token. Only cfg's non-empty fields (and OutputLevel when non-nil) are
+// changed.
+func (c *Client) SetAudioOutputConfiguration(camera *Camera, cfg AudioOutputConfig) error {
+	return c.SetAudioOutputConfigurationContext(context.Background(), camera, cfg)
+}
+
+// SetAudioOutputConfigurationContext is like SetAudioOutputConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) SetAudioOutputConfigurationContext(ctx context.Context, camera *Camera, cfg AudioOutputConfig) error {
+	raw, err := c.getAudioOutputConfigurations(ctx, camera)
+	if err != nil {
+		return err
+	}
+	for _, a := range raw {
+		if a.Token != cfg.Token {
+			continue
+		}
+		if cfg.Name != "" {
+			a.Name = cfg.Name
+		}
+		if cfg.OutputToken != "" {
+			a.OutputToken = cfg.OutputToken
+		}
+		if cfg.SendPrimacy != "" {
+			a.SendPrimacy = cfg.SendPrimacy
+		}
+		if cfg.OutputLevel != nil {
+			a.OutputLevel = *cfg.OutputLevel
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, `<trt:SetAudioOutputConfiguration><trt:Configuration token="%s"><tt:Name>%s</tt:Name><tt:UseCount>%d</tt:UseCount>`,
+			a.Token, escapeXML(a.Name), a.UseCount)
+		fmt.Fprintf(&b, `<tt:OutputToken>%s</tt:OutputToken>`, strings.TrimSpace(a.OutputToken))
+		if sp := strings.TrimSpace(a.SendPrimacy); sp != "" {
+			fmt.Fprintf(&b, `<tt:SendPrimacy>%s</tt:SendPrimacy>`, sp)
+		}
+		fmt.Fprintf(&b, `<tt:OutputLevel>%d</tt:OutputLevel>`, a.OutputLevel)
+		b.WriteString(`</trt:Configuration><trt:ForcePersistence>true</trt:ForcePersistence></trt:SetAudioOutputConfiguration>`)
+		_, err := c.mediaCall(ctx, camera, "SetAudioOutputConfiguration", b.String())
+		return err
+	}
+	return fmt.Errorf("audio output configuration %q not found", cfg.Token)
+}
+
+// GetAudioDecoderConfigurations returns every audio decoder configuration on
+// the device.
+func (c *Client) GetAudioDecoderConfigurations(camera *Camera) ([]AudioDecoderConfig, error) {
+	return c.GetAudioDecoderConfigurationsContext(context.Background(), camera)
+}
+
+// GetAudioDecoderConfigurationsContext is like GetAudioDecoderConfigurations
+// but uses ctx for cancellation and deadlines.
+func (c *Client) GetAudioDecoderConfigurationsContext(ctx context.Context, camera *Camera) ([]AudioDecoderConfig, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioDecoderConfigurations", `<trt:GetAudioDecoderConfigurations/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Configs []audioDecoderConfigXML `xml:"Body>GetAudioDecoderConfigurationsResponse>Configurations"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio decoder configurations: %v", err)
+	}
+
+	configs := make([]AudioDecoderConfig, 0, len(parsed.Configs))
+	for _, a := range parsed.Configs {
+		configs = append(configs, AudioDecoderConfig{Token: a.Token, Name: a.Name, UseCount: a.UseCount})
+	}
+	return configs, nil
+}
+
+// GetAudioDecoderConfigurationOptions returns the formats the audio decoder
+// can receive on the backchannel. Either token may be empty.
+func (c *Client) GetAudioDecoderConfigurationOptions(camera *Camera, configToken, profileToken string) ([]AudioEncodingOptions, error) {
+	return c.GetAudioDecoderConfigurationOptionsContext(context.Background(), camera, configToken, profileToken)
+}
+
+// GetAudioDecoderConfigurationOptionsContext is like
+// GetAudioDecoderConfigurationOptions but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetAudioDecoderConfigurationOptionsContext(ctx context.Context, camera *Camera, configToken, profileToken string) ([]AudioEncodingOptions, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetAudioDecoderConfigurationOptions",
+		buildOptionsBody("trt", "GetAudioDecoderConfigurationOptions", configToken, profileToken))
+	if err != nil {
+		return nil, err
+	}
+
+	type decOptions struct {
+		Bitrate    intListXML `xml:"Bitrate"`
+		SampleRate intListXML `xml:"SampleRateRange"`
+	}
+	var parsed struct {
+		Options struct {
+			AAC  *decOptions `xml:"AACDecOptions"`
+			G711 *decOptions `xml:"G711DecOptions"`
+			G726 *decOptions `xml:"G726DecOptions"`
+		} `xml:"Body>GetAudioDecoderConfigurationOptionsResponse>Options"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse audio decoder options: %v", err)
+	}
+
+	var opts []AudioEncodingOptions
+	for _, d := range []struct {
+		encoding string
+		options  *decOptions
+	}{
+		{AudioEncodingAAC, parsed.Options.AAC},
+		{AudioEncodingG711, parsed.Options.G711},
+		{AudioEncodingG726, parsed.Options.G726},
+	} {
+		if d.options != nil {
+			opts = append(opts, AudioEncodingOptions{
+				Encoding:    d.encoding,
+				Bitrates:    d.options.Bitrate.ints(),
+				SampleRates: d.options.SampleRate.ints(),
+			})
+		}
+	}
+	return opts, nil
+}
+
+// SetAudioDecoderConfiguration renames the audio decoder configuration
+// cfg.Token, the only writable field.
+func (c *Client) SetAudioDecoderConfiguration(camera *Camera, cfg AudioDecoderConfig) error {
+	return c.SetAudioDecoderConfigurationContext(context.Background(), camera, cfg)
+}
+
+// SetAudioDecoderConfigurationContext is like SetAudioDecoderConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) SetAudioDecoderConfigurationContext(ctx context.Context, camera *Camera, cfg AudioDecoderConfig) error {
+	configs, err := c.GetAudioDecoderConfigurationsContext(ctx, camera)
+	if err != nil {
+		return err
+	}
+	for _, a := range configs {
+		if a.Token != cfg.Token {
+			continue
+		}
+		if cfg.Name != "" {
+			a.Name = cfg.Name
+		}
+		body := fmt.Sprintf(`<trt:SetAudioDecoderConfiguration><trt:Configuration token="%s"><tt:Name>%s</tt:Name><tt:UseCount>%d</tt:UseCount>`+
+			`</trt:Configuration><trt:ForcePersistence>true</trt:ForcePersistence></trt:SetAudioDecoderConfiguration>`,
+			a.Token, escapeXML(a.Name), a.UseCount)
+		_, err := c.mediaCall(ctx, camera, "SetAudioDecoderConfiguration", body)
+		return err
+	}
+	return fmt.Errorf("audio decoder configuration %q not found", cfg.Token)
+}
@@ -0,0 +1,96 @@
+package onvif
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAudioConfiguration(t *testing.T) {
+	var gotAction, gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotAction, gotBody = action, body
+		switch action {
+		case "http://www.onvif.org/ver10/media/wsdl/GetAudioEncoderConfigurations":
+			return `<trt:GetAudioEncoderConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+<trt:Configurations token="aec0"><tt:Name>Audio</tt:Name><tt:UseCount>2</tt:UseCount><tt:Encoding>G711</tt:Encoding>
+<tt:Bitrate>64</tt:Bitrate><tt:SampleRate>8</tt:SampleRate>
+<tt:Multicast><tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>0.0.0.0</tt:IPv4Address></tt:Address><tt:Port>0</tt:Port><tt:TTL>5</tt:TTL><tt:AutoStart>false</tt:AutoStart></tt:Multicast>
+<tt:SessionTimeout>PT30S</tt:SessionTimeout></trt:Configurations></trt:GetAudioEncoderConfigurationsResponse>`
+		case "http://www.onvif.org/ver10/media/wsdl/GetAudioEncoderConfigurationOptions":
+			return `<trt:GetAudioEncoderConfigurationOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:Options>
+<tt:Options><tt:Encoding>G711</tt:Encoding><tt:BitrateList><tt:Items>64</tt:Items></tt:BitrateList><tt:SampleRateList><tt:Items>8</tt:Items></tt:SampleRateList></tt:Options>
+<tt:Options><tt:Encoding>AAC</tt:Encoding><tt:BitrateList><tt:Items>32 64 128</tt:Items></tt:BitrateList><tt:SampleRateList><tt:Items>16 48</tt:Items></tt:SampleRateList></tt:Options>
+</trt:Options></trt:GetAudioEncoderConfigurationOptionsResponse>`
+		case "http://www.onvif.org/ver10/media/wsdl/GetAudioOutputConfigurations":
+			return `<trt:GetAudioOutputConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+<trt:Configurations token="aoc0"><tt:Name>Speaker</tt:Name><tt:UseCount>1</tt:UseCount><tt:OutputToken>ao0</tt:OutputToken><tt:OutputLevel>50</tt:OutputLevel></trt:Configurations>
+</trt:GetAudioOutputConfigurationsResponse>`
+		case "http://www.onvif.org/ver10/media/wsdl/GetAudioDecoderConfigurationOptions":
+			return `<trt:GetAudioDecoderConfigurationOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:Options>
+<tt:G711DecOptions><tt:Bitrate><tt:Items>64</tt:Items></tt:Bitrate><tt:SampleRateRange><tt:Items>8</tt:Items></tt:SampleRateRange></tt:G711DecOptions>
+</trt:Options></trt:GetAudioDecoderConfigurationOptionsResponse>`
+		}
+		return `<trt:Response xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+	})
+	camera := &Camera{MediaURL: "http://192.0.2.10/onvif/media"}
+
+	encoders, err := c.GetAudioEncoderConfigurations(camera)
+	if err != nil {
+		t.Fatalf("GetAudioEncoderConfigurations() error = %v", err)
+	}
+	if len(encoders) != 1 || encoders[0].Encoding != AudioEncodingG711 || encoders[0].Bitrate != 64 || encoders[0].SampleRate != 8 {
+		t.Errorf("GetAudioEncoderConfigurations() = %+v", encoders)
+	}
+
+	opts, err := c.GetAudioEncoderConfigurationOptions(camera, "aec0", "")
+	if err != nil {
+		t.Fatalf("GetAudioEncoderConfigurationOptions() error = %v", err)
+	}
+	want := []AudioEncodingOptions{
+		{Encoding: "G711", Bitrates: []int{64}, SampleRates: []int{8}},
+		{Encoding: "AAC", Bitrates: []int{32, 64, 128}, SampleRates: []int{16, 48}},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("GetAudioEncoderConfigurationOptions() = %+v", opts)
+	}
+	if !strings.Contains(gotBody, `<trt:ConfigurationToken>aec0</trt:ConfigurationToken>`) || strings.Contains(gotBody, "ProfileToken") {
+		t.Errorf("options body = %s", gotBody)
+	}
+
+	if err := c.SetAudioEncoderConfiguration(camera, AudioEncoderConfig{Token: "aec0", Encoding: AudioEncodingAAC, Bitrate: 128, SampleRate: 48}); err != nil {
+		t.Fatalf("SetAudioEncoderConfiguration() error = %v", err)
+	}
+	wantBody := `<trt:Configuration token="aec0"><tt:Name>Audio</tt:Name><tt:UseCount>2</tt:UseCount><tt:Encoding>AAC</tt:Encoding>` +
+		`<tt:Bitrate>128</tt:Bitrate><tt:SampleRate>48</tt:SampleRate>` +
+		`<tt:Multicast><tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>0.0.0.0</tt:IPv4Address></tt:Address><tt:Port>0</tt:Port><tt:TTL>5</tt:TTL><tt:AutoStart>false</tt:AutoStart></tt:Multicast>` +
+		`<tt:SessionTimeout>PT30S</tt:SessionTimeout></trt:Configuration>`
+	if !strings.HasSuffix(gotAction, "/SetAudioEncoderConfiguration") || !strings.Contains(gotBody, wantBody) {
+		t.Errorf("SetAudioEncoderConfiguration body = %s\nwant %s", gotBody, wantBody)
+	}
+
+	outputs, err := c.GetAudioOutputConfigurations(camera)
+	if err != nil || len(outputs) != 1 || outputs[0].OutputToken != "ao0" || outputs[0].OutputLevel == nil || *outputs[0].OutputLevel != 50 {
+		t.Fatalf("GetAudioOutputConfigurations() = %+v, %v", outputs, err)
+	}
+	level := 0
+	if err := c.SetAudioOutputConfiguration(camera, AudioOutputConfig{Token: "aoc0", OutputLevel: &level}); err != nil {
+		t.Fatalf("SetAudioOutputConfiguration() error = %v", err)
+	}
+	wantBody = `<tt:Name>Speaker</tt:Name><tt:UseCount>1</tt:UseCount><tt:OutputToken>ao0</tt:OutputToken><tt:OutputLevel>0</tt:OutputLevel></trt:Configuration>`
+	if !strings.Contains(gotBody, wantBody) {
+		t.Errorf("SetAudioOutputConfiguration body = %s", gotBody)
+	}
+
+	dec, err := c.GetAudioDecoderConfigurationOptions(camera, "", "")
+	if err != nil || !reflect.DeepEqual(dec, []AudioEncodingOptions{{Encoding: "G711", Bitrates: []int{64}, SampleRates: []int{8}}}) {
+		t.Errorf("GetAudioDecoderConfigurationOptions() = %+v, %v", dec, err)
+	}
+
+	if err := c.AddAudioDecoderConfiguration(camera, "prof0", "adc0"); err != nil {
+		t.Fatalf("AddAudioDecoderConfiguration() error = %v", err)
+	}
+	if gotAction != "http://www.onvif.org/ver10/media/wsdl/AddAudioDecoderConfiguration" {
+		t.Errorf("AddAudioDecoderConfiguration action = %s", gotAction)
+	}
+}
@@ -2,7 +2,9 @@
 package onvif
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 )
@@ -25,6 +27,51 @@ func NewClientWithTimeout(username, password string, timeout time.Duration) *Cli
 	}
 }
 
+// transport returns the shared HTTP client used for all SOAP and snapshot
+// requests, building it on first use.
+func (c *Client) transport() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	c.httpOnce.Do(func() {
+		timeout := c.Timeout
+		if timeout == 0 {
+			timeout = 10 * time.Second
+		}
+		rt := c.Transport
+		if rt == nil {
+			rt = c.newTransport()
+		}
+		c.httpClient = &http.Client{Transport: rt, Timeout: timeout}
+	})
+	return c.httpClient
+}
+
+// newTransport builds the pooled transport from the client's TLS and proxy
+// settings. It starts from a clone of http.DefaultTransport so keep-alive and
+// idle-connection defaults match the standard library.
+func (c *Client) newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: c.InsecureTLS,
+		RootCAs:            c.RootCAs,
+		Certificates:       c.Certificates,
+	}
+	if c.Proxy != nil {
+		t.Proxy = c.Proxy
+	}
+	// A fleet poller talks to each camera repeatedly; keep a few more idle
+	// connections per host than the default of 2.
+	t.MaxIdleConnsPerHost = 4
+	return t
+}
+
+// CloseIdleConnections closes any idle keep-alive connections held by the
+// client's transport. It does not interrupt connections in use.
+func (c *Client) CloseIdleConnections() {
+	c.transport().CloseIdleConnections()
+}
+
 // GetCameraInfo returns a formatted string with camera information
 func (camera *Camera) GetCameraInfo() string {
 	info := fmt.Sprintf("Camera: %s\n", camera.GetDisplayName())
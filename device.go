@@ -1,6 +1,7 @@
 package onvif
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strings"
@@ -9,11 +10,17 @@ import (
 
 // GetDeviceInformation fetches comprehensive device information
 func (c *Client) GetDeviceInformation(camera *Camera) error {
+	return c.GetDeviceInformationContext(context.Background(), camera)
+}
+
+// GetDeviceInformationContext is like GetDeviceInformation but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetDeviceInformationContext(ctx context.Context, camera *Camera) error {
 	address := getFirstAddress(camera.Address)
 
 	// Get device information
 	deviceInfoBody := `<tds:GetDeviceInformation/>`
-	deviceInfoResp, err := c.sendSOAPRequest(address,
+	deviceInfoResp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/GetDeviceInformation", deviceInfoBody)
 
 	if err == nil && parseSOAPFault(deviceInfoResp) == nil {
@@ -36,23 +43,29 @@ func (c *Client) GetDeviceInformation(camera *Camera) error {
 	}
 
 	// Get hostname
-	c.GetHostname(camera)
+	c.GetHostnameContext(ctx, camera)
 
 	// Get system date and time
-	c.GetSystemDateTime(camera)
+	c.GetSystemDateTimeContext(ctx, camera)
 
 	// Get capabilities
-	c.GetCapabilities(camera)
+	c.GetCapabilitiesContext(ctx, camera)
 
 	return nil
 }
 
 // GetHostname fetches the device hostname
 func (c *Client) GetHostname(camera *Camera) error {
+	return c.GetHostnameContext(context.Background(), camera)
+}
+
+// GetHostnameContext is like GetHostname but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetHostnameContext(ctx context.Context, camera *Camera) error {
 	address := getFirstAddress(camera.Address)
 
 	hostnameBody := `<tds:GetHostname/>`
-	hostnameResp, err := c.sendSOAPRequest(address,
+	hostnameResp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/GetHostname", hostnameBody)
 
 	if err == nil && parseSOAPFault(hostnameResp) == nil {
@@ -79,10 +92,16 @@ func (c *Client) GetHostname(camera *Camera) error {
 
 // GetSystemDateTime fetches the system date and time
 func (c *Client) GetSystemDateTime(camera *Camera) error {
+	return c.GetSystemDateTimeContext(context.Background(), camera)
+}
+
+// GetSystemDateTimeContext is like GetSystemDateTime but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetSystemDateTimeContext(ctx context.Context, camera *Camera) error {
 	address := getFirstAddress(camera.Address)
 
 	dateTimeBody := `<tds:GetSystemDateAndTime/>`
-	dateTimeResp, err := c.sendSOAPRequest(address,
+	dateTimeResp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/GetSystemDateAndTime", dateTimeBody)
 
 	if err == nil {
@@ -124,6 +143,12 @@ func (c *Client) GetSystemDateTime(camera *Camera) error {
 
 // SetSystemDateTime sets the system date and time to current GMT
 func (c *Client) SetSystemDateTime(camera *Camera) error {
+	return c.SetSystemDateTimeContext(context.Background(), camera)
+}
+
+// SetSystemDateTimeContext is like SetSystemDateTime but uses ctx for
+// cancellation and deadlines.
+func (c *Client) SetSystemDateTimeContext(ctx context.Context, camera *Camera) error {
 	address := getFirstAddress(camera.Address)
 	now := time.Now().UTC()
 
@@ -153,7 +178,7 @@ func (c *Client) SetSystemDateTime(camera *Camera) error {
 		now.Hour(), now.Minute(), now.Second(),
 		now.Year(), int(now.Month()), now.Day())
 
-	setResp, err := c.sendSOAPRequest(address,
+	setResp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/SetSystemDateAndTime", setDateTimeBody)
 	if err != nil {
 		return fmt.Errorf("failed to set date/time: %v", err)
@@ -168,10 +193,16 @@ func (c *Client) SetSystemDateTime(camera *Camera) error {
 
 // SetHostname sets the device hostname
 func (c *Client) SetHostname(camera *Camera, name string) error {
+	return c.SetHostnameContext(context.Background(), camera, name)
+}
+
+// SetHostnameContext is like SetHostname but uses ctx for cancellation and
+// deadlines.
+func (c *Client) SetHostnameContext(ctx context.Context, camera *Camera, name string) error {
 	address := getFirstAddress(camera.Address)
 
 	body := fmt.Sprintf(`<tds:SetHostname><tds:Name>%s</tds:Name></tds:SetHostname>`, name)
-	resp, err := c.sendSOAPRequest(address,
+	resp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/SetHostname", body)
 	if err != nil {
 		return fmt.Errorf("failed to set hostname: %v", err)
@@ -191,10 +222,16 @@ func (c *Client) SetHostname(camera *Camera, name string) error {
 // event capabilities, etc.) can parse these bytes themselves. On a SOAP fault
 // the response bytes are still returned alongside the error.
 func (c *Client) GetCapabilitiesRaw(camera *Camera) ([]byte, error) {
+	return c.GetCapabilitiesRawContext(context.Background(), camera)
+}
+
+// GetCapabilitiesRawContext is like GetCapabilitiesRaw but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetCapabilitiesRawContext(ctx context.Context, camera *Camera) ([]byte, error) {
 	address := getFirstAddress(camera.Address)
 
 	capabilitiesBody := `<tds:GetCapabilities><tds:Category>All</tds:Category></tds:GetCapabilities>`
-	resp, err := c.sendSOAPRequest(address,
+	resp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/GetCapabilities", capabilitiesBody)
 	if err != nil {
 		return nil, err
@@ -207,17 +244,15 @@ func (c *Client) GetCapabilitiesRaw(camera *Camera) ([]byte, error) {
 
 // GetCapabilities fetches device capabilities
 func (c *Client) GetCapabilities(camera *Camera) error {
-	capabilitiesResp, _ := c.GetCapabilitiesRaw(camera)
-
-	if capabilitiesResp != nil {
-		respStr := string(capabilitiesResp)
-
-		// Check for PTZ support
-		camera.PTZSupport = strings.Contains(respStr, "PTZ>") && strings.Contains(respStr, "XAddr")
+	return c.GetCapabilitiesContext(context.Background(), camera)
+}
 
-		// Check for Analytics support
-		camera.AnalyticsSupport = strings.Contains(respStr, "Analytics>") && strings.Contains(respStr, "XAddr")
+// GetCapabilitiesContext is like GetCapabilities but uses ctx for cancellation
+// and deadlines.
+func (c *Client) GetCapabilitiesContext(ctx context.Context, camera *Camera) error {
+	capabilitiesResp, _ := c.GetCapabilitiesRawContext(ctx, camera)
 
+	if capabilitiesResp != nil {
 		// Parse capabilities including service URLs
 		type CapabilitiesResponse struct {
 			Media struct {
@@ -235,6 +270,12 @@ func (c *Client) GetCapabilities(camera *Camera) error {
 			PTZ struct {
 				XAddr string `xml:"XAddr"`
 			} `xml:"Body>GetCapabilitiesResponse>Capabilities>PTZ"`
+			Events struct {
+				XAddr string `xml:"XAddr"`
+			} `xml:"Body>GetCapabilitiesResponse>Capabilities>Events"`
+			Analytics struct {
+				XAddr string `xml:"XAddr"`
+			} `xml:"Body>GetCapabilitiesResponse>Capabilities>Analytics"`
 			Device struct {
 				IO struct {
 					RelayOutputs int `xml:"RelayOutputs,attr"`
@@ -255,9 +296,16 @@ func (c *Client) GetCapabilities(camera *Camera) error {
 			if capabilities.Imaging.XAddr != "" {
 				camera.ImagingURL = capabilities.Imaging.XAddr
 			}
-			if capabilities.PTZ.XAddr != "" {
+			camera.PTZSupport = capabilities.PTZ.XAddr != ""
+			if camera.PTZSupport {
 				camera.PTZURL = capabilities.PTZ.XAddr
-				camera.PTZSupport = true
+			}
+			if capabilities.Events.XAddr != "" {
+				camera.EventsURL = capabilities.Events.XAddr
+			}
+			camera.AnalyticsSupport = capabilities.Analytics.XAddr != ""
+			if camera.AnalyticsSupport {
+				camera.AnalyticsURL = capabilities.Analytics.XAddr
 			}
 		}
 		// Service URLs that GetCapabilities does not report (notably Media2) are
@@ -273,10 +321,16 @@ func (c *Client) GetCapabilities(camera *Camera) error {
 // real host/port/path — important for cameras (e.g. Reolink) that serve ONVIF
 // services on a non-default port and distinct paths.
 func (c *Client) GetServices(camera *Camera) error {
+	return c.GetServicesContext(context.Background(), camera)
+}
+
+// GetServicesContext is like GetServices but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetServicesContext(ctx context.Context, camera *Camera) error {
 	address := getFirstAddress(camera.Address)
 
 	body := `<tds:GetServices><tds:IncludeCapability>false</tds:IncludeCapability></tds:GetServices>`
-	resp, err := c.sendSOAPRequest(address,
+	resp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/GetServices", body)
 	if err != nil {
 		return fmt.Errorf("failed to get services: %v", err)
@@ -312,30 +366,48 @@ func (c *Client) GetServices(camera *Camera) error {
 				camera.PTZURL = s.XAddr
 				camera.PTZSupport = true
 			}
+		case "http://www.onvif.org/ver10/events/wsdl":
+			if camera.EventsURL == "" {
+				camera.EventsURL = s.XAddr
+			}
+		case "http://www.onvif.org/ver20/analytics/wsdl":
+			if camera.AnalyticsURL == "" {
+				camera.AnalyticsURL = s.XAddr
+				camera.AnalyticsSupport = true
+			}
 		}
 	}
 	return nil
 }
 
 // discoverServices populates the camera's service URLs (Media / Media2 /
-// Imaging) using the device's own advertisements: GetCapabilities first, then
+// Imaging / PTZ / Events / Analytics) using the device's own advertisements: GetCapabilities first, then
 // GetServices for anything still missing (notably Media2, and the real
 // host/port for cameras that serve ONVIF off the default endpoint). Best-effort
-// — anything still unset is left to a per-service heuristic fallback.
-func (c *Client) discoverServices(camera *Camera) {
-	if camera.MediaURL == "" || camera.ImagingURL == "" {
-		_ = c.GetCapabilities(camera)
+// — anything still unset is left to a per-service heuristic fallback. Once the
+// device has answered, later calls return at once.
+func (c *Client) discoverServices(ctx context.Context, camera *Camera) {
+	if camera.servicesDiscovered {
+		return
 	}
-	if camera.MediaURL == "" || camera.ImagingURL == "" || camera.Media2URL == "" || camera.PTZURL == "" {
-		_ = c.GetServices(camera)
+	if camera.MediaURL == "" || camera.ImagingURL == "" || camera.EventsURL == "" {
+		if c.GetCapabilitiesContext(ctx, camera) == nil {
+			camera.servicesDiscovered = true
+		}
+	}
+	if camera.MediaURL == "" || camera.ImagingURL == "" || camera.Media2URL == "" || camera.PTZURL == "" ||
+		camera.EventsURL == "" || camera.AnalyticsURL == "" {
+		if c.GetServicesContext(ctx, camera) == nil {
+			camera.servicesDiscovered = true
+		}
 	}
 }
 
 // resolveMediaURL returns the Media (ver10) service URL, discovering it if
 // needed and falling back to a heuristic rewrite of the device-service address.
-func (c *Client) resolveMediaURL(camera *Camera) string {
+func (c *Client) resolveMediaURL(ctx context.Context, camera *Camera) string {
 	if camera.MediaURL == "" {
-		c.discoverServices(camera)
+		c.discoverServices(ctx, camera)
 	}
 	if camera.MediaURL != "" {
 		return camera.MediaURL
@@ -345,9 +417,9 @@ func (c *Client) resolveMediaURL(camera *Camera) string {
 
 // resolveImagingURL returns the Imaging service URL, discovering it if needed
 // and falling back to a heuristic rewrite of the device-service address.
-func (c *Client) resolveImagingURL(camera *Camera) string {
+func (c *Client) resolveImagingURL(ctx context.Context, camera *Camera) string {
 	if camera.ImagingURL == "" {
-		c.discoverServices(camera)
+		c.discoverServices(ctx, camera)
 	}
 	if camera.ImagingURL != "" {
 		return camera.ImagingURL
@@ -357,9 +429,9 @@ func (c *Client) resolveImagingURL(camera *Camera) string {
 
 // resolveMedia2URL returns the Media2 (ver20) service URL, discovering it if
 // needed and falling back to a heuristic rewrite of the device-service address.
-func (c *Client) resolveMedia2URL(camera *Camera) string {
+func (c *Client) resolveMedia2URL(ctx context.Context, camera *Camera) string {
 	if camera.Media2URL == "" {
-		c.discoverServices(camera)
+		c.discoverServices(ctx, camera)
 	}
 	if camera.Media2URL != "" {
 		return camera.Media2URL
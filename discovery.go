@@ -1,6 +1,7 @@
 package onvif
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net"
@@ -67,6 +68,14 @@ type endpointRef struct {
 
 // DiscoverCameras discovers ONVIF cameras on the network
 func DiscoverCameras(options *DiscoveryOptions) ([]Camera, error) {
+	return DiscoverCamerasContext(context.Background(), options)
+}
+
+// DiscoverCamerasContext is like DiscoverCameras but stops listening for probe
+// matches as soon as ctx is done. A ctx deadline earlier than options.Timeout
+// shortens the listen window; on cancellation the cameras found so far are
+// returned together with ctx.Err().
+func DiscoverCamerasContext(ctx context.Context, options *DiscoveryOptions) ([]Camera, error) {
 	if options == nil {
 		options = &DiscoveryOptions{
 			Timeout:       DefaultTimeout,
@@ -93,10 +102,25 @@ func DiscoverCameras(options *DiscoveryOptions) ([]Camera, error) {
 	}
 	defer conn.Close()
 
-	if err := conn.SetReadDeadline(time.Now().Add(options.Timeout)); err != nil {
+	deadline := time.Now().Add(options.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
 		return nil, fmt.Errorf("failed to set read deadline: %v", err)
 	}
 
+	// Unblock the read loop as soon as ctx is cancelled.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetReadDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
 	if _, err := conn.WriteToUDP([]byte(probeMessage), addr); err != nil {
 		return nil, fmt.Errorf("failed to send probe message: %v", err)
 	}
@@ -110,6 +134,9 @@ func DiscoverCameras(options *DiscoveryOptions) ([]Camera, error) {
 			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 				break
 			}
+			if ctx.Err() != nil {
+				break
+			}
 			continue
 		}
 
@@ -135,11 +162,20 @@ func DiscoverCameras(options *DiscoveryOptions) ([]Camera, error) {
 		}
 	}
 
+	if err := ctx.Err(); err != nil && err != context.DeadlineExceeded {
+		return deduplicateCameras(cameras), err
+	}
 	return deduplicateCameras(cameras), nil
 }
 
 // DiscoverWithDetails discovers cameras and fetches additional information
 func (c *Client) DiscoverWithDetails() ([]Camera, error) {
+	return c.DiscoverWithDetailsContext(context.Background())
+}
+
+// DiscoverWithDetailsContext is like DiscoverWithDetails but uses ctx for the
+// discovery probe and every per-camera device query.
+func (c *Client) DiscoverWithDetailsContext(ctx context.Context) ([]Camera, error) {
 	options := &DiscoveryOptions{
 		Timeout:           DefaultTimeout,
 		MulticastAddr:     DefaultMulticastAddr,
@@ -148,14 +184,17 @@ func (c *Client) DiscoverWithDetails() ([]Camera, error) {
 		FetchCapabilities: true,
 	}
 
-	cameras, err := DiscoverCameras(options)
+	cameras, err := DiscoverCamerasContext(ctx, options)
 	if err != nil {
 		return nil, err
 	}
 
 	// Fetch additional details for each camera
 	for i := range cameras {
-		c.GetDeviceInformation(&cameras[i])
+		if ctx.Err() != nil {
+			return cameras, ctx.Err()
+		}
+		c.GetDeviceInformationContext(ctx, &cameras[i])
 	}
 
 	return cameras, nil
@@ -0,0 +1,588 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WS-Addressing actions for the Events service and WS-BaseNotification
+// subscription manager. Unlike the other services, event endpoints dispatch on
+// the wsa:Action header, so these must be the exact WSDL action URIs.
+const (
+	actionGetEventProperties          = "http://www.onvif.org/ver10/events/wsdl/EventPortType/GetEventPropertiesRequest"
+	actionCreatePullPointSubscription = "http://www.onvif.org/ver10/events/wsdl/EventPortType/CreatePullPointSubscriptionRequest"
+	actionPullMessages                = "http://www.onvif.org/ver10/events/wsdl/PullPointSubscription/PullMessagesRequest"
+	actionSetSynchronizationPoint     = "http://www.onvif.org/ver10/events/wsdl/PullPointSubscription/SetSynchronizationPointRequest"
+	actionSubscribe                   = "http://docs.oasis-open.org/wsn/bw-2/NotificationProducer/SubscribeRequest"
+	actionRenew                       = "http://docs.oasis-open.org/wsn/bw-2/SubscriptionManager/RenewRequest"
+	actionUnsubscribe                 = "http://docs.oasis-open.org/wsn/bw-2/SubscriptionManager/UnsubscribeRequest"
+)
+
+// topicExpressionConcreteSet is the ONVIF topic expression dialect, which
+// allows "|"-separated alternatives and "//." to match a whole subtree.
+const topicExpressionConcreteSet = "http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet"
+
+// eventsURLHeuristic derives a likely Events service URL from the
+// device-service address. Used only when service discovery reported none.
+func eventsURLHeuristic(address string) string {
+	url := strings.Replace(address, "/device_service", "/event_service", 1)
+	if !strings.Contains(url, "event_service") {
+		url = strings.Replace(address, "/onvif/device_service", "/onvif/event_service", 1)
+	}
+	return url
+}
+
+// resolveEventsURL returns the Events service URL, discovering it if needed
+// and falling back to a heuristic rewrite of the device-service address.
+func (c *Client) resolveEventsURL(ctx context.Context, camera *Camera) string {
+	if camera.EventsURL == "" {
+		c.discoverServices(ctx, camera)
+	}
+	if camera.EventsURL != "" {
+		return camera.EventsURL
+	}
+	return eventsURLHeuristic(getFirstAddress(camera.Address))
+}
+
+// simpleItemsXML is a list of <SimpleItem Name=".." Value=".."/> elements.
+type simpleItemsXML struct {
+	Items []struct {
+		Name  string `xml:"Name,attr"`
+		Value string `xml:"Value,attr"`
+	} `xml:"SimpleItem"`
+}
+
+func (s simpleItemsXML) items() []SimpleItem {
+	if len(s.Items) == 0 {
+		return nil
+	}
+	out := make([]SimpleItem, 0, len(s.Items))
+	for _, it := range s.Items {
+		out = append(out, SimpleItem{Name: it.Name, Value: it.Value})
+	}
+	return out
+}
+
+// notificationMessageXML is the parsed form of a wsnt:NotificationMessage.
+// PullMessages responses and Notify requests carry the same element.
+type notificationMessageXML struct {
+	SubscriptionReference string `xml:"SubscriptionReference>Address"`
+	Topic                 string `xml:"Topic"`
+	ProducerReference     string `xml:"ProducerReference>Address"`
+	Message               struct {
+		UtcTime           string         `xml:"UtcTime,attr"`
+		PropertyOperation string         `xml:"PropertyOperation,attr"`
+		Source            simpleItemsXML `xml:"Source"`
+		Key               simpleItemsXML `xml:"Key"`
+		Data              simpleItemsXML `xml:"Data"`
+	} `xml:"Message>Message"`
+}
+
+func (n notificationMessageXML) message() NotificationMessage {
+	return NotificationMessage{
+		Topic:                 strings.TrimSpace(n.Topic),
+		SubscriptionReference: strings.TrimSpace(n.SubscriptionReference),
+		ProducerReference:     strings.TrimSpace(n.ProducerReference),
+		UtcTime:               parseXSDateTime(n.Message.UtcTime),
+		PropertyOperation:     n.Message.PropertyOperation,
+		Source:                n.Message.Source.items(),
+		Key:                   n.Message.Key.items(),
+		Data:                  n.Message.Data.items(),
+	}
+}
+
+// subscriptionReferenceXML is a wsa:EndpointReference identifying a
+// subscription. ReferenceParameters is kept as raw XML so it can be echoed
+// back in the SOAP header of later calls.
+type subscriptionReferenceXML struct {
+	Address             string `xml:"Address"`
+	ReferenceParameters struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"ReferenceParameters"`
+}
+
+// subscription builds an EventSubscription from the reference and the
+// CurrentTime/TerminationTime returned alongside it. The reference parameters
+// get declarations, from namespaces, for prefixes the response bound on an
+// ancestor element.
+func (r subscriptionReferenceXML) subscription(namespaces map[string]string, currentTime, terminationTime string) (*EventSubscription, error) {
+	address := strings.TrimSpace(r.Address)
+	if address == "" {
+		return nil, fmt.Errorf("no subscription reference in response")
+	}
+	return &EventSubscription{
+		Address:             address,
+		ReferenceParameters: withNamespaces(strings.TrimSpace(r.ReferenceParameters.Inner), namespaces),
+		CurrentTime:         parseXSDateTime(currentTime),
+		TerminationTime:     parseXSDateTime(terminationTime),
+	}, nil
+}
+
+// buildTopicFilterXML renders a topic filter in the ConcreteSet dialect, or ""
+// when no filter is requested. The tns1 prefix (ONVIF topic namespace) is
+// declared on the element since filters almost always use it.
+func buildTopicFilterXML(elem, filter string) string {
+	if strings.TrimSpace(filter) == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<%s><wsnt:TopicExpression Dialect="%s" xmlns:tns1="http://www.onvif.org/ver10/topics">%s</wsnt:TopicExpression></%s>`,
+		elem, topicExpressionConcreteSet, escapeXML(filter), elem)
+}
+
+// subscriptionCall sends a request to a subscription's manager endpoint with
+// the WS-Addressing headers (including the reference parameters) it expects.
+func (c *Client) subscriptionCall(ctx context.Context, sub *EventSubscription, op, action, body string) ([]byte, error) {
+	if sub == nil || sub.Address == "" {
+		return nil, fmt.Errorf("%s failed: subscription has no address", op)
+	}
+	resp, err := c.sendSOAPRequestWithHeader(ctx, sub.Address, action,
+		addressingHeader(action, sub.Address, sub.ReferenceParameters), body)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %v", op, err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, fmt.Errorf("%s failed: %w", op, err)
+	}
+	return resp, nil
+}
+
+// CreatePullPointSubscription creates a PullPoint subscription on the camera's
+// Events service. filter is a ConcreteSet topic expression (e.g.
+// "tns1:RuleEngine//.|tns1:VideoSource//.") or "" for every topic;
+// terminationTime is the requested lifetime, which must be extended with Renew.
+func (c *Client) CreatePullPointSubscription(camera *Camera, filter string, terminationTime time.Duration) (*EventSubscription, error) {
+	return c.CreatePullPointSubscriptionContext(context.Background(), camera, filter, terminationTime)
+}
+
+// CreatePullPointSubscriptionContext is like CreatePullPointSubscription but
+// uses ctx for cancellation and deadlines.
+func (c *Client) CreatePullPointSubscriptionContext(ctx context.Context, camera *Camera, filter string, terminationTime time.Duration) (*EventSubscription, error) {
+	eventsURL := c.resolveEventsURL(ctx, camera)
+
+	termination := ""
+	if terminationTime > 0 {
+		termination = fmt.Sprintf(`<tev:InitialTerminationTime>%s</tev:InitialTerminationTime>`, formatXSDuration(terminationTime))
+	}
+	body := fmt.Sprintf(`<tev:CreatePullPointSubscription>%s%s</tev:CreatePullPointSubscription>`,
+		buildTopicFilterXML("tev:Filter", filter), termination)
+
+	resp, err := c.sendSOAPRequestWithHeader(ctx, eventsURL, actionCreatePullPointSubscription,
+		addressingHeader(actionCreatePullPointSubscription, eventsURL, ""), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull point subscription: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Reference       subscriptionReferenceXML `xml:"Body>CreatePullPointSubscriptionResponse>SubscriptionReference"`
+		CurrentTime     string                   `xml:"Body>CreatePullPointSubscriptionResponse>CurrentTime"`
+		TerminationTime string                   `xml:"Body>CreatePullPointSubscriptionResponse>TerminationTime"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse pull point subscription: %v", err)
+	}
+	return parsed.Reference.subscription(declaredNamespaces(resp), parsed.CurrentTime, parsed.TerminationTime)
+}
+
+// PullMessages fetches pending events from a PullPoint subscription. The camera
+// holds the request for up to timeout waiting for events, so timeout must be
+// shorter than the client's HTTP timeout. The subscription's CurrentTime and
+// TerminationTime are updated from the response.
+func (c *Client) PullMessages(sub *EventSubscription, timeout time.Duration, limit int) ([]NotificationMessage, error) {
+	return c.PullMessagesContext(context.Background(), sub, timeout, limit)
+}
+
+// PullMessagesContext is like PullMessages but uses ctx for cancellation and
+// deadlines.
+func (c *Client) PullMessagesContext(ctx context.Context, sub *EventSubscription, timeout time.Duration, limit int) ([]NotificationMessage, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	body := fmt.Sprintf(`<tev:PullMessages><tev:Timeout>%s</tev:Timeout><tev:MessageLimit>%d</tev:MessageLimit></tev:PullMessages>`,
+		formatXSDuration(timeout), limit)
+	resp, err := c.subscriptionCall(ctx, sub, "PullMessages", actionPullMessages, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		CurrentTime     string                   `xml:"Body>PullMessagesResponse>CurrentTime"`
+		TerminationTime string                   `xml:"Body>PullMessagesResponse>TerminationTime"`
+		Messages        []notificationMessageXML `xml:"Body>PullMessagesResponse>NotificationMessage"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse PullMessages response: %v", err)
+	}
+	if t := parseXSDateTime(parsed.CurrentTime); !t.IsZero() {
+		sub.CurrentTime = t
+	}
+	if t := parseXSDateTime(parsed.TerminationTime); !t.IsZero() {
+		sub.TerminationTime = t
+	}
+
+	messages := make([]NotificationMessage, 0, len(parsed.Messages))
+	for _, m := range parsed.Messages {
+		messages = append(messages, m.message())
+	}
+	return messages, nil
+}
+
+// Renew extends a subscription's lifetime by terminationTime from now. It
+// works for both PullPoint and push subscriptions.
+func (c *Client) Renew(sub *EventSubscription, terminationTime time.Duration) error {
+	return c.RenewContext(context.Background(), sub, terminationTime)
+}
+
+// RenewContext is like Renew but uses ctx for cancellation and deadlines.
+func (c *Client) RenewContext(ctx context.Context, sub *EventSubscription, terminationTime time.Duration) error {
+	body := fmt.Sprintf(`<wsnt:Renew><wsnt:TerminationTime>%s</wsnt:TerminationTime></wsnt:Renew>`,
+		formatXSDuration(terminationTime))
+	resp, err := c.subscriptionCall(ctx, sub, "Renew", actionRenew, body)
+	if err != nil {
+		return err
+	}
+
+	var parsed struct {
+		CurrentTime     string `xml:"Body>RenewResponse>CurrentTime"`
+		TerminationTime string `xml:"Body>RenewResponse>TerminationTime"`
+	}
+	_ = xml.Unmarshal(resp, &parsed)
+	current := parseXSDateTime(parsed.CurrentTime)
+	termination := parseXSDateTime(parsed.TerminationTime)
+	if termination.IsZero() {
+		// Some devices omit the new termination time; assume the request was
+		// honoured relative to their clock.
+		if current.IsZero() {
+			current = sub.CurrentTime
+		}
+		termination = current.Add(terminationTime)
+	}
+	if !current.IsZero() {
+		sub.CurrentTime = current
+	}
+	sub.TerminationTime = termination
+	return nil
+}
+
+// Unsubscribe cancels a PullPoint or push subscription.
+func (c *Client) Unsubscribe(sub *EventSubscription) error {
+	return c.UnsubscribeContext(context.Background(), sub)
+}
+
+// UnsubscribeContext is like Unsubscribe but uses ctx for cancellation and
+// deadlines.
+func (c *Client) UnsubscribeContext(ctx context.Context, sub *EventSubscription) error {
+	_, err := c.subscriptionCall(ctx, sub, "Unsubscribe", actionUnsubscribe, `<wsnt:Unsubscribe/>`)
+	return err
+}
+
+// SetSynchronizationPoint asks the camera to re-send the current state of all
+// property events (e.g. motion active, digital input level) on the
+// subscription, so a consumer can resynchronise after a gap.
+func (c *Client) SetSynchronizationPoint(sub *EventSubscription) error {
+	return c.SetSynchronizationPointContext(context.Background(), sub)
+}
+
+// SetSynchronizationPointContext is like SetSynchronizationPoint but uses ctx
+// for cancellation and deadlines.
+func (c *Client) SetSynchronizationPointContext(ctx context.Context, sub *EventSubscription) error {
+	_, err := c.subscriptionCall(ctx, sub, "SetSynchronizationPoint", actionSetSynchronizationPoint,
+		`<tev:SetSynchronizationPoint/>`)
+	return err
+}
+
+// subscriptionExpiry converts a subscription's termination time, reported on
+// the device clock, into local time. Using the device's own CurrentTime avoids
+// renewing too late on cameras whose clock has drifted.
+func subscriptionExpiry(sub *EventSubscription, fallback time.Duration) time.Time {
+	if sub.TerminationTime.IsZero() {
+		return time.Now().Add(fallback)
+	}
+	if sub.CurrentTime.IsZero() {
+		return sub.TerminationTime
+	}
+	return time.Now().Add(sub.TerminationTime.Sub(sub.CurrentTime))
+}
+
+// PullEvents creates a PullPoint subscription and runs a pull loop in the
+// background, delivering events on the returned channel. The subscription is
+// renewed once half its lifetime has elapsed. The loop ends when ctx is done
+// (the subscription is then unsubscribed) or on the first pull/renew failure,
+// which is sent on the error channel; both channels are closed on exit.
+func (c *Client) PullEvents(ctx context.Context, camera *Camera, opts PullPointOptions) (<-chan NotificationMessage, <-chan error, error) {
+	if opts.TerminationTime <= 0 {
+		opts.TerminationTime = 60 * time.Second
+	}
+	if opts.PullTimeout <= 0 {
+		opts.PullTimeout = 5 * time.Second
+	}
+	if opts.MessageLimit <= 0 {
+		opts.MessageLimit = 100
+	}
+
+	sub, err := c.CreatePullPointSubscriptionContext(ctx, camera, opts.Filter, opts.TerminationTime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan NotificationMessage)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(events)
+		defer c.unsubscribeDetached(sub)
+
+		renewAt := time.Now().Add(time.Until(subscriptionExpiry(sub, opts.TerminationTime)) / 2)
+		for ctx.Err() == nil {
+			if time.Now().After(renewAt) {
+				if err := c.RenewContext(ctx, sub, opts.TerminationTime); err != nil {
+					if ctx.Err() == nil {
+						errs <- err
+					}
+					return
+				}
+				renewAt = time.Now().Add(time.Until(subscriptionExpiry(sub, opts.TerminationTime)) / 2)
+			}
+
+			messages, err := c.PullMessagesContext(ctx, sub, opts.PullTimeout, opts.MessageLimit)
+			if err != nil {
+				if ctx.Err() == nil {
+					errs <- err
+				}
+				return
+			}
+			for _, m := range messages {
+				select {
+				case events <- m:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, errs, nil
+}
+
+// unsubscribeDetached cancels sub on a fresh, short-lived context, for use
+// when the caller's context has already been cancelled.
+func (c *Client) unsubscribeDetached(sub *EventSubscription) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_ = c.UnsubscribeContext(ctx, sub)
+}
+
+// xmlNode is a generic XML element, used where element names are data (the
+// topic tree of GetEventProperties) rather than a fixed schema.
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []xmlNode  `xml:",any"`
+}
+
+func (n xmlNode) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// onvifTopicsNamespace is the namespace of the standard ONVIF topics,
+// conventionally bound to the "tns1" prefix.
+const onvifTopicsNamespace = "http://www.onvif.org/ver10/topics"
+
+// GetEventProperties queries the topics the camera can raise and the filter
+// dialects it accepts, and stores the result in camera.EventProperties.
+func (c *Client) GetEventProperties(camera *Camera) (*EventProperties, error) {
+	return c.GetEventPropertiesContext(context.Background(), camera)
+}
+
+// GetEventPropertiesContext is like GetEventProperties but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetEventPropertiesContext(ctx context.Context, camera *Camera) (*EventProperties, error) {
+	eventsURL := c.resolveEventsURL(ctx, camera)
+	resp, err := c.sendSOAPRequestWithHeader(ctx, eventsURL, actionGetEventProperties,
+		addressingHeader(actionGetEventProperties, eventsURL, ""), `<tev:GetEventProperties/>`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event properties: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, err
+	}
+
+	props, err := parseEventProperties(resp)
+	if err != nil {
+		return nil, err
+	}
+	camera.EventProperties = props
+	return props, nil
+}
+
+// parseEventProperties parses a GetEventPropertiesResponse. Topic paths use
+// the namespace prefixes the camera itself declared, so they can be used
+// verbatim in filters; the ONVIF namespace falls back to "tns1".
+func parseEventProperties(resp []byte) (*EventProperties, error) {
+	var parsed struct {
+		TopicNamespaceLocation       []string `xml:"Body>GetEventPropertiesResponse>TopicNamespaceLocation"`
+		FixedTopicSet                bool     `xml:"Body>GetEventPropertiesResponse>FixedTopicSet"`
+		TopicSet                     xmlNode  `xml:"Body>GetEventPropertiesResponse>TopicSet"`
+		TopicExpressionDialect       []string `xml:"Body>GetEventPropertiesResponse>TopicExpressionDialect"`
+		MessageContentFilterDialect  []string `xml:"Body>GetEventPropertiesResponse>MessageContentFilterDialect"`
+		MessageContentSchemaLocation []string `xml:"Body>GetEventPropertiesResponse>MessageContentSchemaLocation"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse event properties: %v", err)
+	}
+
+	prefixes := namespacePrefixes(resp)
+	props := &EventProperties{
+		TopicNamespaceLocations:      trimAll(parsed.TopicNamespaceLocation),
+		FixedTopicSet:                parsed.FixedTopicSet,
+		TopicExpressionDialects:      trimAll(parsed.TopicExpressionDialect),
+		MessageContentFilterDialects: trimAll(parsed.MessageContentFilterDialect),
+		MessageContentSchemaLocation: trimAll(parsed.MessageContentSchemaLocation),
+	}
+	for _, n := range parsed.TopicSet.Nodes {
+		if n.XMLName.Local == "Documentation" {
+			continue
+		}
+		root := n.XMLName.Local
+		if prefix := prefixes[n.XMLName.Space]; prefix != "" {
+			root = prefix + ":" + root
+		}
+		props.Topics = append(props.Topics, buildEventTopic(n, n.XMLName.Space, root))
+	}
+	return props, nil
+}
+
+// buildEventTopic converts a topic element and its descendants into an
+// EventTopic rooted at path.
+func buildEventTopic(n xmlNode, namespace, path string) EventTopic {
+	topic := EventTopic{
+		Name:      n.XMLName.Local,
+		Namespace: namespace,
+		Path:      path,
+		IsTopic:   n.attr("topic") == "true",
+	}
+	for _, child := range n.Nodes {
+		switch child.XMLName.Local {
+		case "MessageDescription":
+			topic.Message = parseMessageDescription(child)
+			topic.IsTopic = true
+		case "Documentation":
+		default:
+			topic.Children = append(topic.Children,
+				buildEventTopic(child, namespace, path+"/"+child.XMLName.Local))
+		}
+	}
+	return topic
+}
+
+func parseMessageDescription(n xmlNode) *EventMessageDescription {
+	desc := &EventMessageDescription{IsProperty: n.attr("IsProperty") == "true"}
+	for _, section := range n.Nodes {
+		var items []SimpleItemDescription
+		for _, it := range section.Nodes {
+			if it.XMLName.Local == "SimpleItemDescription" || it.XMLName.Local == "ElementItemDescription" {
+				items = append(items, SimpleItemDescription{Name: it.attr("Name"), Type: it.attr("Type")})
+			}
+		}
+		switch section.XMLName.Local {
+		case "Source":
+			desc.Source = items
+		case "Key":
+			desc.Key = items
+		case "Data":
+			desc.Data = items
+		}
+	}
+	return desc
+}
+
+// namespacePrefixes maps each namespace URI declared anywhere in doc to the
+// prefix it was bound to, so topic paths can be rendered the way the camera
+// spells them.
+func namespacePrefixes(doc []byte) map[string]string {
+	prefixes := map[string]string{onvifTopicsNamespace: "tns1"}
+	dec := xml.NewDecoder(strings.NewReader(string(doc)))
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			break
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			for _, a := range se.Attr {
+				if a.Name.Space == "xmlns" {
+					prefixes[a.Value] = a.Name.Local
+				}
+			}
+		}
+	}
+	return prefixes
+}
+
+func trimAll(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// FindTopic returns the topic node with the given path (e.g.
+// "tns1:RuleEngine/CellMotionDetector/Motion"), or nil if the camera does not
+// advertise it.
+func (p *EventProperties) FindTopic(path string) *EventTopic {
+	for i := range p.Topics {
+		if t := p.Topics[i].find(path); t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+func (t *EventTopic) find(path string) *EventTopic {
+	if t.Path == path {
+		return t
+	}
+	if !strings.HasPrefix(path, t.Path+"/") {
+		return nil
+	}
+	for i := range t.Children {
+		if found := t.Children[i].find(path); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// TopicPaths returns the paths of every topic on which events are raised, in
+// document order.
+func (p *EventProperties) TopicPaths() []string {
+	var paths []string
+	var walk func(t EventTopic)
+	walk = func(t EventTopic) {
+		if t.IsTopic {
+			paths = append(paths, t.Path)
+		}
+		for _, child := range t.Children {
+			walk(child)
+		}
+	}
+	for _, t := range p.Topics {
+		walk(t)
+	}
+	return paths
+}
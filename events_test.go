@@ -0,0 +1,302 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const pullMessagesResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:tev="http://www.onvif.org/ver10/events/wsdl"
+ xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2" xmlns:tt="http://www.onvif.org/ver10/schema" xmlns:tns1="http://www.onvif.org/ver10/topics">
+ <env:Body><tev:PullMessagesResponse>
+  <tev:CurrentTime>2024-05-01T10:00:00Z</tev:CurrentTime>
+  <tev:TerminationTime>2024-05-01T10:01:00Z</tev:TerminationTime>
+  <wsnt:NotificationMessage>
+   <wsnt:Topic Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet">tns1:RuleEngine/CellMotionDetector/Motion</wsnt:Topic>
+   <wsnt:Message>
+    <tt:Message UtcTime="2024-05-01T09:59:58.120Z" PropertyOperation="Changed">
+     <tt:Source>
+      <tt:SimpleItem Name="VideoSourceConfigurationToken" Value="VSC0"/>
+      <tt:SimpleItem Name="Rule" Value="MyMotionDetectorRule"/>
+     </tt:Source>
+     <tt:Data><tt:SimpleItem Name="IsMotion" Value="true"/></tt:Data>
+    </tt:Message>
+   </wsnt:Message>
+  </wsnt:NotificationMessage>
+ </tev:PullMessagesResponse></env:Body></env:Envelope>`
+
+// fakeEventsCamera serves the Events service and the PullPoint subscription
+// manager of a single camera, dispatching on the SOAP action.
+func fakeEventsCamera(t *testing.T, unsubscribed *int32) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBody, _ := io.ReadAll(r.Body)
+		switch r.Header.Get("SOAPAction") {
+		case actionCreatePullPointSubscription:
+			// The reference parameter's prefix is declared on the envelope, so
+			// it must be declared again when echoed back.
+			fmt.Fprintf(w, `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:wsa="http://www.w3.org/2005/08/addressing" xmlns:dom0="urn:example"><env:Body>
+<tev:CreatePullPointSubscriptionResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl" xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2">
+ <tev:SubscriptionReference><wsa:Address>%s/subscription/7</wsa:Address>
+  <wsa:ReferenceParameters><dom0:SubscriptionId>7</dom0:SubscriptionId></wsa:ReferenceParameters>
+ </tev:SubscriptionReference>
+ <wsnt:CurrentTime>2024-05-01T10:00:00Z</wsnt:CurrentTime>
+ <wsnt:TerminationTime>2024-05-01T10:01:00Z</wsnt:TerminationTime>
+</tev:CreatePullPointSubscriptionResponse></env:Body></env:Envelope>`, srv.URL)
+		case actionPullMessages:
+			if !strings.Contains(string(reqBody), `<dom0:SubscriptionId xmlns:dom0="urn:example">7</dom0:SubscriptionId>`) {
+				t.Errorf("PullMessages request lacks the reference parameters:\n%s", reqBody)
+			}
+			io.WriteString(w, pullMessagesResponse)
+		case actionUnsubscribe:
+			atomic.AddInt32(unsubscribed, 1)
+			io.WriteString(w, `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><wsnt:UnsubscribeResponse xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2"/></env:Body></env:Envelope>`)
+		default:
+			http.Error(w, "unexpected action "+r.Header.Get("SOAPAction"), http.StatusBadRequest)
+		}
+	}))
+	return srv
+}
+
+func TestPullEvents(t *testing.T) {
+	var unsubscribed int32
+	srv := fakeEventsCamera(t, &unsubscribed)
+	defer srv.Close()
+
+	c := NewClient("admin", "secret")
+	camera := &Camera{Address: srv.URL + "/onvif/device_service", EventsURL: srv.URL + "/onvif/event_service"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, errs, err := c.PullEvents(ctx, camera, PullPointOptions{Filter: "tns1:RuleEngine//."})
+	if err != nil {
+		t.Fatalf("PullEvents() error = %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Topic != "tns1:RuleEngine/CellMotionDetector/Motion" {
+			t.Errorf("Topic = %q", ev.Topic)
+		}
+		if ev.PropertyOperation != "Changed" {
+			t.Errorf("PropertyOperation = %q", ev.PropertyOperation)
+		}
+		if want := time.Date(2024, 5, 1, 9, 59, 58, 120e6, time.UTC); !ev.UtcTime.Equal(want) {
+			t.Errorf("UtcTime = %v, want %v", ev.UtcTime, want)
+		}
+		if len(ev.Source) != 2 || ev.Source[1] != (SimpleItem{Name: "Rule", Value: "MyMotionDetectorRule"}) {
+			t.Errorf("Source = %+v", ev.Source)
+		}
+		if len(ev.Data) != 1 || ev.Data[0] != (SimpleItem{Name: "IsMotion", Value: "true"}) {
+			t.Errorf("Data = %+v", ev.Data)
+		}
+	case err := <-errs:
+		t.Fatalf("pull loop failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event delivered")
+	}
+
+	cancel()
+	for range events {
+	}
+	if err, ok := <-errs; ok {
+		t.Errorf("unexpected error after cancel: %v", err)
+	}
+	if atomic.LoadInt32(&unsubscribed) != 1 {
+		t.Errorf("Unsubscribe called %d times, want 1", unsubscribed)
+	}
+}
+
+func TestBuildTopicFilterXML(t *testing.T) {
+	if got := buildTopicFilterXML("tev:Filter", ""); got != "" {
+		t.Errorf("empty filter rendered %q", got)
+	}
+	got := buildTopicFilterXML("tev:Filter", "tns1:RuleEngine//.|tns1:VideoSource//.")
+	for _, want := range []string{
+		"<tev:Filter>",
+		`Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet"`,
+		`xmlns:tns1="http://www.onvif.org/ver10/topics"`,
+		">tns1:RuleEngine//.|tns1:VideoSource//.</wsnt:TopicExpression>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("buildTopicFilterXML() = %q, want contains %q", got, want)
+		}
+	}
+}
+
+const notifyEnvelope = `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2" xmlns:tt="http://www.onvif.org/ver10/schema">
+ <s:Body><wsnt:Notify>
+  <wsnt:NotificationMessage>
+   <wsnt:Topic Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet">tns1:Device/Trigger/DigitalInput</wsnt:Topic>
+   <wsnt:Message><tt:Message UtcTime="2024-05-01T10:00:05Z" PropertyOperation="Changed">
+    <tt:Source><tt:SimpleItem Name="InputToken" Value="DI0"/></tt:Source>
+    <tt:Data><tt:SimpleItem Name="LogicalState" Value="true"/></tt:Data>
+   </tt:Message></wsnt:Message>
+  </wsnt:NotificationMessage>
+ </wsnt:Notify></s:Body></s:Envelope>`
+
+func TestPushEvents(t *testing.T) {
+	consumerMux := http.NewServeMux()
+	consumerSrv := httptest.NewServer(consumerMux)
+	defer consumerSrv.Close()
+	consumer := NewEventConsumer(consumerSrv.URL + "/onvif/notify")
+	consumerMux.Handle("/onvif/notify/", consumer)
+
+	// The fake camera answers Subscribe and then pushes one Notify to the
+	// consumer address it was given.
+	var unsubscribed int32
+	var camSrv *httptest.Server
+	camSrv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBody, _ := io.ReadAll(r.Body)
+		switch r.Header.Get("SOAPAction") {
+		case actionSubscribe:
+			var req struct {
+				Address string `xml:"Body>Subscribe>ConsumerReference>Address"`
+			}
+			if err := xml.Unmarshal(reqBody, &req); err != nil || req.Address == "" {
+				t.Errorf("bad Subscribe request: %v\n%s", err, reqBody)
+			}
+			// The reference parameter's prefix is declared on the envelope, so
+			// it must be declared again when echoed back.
+			fmt.Fprintf(w, `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:wsa="http://www.w3.org/2005/08/addressing" xmlns:dom0="urn:example"><env:Body>
+<wsnt:SubscribeResponse xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2">
+ <wsnt:SubscriptionReference><wsa:Address>%s/subscription/1</wsa:Address></wsnt:SubscriptionReference>
+ <wsnt:CurrentTime>2024-05-01T10:00:00Z</wsnt:CurrentTime>
+ <wsnt:TerminationTime>2024-05-01T10:01:00Z</wsnt:TerminationTime>
+</wsnt:SubscribeResponse></env:Body></env:Envelope>`, camSrv.URL)
+			go func() {
+				resp, err := http.Post(req.Address, "application/soap+xml", strings.NewReader(notifyEnvelope))
+				if err != nil {
+					t.Errorf("posting Notify: %v", err)
+					return
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("Notify answered HTTP %d", resp.StatusCode)
+				}
+			}()
+		case actionUnsubscribe:
+			atomic.AddInt32(&unsubscribed, 1)
+			io.WriteString(w, `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body/></env:Envelope>`)
+		default:
+			http.Error(w, "unexpected action", http.StatusBadRequest)
+		}
+	}))
+	defer camSrv.Close()
+
+	c := NewClient("admin", "secret")
+	camera := &Camera{Address: camSrv.URL + "/onvif/device_service", EventsURL: camSrv.URL + "/onvif/event_service"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, errs, err := c.PushEvents(ctx, camera, consumer, SubscribeOptions{Filter: "tns1:Device//."})
+	if err != nil {
+		t.Fatalf("PushEvents() error = %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Topic != "tns1:Device/Trigger/DigitalInput" {
+			t.Errorf("Topic = %q", ev.Topic)
+		}
+		if len(ev.Data) != 1 || ev.Data[0].Value != "true" {
+			t.Errorf("Data = %+v", ev.Data)
+		}
+	case err := <-errs:
+		t.Fatalf("push loop failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event delivered")
+	}
+
+	cancel()
+	for range events {
+	}
+	if atomic.LoadInt32(&unsubscribed) != 1 {
+		t.Errorf("Unsubscribe called %d times, want 1", unsubscribed)
+	}
+
+	// Once unregistered, further posts are rejected.
+	resp, err := http.Post(consumerSrv.URL+"/onvif/notify/unknown", "application/soap+xml", strings.NewReader(notifyEnvelope))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("post to unknown key: HTTP %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestParseEventProperties(t *testing.T) {
+	resp := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:tev="http://www.onvif.org/ver10/events/wsdl"
+ xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2" xmlns:wstop="http://docs.oasis-open.org/wsn/t-1"
+ xmlns:tt="http://www.onvif.org/ver10/schema" xmlns:tns1="http://www.onvif.org/ver10/topics"
+ xmlns:tnsaxis="http://www.axis.com/2009/event/topics">
+ <env:Body><tev:GetEventPropertiesResponse>
+  <tev:TopicNamespaceLocation>http://www.onvif.org/onvif/ver10/topics/topicns.xml</tev:TopicNamespaceLocation>
+  <wsnt:FixedTopicSet>true</wsnt:FixedTopicSet>
+  <wstop:TopicSet>
+   <tns1:RuleEngine>
+    <CellMotionDetector>
+     <Motion wstop:topic="true">
+      <tt:MessageDescription IsProperty="true">
+       <tt:Source>
+        <tt:SimpleItemDescription Name="VideoSourceConfigurationToken" Type="tt:ReferenceToken"/>
+        <tt:SimpleItemDescription Name="Rule" Type="xs:string"/>
+       </tt:Source>
+       <tt:Data><tt:SimpleItemDescription Name="IsMotion" Type="xs:boolean"/></tt:Data>
+      </tt:MessageDescription>
+     </Motion>
+    </CellMotionDetector>
+   </tns1:RuleEngine>
+   <tns1:VideoSource><ImageTooDark wstop:topic="true"/></tns1:VideoSource>
+   <tnsaxis:Storage><Alert wstop:topic="true"/></tnsaxis:Storage>
+  </wstop:TopicSet>
+  <wsnt:TopicExpressionDialect>http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet</wsnt:TopicExpressionDialect>
+  <tev:MessageContentFilterDialect>http://www.onvif.org/ver10/tev/messageContentFilter/ItemFilter</tev:MessageContentFilterDialect>
+ </tev:GetEventPropertiesResponse></env:Body></env:Envelope>`)
+
+	props, err := parseEventProperties(resp)
+	if err != nil {
+		t.Fatalf("parseEventProperties() error = %v", err)
+	}
+	if !props.FixedTopicSet {
+		t.Error("FixedTopicSet = false, want true")
+	}
+	wantPaths := []string{
+		"tns1:RuleEngine/CellMotionDetector/Motion",
+		"tns1:VideoSource/ImageTooDark",
+		"tnsaxis:Storage/Alert",
+	}
+	if got := props.TopicPaths(); strings.Join(got, ",") != strings.Join(wantPaths, ",") {
+		t.Errorf("TopicPaths() = %v, want %v", got, wantPaths)
+	}
+
+	motion := props.FindTopic("tns1:RuleEngine/CellMotionDetector/Motion")
+	if motion == nil || motion.Message == nil {
+		t.Fatalf("motion topic or its description missing: %+v", motion)
+	}
+	if !motion.Message.IsProperty {
+		t.Error("motion IsProperty = false, want true")
+	}
+	if len(motion.Message.Source) != 2 || motion.Message.Source[0].Type != "tt:ReferenceToken" {
+		t.Errorf("Source = %+v", motion.Message.Source)
+	}
+	if len(motion.Message.Data) != 1 || motion.Message.Data[0] != (SimpleItemDescription{Name: "IsMotion", Type: "xs:boolean"}) {
+		t.Errorf("Data = %+v", motion.Message.Data)
+	}
+	if props.FindTopic("tns1:RuleEngine/Missing") != nil {
+		t.Error("FindTopic found a topic the camera does not advertise")
+	}
+	if len(props.MessageContentFilterDialects) != 1 {
+		t.Errorf("MessageContentFilterDialects = %v", props.MessageContentFilterDialects)
+	}
+}
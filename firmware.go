@@ -0,0 +1,319 @@
+package onvif
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// firmwareRebootDelay is how long UpgradeFirmware lets the device go down
+// before polling it, when the device gives no ExpectedDownTime.
+var firmwareRebootDelay = 30 * time.Second
+
+// uploadClient returns an HTTP client for firmware transfers. It shares the
+// pooled transport but not Client.Timeout, which is sized for SOAP calls; a
+// transfer is bounded by its context instead.
+func (c *Client) uploadClient() *http.Client {
+	base := c.transport()
+	return &http.Client{Transport: base.Transport, CheckRedirect: base.CheckRedirect, Jar: base.Jar}
+}
+
+// sleepContext waits for d, returning early with ctx's error if ctx is done
+// first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// getFirmwareVersion reads FirmwareVersion with GetDeviceInformation and
+// records it on camera. Unlike GetDeviceInformation it reports failures,
+// since upgrade verification depends on the answer.
+func (c *Client) getFirmwareVersion(ctx context.Context, camera *Camera) (string, error) {
+	resp, err := c.sendSOAPRequest(ctx, getFirstAddress(camera.Address),
+		"http://www.onvif.org/ver10/device/wsdl/GetDeviceInformation", `<tds:GetDeviceInformation/>`)
+	if err != nil {
+		return "", fmt.Errorf("failed to get device information: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return "", fmt.Errorf("failed to get device information: %w", err)
+	}
+
+	var parsed struct {
+		FirmwareVersion string `xml:"Body>GetDeviceInformationResponse>FirmwareVersion"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse device information: %v", err)
+	}
+	camera.FirmwareVersion = strings.TrimSpace(parsed.FirmwareVersion)
+	return camera.FirmwareVersion, nil
+}
+
+// faultNotSupported reports whether resp is a SOAP fault whose subcode (or
+// SOAP 1.1 faultcode) says the operation is not implemented, such as
+// ter:ActionNotSupported.
+func faultNotSupported(resp []byte) bool {
+	var env struct {
+		Fault soapFault `xml:"Body>Fault"`
+	}
+	if err := xml.Unmarshal(resp, &env); err != nil {
+		return false
+	}
+	for _, code := range []string{env.Fault.Code.Subcode.Value, env.Fault.FaultCode} {
+		code = strings.TrimSpace(code)
+		if i := strings.LastIndex(code, ":"); i >= 0 {
+			code = code[i+1:]
+		}
+		if code == "ActionNotSupported" || code == "NotSupported" {
+			return true
+		}
+	}
+	return false
+}
+
+// startFirmwareUpgrade runs StartFirmwareUpgrade. unsupported reports that the
+// device answered with a fault saying it does not implement the operation,
+// rather than failing it.
+func (c *Client) startFirmwareUpgrade(ctx context.Context, camera *Camera) (upgrade *FirmwareUpgrade, unsupported bool, err error) {
+	resp, err := c.sendSOAPRequest(ctx, getFirstAddress(camera.Address),
+		"http://www.onvif.org/ver10/device/wsdl/StartFirmwareUpgrade", `<tds:StartFirmwareUpgrade/>`)
+	if resp != nil {
+		if ferr := parseSOAPFault(resp); ferr != nil {
+			return nil, faultNotSupported(resp), fmt.Errorf("failed to start firmware upgrade: %w", ferr)
+		}
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to start firmware upgrade: %v", err)
+	}
+
+	var parsed struct {
+		UploadUri        string `xml:"Body>StartFirmwareUpgradeResponse>UploadUri"`
+		UploadDelay      string `xml:"Body>StartFirmwareUpgradeResponse>UploadDelay"`
+		ExpectedDownTime string `xml:"Body>StartFirmwareUpgradeResponse>ExpectedDownTime"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, false, fmt.Errorf("failed to parse firmware upgrade response: %v", err)
+	}
+	upgrade = &FirmwareUpgrade{UploadURI: strings.TrimSpace(parsed.UploadUri)}
+	if upgrade.UploadURI == "" {
+		return nil, false, fmt.Errorf("no upload URI in firmware upgrade response")
+	}
+	upgrade.UploadDelay, _ = parseXSDuration(parsed.UploadDelay)
+	upgrade.ExpectedDownTime, _ = parseXSDuration(parsed.ExpectedDownTime)
+	return upgrade, false, nil
+}
+
+// StartFirmwareUpgrade asks the device to prepare for a firmware upgrade and
+// returns where and when to upload the image (see UploadFirmware) and how
+// long the device expects to be down afterwards.
+func (c *Client) StartFirmwareUpgrade(camera *Camera) (*FirmwareUpgrade, error) {
+	return c.StartFirmwareUpgradeContext(context.Background(), camera)
+}
+
+// StartFirmwareUpgradeContext is like StartFirmwareUpgrade but uses ctx for
+// cancellation and deadlines.
+func (c *Client) StartFirmwareUpgradeContext(ctx context.Context, camera *Camera) (*FirmwareUpgrade, error) {
+	upgrade, _, err := c.startFirmwareUpgrade(ctx, camera)
+	return upgrade, err
+}
+
+// UploadFirmware waits upgrade.UploadDelay, then POSTs image to
+// upgrade.UploadURI with the same HTTP Basic/Digest handling as
+// FetchSnapshot. The device installs the image and restarts once the upload
+// completes.
+func (c *Client) UploadFirmware(upgrade FirmwareUpgrade, image []byte) error {
+	return c.UploadFirmwareContext(context.Background(), upgrade, image)
+}
+
+// UploadFirmwareContext is like UploadFirmware but uses ctx for cancellation
+// and deadlines. Client.Timeout does not apply to the upload.
+func (c *Client) UploadFirmwareContext(ctx context.Context, upgrade FirmwareUpgrade, image []byte) error {
+	if upgrade.UploadURI == "" {
+		return fmt.Errorf("no firmware upload URI")
+	}
+	if err := sleepContext(ctx, upgrade.UploadDelay); err != nil {
+		return err
+	}
+
+	resp, err := c.doHTTPWithAuth(ctx, c.uploadClient(), http.MethodPost, upgrade.UploadURI, "application/octet-stream", image)
+	if err != nil {
+		return fmt.Errorf("firmware upload failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("firmware upload HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// buildFirmwareMTOM packages an UpgradeSystemFirmware envelope and the image
+// as an MTOM (XOP) multipart/related message, returning its Content-Type.
+func buildFirmwareMTOM(envelope string, image []byte) (string, []byte, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	root := textproto.MIMEHeader{}
+	root.Set("Content-Type", `application/xop+xml; charset=UTF-8; type="application/soap+xml"`)
+	root.Set("Content-Transfer-Encoding", "8bit")
+	root.Set("Content-ID", "<envelope@onvif>")
+	part, err := w.CreatePart(root)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := io.WriteString(part, envelope); err != nil {
+		return "", nil, err
+	}
+
+	attachment := textproto.MIMEHeader{}
+	attachment.Set("Content-Type", "application/octet-stream")
+	attachment.Set("Content-Transfer-Encoding", "binary")
+	attachment.Set("Content-ID", "<firmware@onvif>")
+	part, err = w.CreatePart(attachment)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := part.Write(image); err != nil {
+		return "", nil, err
+	}
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+
+	contentType := mime.FormatMediaType("multipart/related", map[string]string{
+		"type":       "application/xop+xml",
+		"start":      "<envelope@onvif>",
+		"start-info": "application/soap+xml",
+		"boundary":   w.Boundary(),
+	})
+	return contentType, buf.Bytes(), nil
+}
+
+// UpgradeSystemFirmware sends image inline with the legacy (ONVIF 1.x)
+// UpgradeSystemFirmware operation, as an MTOM attachment, and returns the
+// device's message. Prefer StartFirmwareUpgrade and UploadFirmware on devices
+// that support them.
+func (c *Client) UpgradeSystemFirmware(camera *Camera, image []byte) (string, error) {
+	return c.UpgradeSystemFirmwareContext(context.Background(), camera, image)
+}
+
+// UpgradeSystemFirmwareContext is like UpgradeSystemFirmware but uses ctx for
+// cancellation and deadlines. Client.Timeout does not apply to the upload.
+func (c *Client) UpgradeSystemFirmwareContext(ctx context.Context, camera *Camera, image []byte) (string, error) {
+	const action = "http://www.onvif.org/ver10/device/wsdl/UpgradeSystemFirmware"
+	body := `<tds:UpgradeSystemFirmware><tds:Firmware xmlns:xmime="http://www.w3.org/2005/05/xmlmime" xmime:contentType="application/octet-stream">` +
+		`<xop:Include xmlns:xop="http://www.w3.org/2004/08/xop/include" href="cid:firmware@onvif"/>` +
+		`</tds:Firmware></tds:UpgradeSystemFirmware>`
+	contentType, payload, err := buildFirmwareMTOM(c.soapEnvelope("", body), image)
+	if err != nil {
+		return "", fmt.Errorf("failed to build firmware upload: %v", err)
+	}
+
+	resp, err := c.postSOAP(ctx, c.uploadClient(), getFirstAddress(camera.Address), action, contentType, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to upgrade firmware: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return "", fmt.Errorf("failed to upgrade firmware: %w", err)
+	}
+
+	var parsed struct {
+		Message string `xml:"Body>UpgradeSystemFirmwareResponse>Message"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse firmware upgrade response: %v", err)
+	}
+	return strings.TrimSpace(parsed.Message), nil
+}
+
+// UpgradeFirmware installs image end to end: StartFirmwareUpgrade and an HTTP
+// upload, or UpgradeSystemFirmware on devices without StartFirmwareUpgrade;
+// then it waits for the device to restart and polls FirmwareVersion (from
+// GetDeviceInformation) until it changes. timeout bounds the whole operation,
+// including the restart, and must be positive. If the version has not
+// changed when timeout elapses, the result is returned together with an
+// error.
+func (c *Client) UpgradeFirmware(camera *Camera, image []byte, timeout time.Duration) (*FirmwareUpgradeResult, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be positive, got %v", timeout)
+	}
+	return c.UpgradeFirmwareContext(context.Background(), camera, image, timeout)
+}
+
+// UpgradeFirmwareContext is like UpgradeFirmware but also stops when ctx is
+// done. A timeout <= 0 leaves the bound to ctx.
+func (c *Client) UpgradeFirmwareContext(ctx context.Context, camera *Camera, image []byte, timeout time.Duration) (*FirmwareUpgradeResult, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	previous, err := c.getFirmwareVersion(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	result := &FirmwareUpgradeResult{PreviousVersion: previous}
+
+	downtime := firmwareRebootDelay
+	upgrade, unsupported, err := c.startFirmwareUpgrade(ctx, camera)
+	switch {
+	case err == nil:
+		if err := c.UploadFirmwareContext(ctx, *upgrade, image); err != nil {
+			return nil, err
+		}
+		if upgrade.ExpectedDownTime > 0 {
+			downtime = upgrade.ExpectedDownTime
+		}
+	case unsupported:
+		message, err := c.UpgradeSystemFirmwareContext(ctx, camera, image)
+		if err != nil {
+			return nil, err
+		}
+		result.Message = message
+	default:
+		return nil, err
+	}
+
+	if err := sleepContext(ctx, downtime); err != nil {
+		return nil, fmt.Errorf("waiting for the device to restart: %w", err)
+	}
+
+	// The device may still be installing, and answering with the old version,
+	// once the downtime has passed, or be down; poll until the version changes.
+	answered := false
+	for {
+		version, err := c.getFirmwareVersion(ctx, camera)
+		if err == nil {
+			if version != previous {
+				result.Version = version
+				return result, nil
+			}
+			answered = true
+		}
+		if waitErr := sleepContext(ctx, deviceReadyPollInterval); waitErr != nil {
+			if answered {
+				result.Version = previous
+				return result, fmt.Errorf("firmware version is still %q after the upgrade", previous)
+			}
+			return nil, fmt.Errorf("device not ready after the upgrade: %w (last error: %v)", waitErr, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package onvif
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeFirmwareCamera serves a device service at /onvif/device_service and a
+// Basic-auth firmware upload URI at /upload. Once an image is received, the
+// reported firmware version becomes newVersion, after a few more answers with
+// the old one while the image installs. With startFault set,
+// StartFirmwareUpgrade faults with that subcode; for ter:ActionNotSupported the
+// image must arrive via MTOM UpgradeSystemFirmware instead.
+func fakeFirmwareCamera(t *testing.T, newVersion, startFault string, received *[]byte) *httptest.Server {
+	t.Helper()
+	version, installing := "1.0", 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/upload" {
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "admin" || pass != "secret" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="camera"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			*received, _ = io.ReadAll(r.Body)
+			installing = 3
+			return
+		}
+
+		var body string
+		switch action := r.Header.Get("SOAPAction"); action {
+		case "http://www.onvif.org/ver10/device/wsdl/GetDeviceInformation":
+			if installing > 0 {
+				if installing--; installing == 0 {
+					version = newVersion
+				}
+			}
+			body = `<tds:GetDeviceInformationResponse><tds:Manufacturer>Acme</tds:Manufacturer><tds:FirmwareVersion>` +
+				version + `</tds:FirmwareVersion></tds:GetDeviceInformationResponse>`
+		case "http://www.onvif.org/ver10/device/wsdl/GetSystemDateAndTime":
+			body = `<tds:GetSystemDateAndTimeResponse/>`
+		case "http://www.onvif.org/ver10/device/wsdl/StartFirmwareUpgrade":
+			if startFault != "" {
+				w.WriteHeader(http.StatusBadRequest)
+				body = `<s:Fault><s:Code><s:Value>s:Receiver</s:Value><s:Subcode><s:Value>` + startFault + `</s:Value></s:Subcode></s:Code>` +
+					`<s:Reason><s:Text xml:lang="en">Refused</s:Text></s:Reason></s:Fault>`
+				break
+			}
+			body = `<tds:StartFirmwareUpgradeResponse><tds:UploadUri>` + srv.URL + `/upload</tds:UploadUri>` +
+				`<tds:UploadDelay>PT0S</tds:UploadDelay><tds:ExpectedDownTime>PT0S</tds:ExpectedDownTime></tds:StartFirmwareUpgradeResponse>`
+		case "http://www.onvif.org/ver10/device/wsdl/UpgradeSystemFirmware":
+			mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "multipart/related" || params["type"] != "application/xop+xml" {
+				t.Errorf("UpgradeSystemFirmware Content-Type = %q", r.Header.Get("Content-Type"))
+			}
+			mr := multipart.NewReader(r.Body, params["boundary"])
+			root, _ := mr.NextPart()
+			envelope, _ := io.ReadAll(root)
+			if !strings.Contains(string(envelope), `<xop:Include xmlns:xop="http://www.w3.org/2004/08/xop/include" href="cid:firmware@onvif"/>`) {
+				t.Errorf("UpgradeSystemFirmware envelope = %s", envelope)
+			}
+			attachment, _ := mr.NextPart()
+			if id := attachment.Header.Get("Content-ID"); id != "<firmware@onvif>" {
+				t.Errorf("attachment Content-ID = %q", id)
+			}
+			*received, _ = io.ReadAll(attachment)
+			installing = 3
+			body = `<tds:UpgradeSystemFirmwareResponse><tds:Message>Upgrade successful, rebooting</tds:Message></tds:UpgradeSystemFirmwareResponse>`
+		default:
+			t.Errorf("unexpected action %q", action)
+		}
+		io.WriteString(w, `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:tds="http://www.onvif.org/ver10/device/wsdl"><s:Body>`+
+			body+`</s:Body></s:Envelope>`)
+	}))
+	return srv
+}
+
+func TestUpgradeFirmware(t *testing.T) {
+	defer func(d, p time.Duration) { firmwareRebootDelay, deviceReadyPollInterval = d, p }(firmwareRebootDelay, deviceReadyPollInterval)
+	firmwareRebootDelay, deviceReadyPollInterval = time.Millisecond, time.Millisecond
+	image := bytes.Repeat([]byte{0xDE, 0xAD, 0xBE, 0xEF}, 1024)
+
+	var received []byte
+	srv := fakeFirmwareCamera(t, "2.0", "", &received)
+	defer srv.Close()
+	c := NewClient("admin", "secret")
+	camera := &Camera{Address: srv.URL + "/onvif/device_service"}
+
+	upgrade, err := c.StartFirmwareUpgrade(camera)
+	if err != nil || upgrade.UploadURI != srv.URL+"/upload" || upgrade.UploadDelay != 0 {
+		t.Fatalf("StartFirmwareUpgrade() = %+v, %v", upgrade, err)
+	}
+
+	result, err := c.UpgradeFirmware(camera, image, 10*time.Second)
+	if err != nil {
+		t.Fatalf("UpgradeFirmware() error = %v", err)
+	}
+	if *result != (FirmwareUpgradeResult{PreviousVersion: "1.0", Version: "2.0"}) || camera.FirmwareVersion != "2.0" {
+		t.Errorf("UpgradeFirmware() = %+v, camera version %q", result, camera.FirmwareVersion)
+	}
+	if !bytes.Equal(received, image) {
+		t.Errorf("uploaded %d bytes, want %d", len(received), len(image))
+	}
+
+	if _, err := c.UpgradeFirmware(camera, image, 0); err == nil {
+		t.Error("UpgradeFirmware() with no timeout returned nil error")
+	}
+}
+
+func TestUpgradeFirmwareLegacy(t *testing.T) {
+	defer func(d, p time.Duration) { firmwareRebootDelay, deviceReadyPollInterval = d, p }(firmwareRebootDelay, deviceReadyPollInterval)
+	firmwareRebootDelay, deviceReadyPollInterval = time.Millisecond, time.Millisecond
+	image := []byte("firmware image")
+
+	// The device accepts the image but comes back on the same version.
+	var received []byte
+	srv := fakeFirmwareCamera(t, "1.0", "ter:ActionNotSupported", &received)
+	defer srv.Close()
+	c := NewClient("admin", "secret")
+	camera := &Camera{Address: srv.URL + "/onvif/device_service"}
+
+	result, err := c.UpgradeFirmware(camera, image, 200*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), `still "1.0"`) {
+		t.Errorf("UpgradeFirmware() error = %v, want unchanged version", err)
+	}
+	if result == nil || result.Message != "Upgrade successful, rebooting" || result.Version != "1.0" {
+		t.Errorf("UpgradeFirmware() = %+v", result)
+	}
+	if !bytes.Equal(received, image) {
+		t.Errorf("MTOM attachment = %q, want %q", received, image)
+	}
+}
+
+func TestUpgradeFirmwareFault(t *testing.T) {
+	// A fault other than ActionNotSupported is returned as is, not retried
+	// with UpgradeSystemFirmware.
+	var received []byte
+	srv := fakeFirmwareCamera(t, "2.0", "ter:NotAuthorized", &received)
+	defer srv.Close()
+	c := NewClient("admin", "secret")
+	camera := &Camera{Address: srv.URL + "/onvif/device_service"}
+
+	result, err := c.UpgradeFirmware(camera, []byte("firmware image"), 10*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "not authorized") || result != nil {
+		t.Errorf("UpgradeFirmware() = %+v, %v, want a not authorized error", result, err)
+	}
+	if received != nil {
+		t.Errorf("image was sent after the fault: %q", received)
+	}
+}
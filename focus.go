@@ -0,0 +1,235 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// buildFocusMoveXML renders the timg:Focus element of an Imaging Move request.
+func buildFocusMoveXML(move FocusMove) (string, error) {
+	speed := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf(`<tt:Speed>%g</tt:Speed>`, *v)
+	}
+	switch {
+	case move.Absolute != nil:
+		return fmt.Sprintf(`<timg:Focus><tt:Absolute><tt:Position>%g</tt:Position>%s</tt:Absolute></timg:Focus>`,
+			move.Absolute.Position, speed(move.Absolute.Speed)), nil
+	case move.Relative != nil:
+		return fmt.Sprintf(`<timg:Focus><tt:Relative><tt:Distance>%g</tt:Distance>%s</tt:Relative></timg:Focus>`,
+			move.Relative.Distance, speed(move.Relative.Speed)), nil
+	case move.Continuous != nil:
+		return fmt.Sprintf(`<timg:Focus><tt:Continuous><tt:Speed>%g</tt:Speed></tt:Continuous></timg:Focus>`,
+			move.Continuous.Speed), nil
+	}
+	return "", fmt.Errorf("focus move has no Absolute, Relative or Continuous component")
+}
+
+// Validate checks move against the options: a move, or a move speed, the
+// camera does not advertise is reported as an error, and the position,
+// distance and speed are clamped to the advertised ranges. The caller's
+// values are not modified; move gets clamped copies. Options that advertise no
+// move at all are treated as unknown and check nothing.
+func (o *FocusMoveOptions) Validate(move *FocusMove) error {
+	if *o == (FocusMoveOptions{}) {
+		return nil
+	}
+	speed := func(kind string, v *float64, r *FloatRange) (*float64, error) {
+		if v != nil && r == nil {
+			return nil, fmt.Errorf("%s focus move speed not supported", kind)
+		}
+		return clampOptional(v, r), nil
+	}
+	var err error
+	switch {
+	case move.Absolute != nil:
+		if o.AbsolutePosition == nil {
+			return fmt.Errorf("absolute focus move not supported")
+		}
+		a := *move.Absolute
+		a.Position = o.AbsolutePosition.Clamp(a.Position)
+		if a.Speed, err = speed("absolute", a.Speed, o.AbsoluteSpeed); err != nil {
+			return err
+		}
+		move.Absolute = &a
+	case move.Relative != nil:
+		if o.RelativeDistance == nil {
+			return fmt.Errorf("relative focus move not supported")
+		}
+		r := *move.Relative
+		r.Distance = o.RelativeDistance.Clamp(r.Distance)
+		if r.Speed, err = speed("relative", r.Speed, o.RelativeSpeed); err != nil {
+			return err
+		}
+		move.Relative = &r
+	case move.Continuous != nil:
+		if o.ContinuousSpeed == nil {
+			return fmt.Errorf("continuous focus move not supported")
+		}
+		move.Continuous = &ContinuousFocus{Speed: o.ContinuousSpeed.Clamp(move.Continuous.Speed)}
+	}
+	return nil
+}
+
+// MoveFocus moves the focus lens of the camera's video source. The camera
+// must be in manual focus mode for most cameras to accept it.
+func (c *Client) MoveFocus(camera *Camera, move FocusMove) error {
+	return c.MoveFocusContext(context.Background(), camera, move)
+}
+
+// MoveFocusContext is like MoveFocus but uses ctx for cancellation and
+// deadlines.
+func (c *Client) MoveFocusContext(ctx context.Context, camera *Camera, move FocusMove) error {
+	// Check the move against the advertised options so the caller gets a
+	// descriptive error instead of an opaque ter:InvalidArgVal. Cameras that
+	// do not implement GetMoveOptions are moved unchecked.
+	if opts, err := c.GetFocusMoveOptionsContext(ctx, camera); err == nil {
+		if err := opts.Validate(&move); err != nil {
+			return err
+		}
+	}
+	focus, err := buildFocusMoveXML(move)
+	if err != nil {
+		return err
+	}
+	_, err = c.imagingCall(ctx, camera, "Move", func(token string) string {
+		return fmt.Sprintf(`<timg:Move><timg:VideoSourceToken>%s</timg:VideoSourceToken>%s</timg:Move>`, token, focus)
+	})
+	return err
+}
+
+// StopFocus stops any focus movement, typically a continuous MoveFocus.
+func (c *Client) StopFocus(camera *Camera) error {
+	return c.StopFocusContext(context.Background(), camera)
+}
+
+// StopFocusContext is like StopFocus but uses ctx for cancellation and
+// deadlines.
+func (c *Client) StopFocusContext(ctx context.Context, camera *Camera) error {
+	_, err := c.imagingCall(ctx, camera, "Stop", func(token string) string {
+		return fmt.Sprintf(`<timg:Stop><timg:VideoSourceToken>%s</timg:VideoSourceToken></timg:Stop>`, token)
+	})
+	return err
+}
+
+// GetFocusMoveOptions returns the focus moves the camera's video source
+// supports and their ranges.
+func (c *Client) GetFocusMoveOptions(camera *Camera) (*FocusMoveOptions, error) {
+	return c.GetFocusMoveOptionsContext(context.Background(), camera)
+}
+
+// GetFocusMoveOptionsContext is like GetFocusMoveOptions but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetFocusMoveOptionsContext(ctx context.Context, camera *Camera) (*FocusMoveOptions, error) {
+	resp, err := c.imagingCall(ctx, camera, "GetMoveOptions", func(token string) string {
+		return fmt.Sprintf(`<timg:GetMoveOptions><timg:VideoSourceToken>%s</timg:VideoSourceToken></timg:GetMoveOptions>`, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Options struct {
+			AbsolutePosition *FloatRange `xml:"Absolute>Position"`
+			AbsoluteSpeed    *FloatRange `xml:"Absolute>Speed"`
+			RelativeDistance *FloatRange `xml:"Relative>Distance"`
+			RelativeSpeed    *FloatRange `xml:"Relative>Speed"`
+			ContinuousSpeed  *FloatRange `xml:"Continuous>Speed"`
+		} `xml:"Body>GetMoveOptionsResponse>MoveOptions"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse focus move options: %v", err)
+	}
+	opts := FocusMoveOptions(parsed.Options)
+	return &opts, nil
+}
+
+// GetImagingStatus returns the focus position and move state of the camera's
+// video source.
+func (c *Client) GetImagingStatus(camera *Camera) (*FocusStatus, error) {
+	return c.GetImagingStatusContext(context.Background(), camera)
+}
+
+// GetImagingStatusContext is like GetImagingStatus but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetImagingStatusContext(ctx context.Context, camera *Camera) (*FocusStatus, error) {
+	resp, err := c.imagingCall(ctx, camera, "GetStatus", func(token string) string {
+		return fmt.Sprintf(`<timg:GetStatus><timg:VideoSourceToken>%s</timg:VideoSourceToken></timg:GetStatus>`, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Focus struct {
+			Position   float64 `xml:"Position"`
+			MoveStatus string  `xml:"MoveStatus"`
+			Error      string  `xml:"Error"`
+		} `xml:"Body>GetStatusResponse>Status>FocusStatus20"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse imaging status: %v", err)
+	}
+	return &FocusStatus{
+		Position:   parsed.Focus.Position,
+		MoveStatus: strings.TrimSpace(parsed.Focus.MoveStatus),
+		Error:      strings.TrimSpace(parsed.Focus.Error),
+	}, nil
+}
+
+// OnePushAutoFocus focuses the lens once and then holds that focus: it
+// switches the video source to AUTO focus, waits settle for the lens to find
+// focus, then locks it by switching back to MANUAL. Cameras already in AUTO
+// focus are left alone. An error is returned when the camera does not offer
+// AUTO focus.
+func (c *Client) OnePushAutoFocus(camera *Camera, settle time.Duration) error {
+	return c.OnePushAutoFocusContext(context.Background(), camera, settle)
+}
+
+// OnePushAutoFocusContext is like OnePushAutoFocus but uses ctx for
+// cancellation and deadlines, including the settle wait.
+func (c *Client) OnePushAutoFocusContext(ctx context.Context, camera *Camera, settle time.Duration) error {
+	settings, err := c.GetImagingSettingsContext(ctx, camera)
+	if err != nil {
+		return err
+	}
+	focus := FocusSettings{AutoFocusMode: "MANUAL"}
+	if settings.Focus != nil {
+		focus = *settings.Focus
+	}
+	if strings.EqualFold(focus.AutoFocusMode, "AUTO") {
+		return nil
+	}
+
+	auto := focus
+	auto.AutoFocusMode = "AUTO"
+	err = c.SetImagingSettingsContext(ctx, camera, ImagingSettings{VideoSourceToken: settings.VideoSourceToken, Focus: &auto})
+	if err != nil {
+		return fmt.Errorf("one-push autofocus not supported: %w", err)
+	}
+
+	timer := time.NewTimer(settle)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+	case <-timer.C:
+	}
+
+	// Lock the focus even if ctx ended during the wait, so the camera is not
+	// left hunting in AUTO mode.
+	lockCtx := ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		lockCtx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+	}
+	if err := c.SetImagingSettingsContext(lockCtx, camera, ImagingSettings{VideoSourceToken: settings.VideoSourceToken, Focus: &focus}); err != nil {
+		return fmt.Errorf("failed to lock focus: %w", err)
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,86 @@
+package onvif
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFocusControl(t *testing.T) {
+	var gotBody string
+	var setBodies []string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		switch action {
+		case "http://www.onvif.org/ver10/media/wsdl/GetVideoSources":
+			return `<trt:GetVideoSourcesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:VideoSources token="src0"/></trt:GetVideoSourcesResponse>`
+		case "http://www.onvif.org/ver20/imaging/wsdl/GetMoveOptions":
+			return `<timg:GetMoveOptionsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"><timg:MoveOptions>
+<tt:Absolute><tt:Position><tt:Min>0</tt:Min><tt:Max>1</tt:Max></tt:Position><tt:Speed><tt:Min>0.1</tt:Min><tt:Max>1</tt:Max></tt:Speed></tt:Absolute>
+<tt:Continuous><tt:Speed><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:Speed></tt:Continuous>
+</timg:MoveOptions></timg:GetMoveOptionsResponse>`
+		case "http://www.onvif.org/ver20/imaging/wsdl/GetStatus":
+			return `<timg:GetStatusResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"><timg:Status>
+<tt:FocusStatus20><tt:Position>0.42</tt:Position><tt:MoveStatus>MOVING</tt:MoveStatus></tt:FocusStatus20>
+</timg:Status></timg:GetStatusResponse>`
+		case "http://www.onvif.org/ver20/imaging/wsdl/GetImagingSettings":
+			return `<timg:GetImagingSettingsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"><timg:ImagingSettings>
+<tt:Focus><tt:AutoFocusMode>MANUAL</tt:AutoFocusMode><tt:NearLimit>1.5</tt:NearLimit></tt:Focus></timg:ImagingSettings></timg:GetImagingSettingsResponse>`
+		case "http://www.onvif.org/ver20/imaging/wsdl/SetImagingSettings":
+			setBodies = append(setBodies, body)
+		}
+		return `<timg:Response xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"/>`
+	})
+	camera := &Camera{MediaURL: "http://192.0.2.10/onvif/media", ImagingURL: "http://192.0.2.10/onvif/imaging"}
+
+	speed := 0.5
+	if err := c.MoveFocus(camera, FocusMove{Absolute: &AbsoluteFocus{Position: 0.3, Speed: &speed}}); err != nil {
+		t.Fatalf("MoveFocus() error = %v", err)
+	}
+	if !strings.Contains(gotBody, `<timg:VideoSourceToken>src0</timg:VideoSourceToken><timg:Focus><tt:Absolute><tt:Position>0.3</tt:Position><tt:Speed>0.5</tt:Speed></tt:Absolute></timg:Focus>`) {
+		t.Errorf("MoveFocus body = %s", gotBody)
+	}
+	if err := c.MoveFocus(camera, FocusMove{}); err == nil {
+		t.Error("MoveFocus(empty) succeeded, want error")
+	}
+
+	// Moves are checked against GetMoveOptions: values are clamped, and a
+	// move the camera does not list is refused before it is sent.
+	gotBody = ""
+	if err := c.MoveFocus(camera, FocusMove{Continuous: &ContinuousFocus{Speed: 3}}); err != nil {
+		t.Fatalf("MoveFocus(continuous) error = %v", err)
+	}
+	if !strings.Contains(gotBody, `<tt:Continuous><tt:Speed>1</tt:Speed></tt:Continuous>`) {
+		t.Errorf("MoveFocus(continuous) body = %s", gotBody)
+	}
+	gotBody = ""
+	if err := c.MoveFocus(camera, FocusMove{Relative: &RelativeFocus{Distance: 0.1}}); err == nil ||
+		!strings.Contains(err.Error(), "relative focus move not supported") || strings.Contains(gotBody, "<timg:Move>") {
+		t.Errorf("MoveFocus(relative) = %v, body = %s", err, gotBody)
+	}
+	if err := c.StopFocus(camera); err != nil || !strings.Contains(gotBody, "<timg:Stop>") {
+		t.Errorf("StopFocus() err = %v, body = %s", err, gotBody)
+	}
+
+	opts, err := c.GetFocusMoveOptions(camera)
+	if err != nil {
+		t.Fatalf("GetFocusMoveOptions() error = %v", err)
+	}
+	if opts.AbsolutePosition == nil || opts.AbsoluteSpeed.Min != 0.1 || opts.RelativeDistance != nil ||
+		opts.ContinuousSpeed == nil || opts.ContinuousSpeed.Min != -1 {
+		t.Errorf("options = %+v", opts)
+	}
+
+	status, err := c.GetImagingStatus(camera)
+	if err != nil || status.Position != 0.42 || status.MoveStatus != "MOVING" {
+		t.Errorf("GetImagingStatus() = %+v, %v", status, err)
+	}
+
+	if err := c.OnePushAutoFocus(camera, 0); err != nil {
+		t.Fatalf("OnePushAutoFocus() error = %v", err)
+	}
+	if len(setBodies) != 2 ||
+		!strings.Contains(setBodies[0], `<tt:AutoFocusMode>AUTO</tt:AutoFocusMode><tt:NearLimit>1.5</tt:NearLimit>`) ||
+		!strings.Contains(setBodies[1], `<tt:AutoFocusMode>MANUAL</tt:AutoFocusMode><tt:NearLimit>1.5</tt:NearLimit>`) {
+		t.Errorf("OnePushAutoFocus writes = %q", setBodies)
+	}
+}
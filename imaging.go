@@ -1,6 +1,7 @@
 package onvif
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strings"
@@ -17,11 +18,11 @@ func imagingURLHeuristic(address string) string {
 }
 
 // getVideoSourceToken retrieves the first video source token from media profiles
-func (c *Client) getVideoSourceToken(camera *Camera) (string, error) {
-	mediaURL := c.resolveMediaURL(camera)
+func (c *Client) getVideoSourceToken(ctx context.Context, camera *Camera) (string, error) {
+	mediaURL := c.resolveMediaURL(ctx, camera)
 
 	body := `<trt:GetVideoSources/>`
-	resp, err := c.sendSOAPRequest(mediaURL,
+	resp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/GetVideoSources", body)
 	if err != nil {
 		return "", fmt.Errorf("failed to get video sources: %v", err)
@@ -44,20 +45,275 @@ func (c *Client) getVideoSourceToken(camera *Camera) (string, error) {
 	return sourcesResp.VideoSources[0].Token, nil
 }
 
+// imagingSettingsXML is the parsed form of a tt:ImagingSettings20 element.
+// The nested setting types unmarshal directly since their fields match the
+// schema names.
+type imagingSettingsXML struct {
+	BacklightCompensation *BacklightCompensation `xml:"BacklightCompensation"`
+	Brightness            *float64               `xml:"Brightness"`
+	ColorSaturation       *float64               `xml:"ColorSaturation"`
+	Contrast              *float64               `xml:"Contrast"`
+	Exposure              *ExposureSettings      `xml:"Exposure"`
+	Focus                 *FocusSettings         `xml:"Focus"`
+	IrCutFilter           string                 `xml:"IrCutFilter"`
+	Sharpness             *float64               `xml:"Sharpness"`
+	WideDynamicRange      *WideDynamicRange      `xml:"WideDynamicRange"`
+	WhiteBalance          *WhiteBalance          `xml:"WhiteBalance"`
+	ImageStabilization    *ImageStabilization    `xml:"Extension>ImageStabilization"`
+}
+
+func (x imagingSettingsXML) settings(token string) *ImagingSettings {
+	return &ImagingSettings{
+		VideoSourceToken:      token,
+		IrCutFilter:           IrCutFilterMode(strings.TrimSpace(x.IrCutFilter)),
+		Brightness:            x.Brightness,
+		ColorSaturation:       x.ColorSaturation,
+		Contrast:              x.Contrast,
+		Sharpness:             x.Sharpness,
+		BacklightCompensation: x.BacklightCompensation,
+		Exposure:              x.Exposure,
+		Focus:                 x.Focus,
+		WideDynamicRange:      x.WideDynamicRange,
+		WhiteBalance:          x.WhiteBalance,
+		ImageStabilization:    x.ImageStabilization,
+	}
+}
+
+// merge overlays the settings present in update onto s. Nested settings are
+// merged field by field, so a partial Exposure (say, only Mode) keeps the
+// camera's limits.
+func (s *ImagingSettings) merge(update ImagingSettings) {
+	if update.IrCutFilter != "" {
+		s.IrCutFilter = update.IrCutFilter
+	}
+	mergeFloat(&s.Brightness, update.Brightness)
+	mergeFloat(&s.ColorSaturation, update.ColorSaturation)
+	mergeFloat(&s.Contrast, update.Contrast)
+	mergeFloat(&s.Sharpness, update.Sharpness)
+
+	if u := update.BacklightCompensation; u != nil {
+		if s.BacklightCompensation == nil {
+			s.BacklightCompensation = &BacklightCompensation{}
+		}
+		mergeMode(&s.BacklightCompensation.Mode, u.Mode)
+		mergeFloat(&s.BacklightCompensation.Level, u.Level)
+	}
+	if u := update.Exposure; u != nil {
+		if s.Exposure == nil {
+			s.Exposure = &ExposureSettings{}
+		}
+		e := s.Exposure
+		mergeMode(&e.Mode, u.Mode)
+		mergeMode(&e.Priority, u.Priority)
+		mergeFloat(&e.MinExposureTime, u.MinExposureTime)
+		mergeFloat(&e.MaxExposureTime, u.MaxExposureTime)
+		mergeFloat(&e.MinGain, u.MinGain)
+		mergeFloat(&e.MaxGain, u.MaxGain)
+		mergeFloat(&e.MinIris, u.MinIris)
+		mergeFloat(&e.MaxIris, u.MaxIris)
+		mergeFloat(&e.ExposureTime, u.ExposureTime)
+		mergeFloat(&e.Gain, u.Gain)
+		mergeFloat(&e.Iris, u.Iris)
+	}
+	if u := update.Focus; u != nil {
+		if s.Focus == nil {
+			s.Focus = &FocusSettings{}
+		}
+		mergeMode(&s.Focus.AutoFocusMode, u.AutoFocusMode)
+		mergeFloat(&s.Focus.DefaultSpeed, u.DefaultSpeed)
+		mergeFloat(&s.Focus.NearLimit, u.NearLimit)
+		mergeFloat(&s.Focus.FarLimit, u.FarLimit)
+	}
+	if u := update.WideDynamicRange; u != nil {
+		if s.WideDynamicRange == nil {
+			s.WideDynamicRange = &WideDynamicRange{}
+		}
+		mergeMode(&s.WideDynamicRange.Mode, u.Mode)
+		mergeFloat(&s.WideDynamicRange.Level, u.Level)
+	}
+	if u := update.WhiteBalance; u != nil {
+		if s.WhiteBalance == nil {
+			s.WhiteBalance = &WhiteBalance{}
+		}
+		mergeMode(&s.WhiteBalance.Mode, u.Mode)
+		mergeFloat(&s.WhiteBalance.CrGain, u.CrGain)
+		mergeFloat(&s.WhiteBalance.CbGain, u.CbGain)
+	}
+	if u := update.ImageStabilization; u != nil {
+		if s.ImageStabilization == nil {
+			s.ImageStabilization = &ImageStabilization{}
+		}
+		mergeMode(&s.ImageStabilization.Mode, u.Mode)
+		mergeFloat(&s.ImageStabilization.Level, u.Level)
+	}
+}
+
+func mergeMode(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func mergeFloat(dst **float64, v *float64) {
+	if v != nil {
+		*dst = v
+	}
+}
+
+// clone returns a copy of s whose nested settings do not alias s's.
+func (s ImagingSettings) clone() ImagingSettings {
+	if s.BacklightCompensation != nil {
+		v := *s.BacklightCompensation
+		s.BacklightCompensation = &v
+	}
+	if s.Exposure != nil {
+		v := *s.Exposure
+		s.Exposure = &v
+	}
+	if s.Focus != nil {
+		v := *s.Focus
+		s.Focus = &v
+	}
+	if s.WideDynamicRange != nil {
+		v := *s.WideDynamicRange
+		s.WideDynamicRange = &v
+	}
+	if s.WhiteBalance != nil {
+		v := *s.WhiteBalance
+		s.WhiteBalance = &v
+	}
+	if s.ImageStabilization != nil {
+		v := *s.ImageStabilization
+		s.ImageStabilization = &v
+	}
+	return s
+}
+
+// buildSetImagingSettingsBody renders a SetImagingSettings request body. The
+// element order follows the tt:ImagingSettings20 schema. Values that only
+// apply in another mode (e.g. manual gains while in AUTO) are omitted, since
+// some cameras reject them.
+func buildSetImagingSettingsBody(token string, s *ImagingSettings) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<timg:SetImagingSettings><timg:VideoSourceToken>%s</timg:VideoSourceToken><timg:ImagingSettings>`, token)
+
+	writeFloat := func(elem string, v *float64) {
+		if v != nil {
+			fmt.Fprintf(&b, `<tt:%s>%g</tt:%s>`, elem, *v, elem)
+		}
+	}
+
+	if bc := s.BacklightCompensation; bc != nil {
+		fmt.Fprintf(&b, `<tt:BacklightCompensation><tt:Mode>%s</tt:Mode>`, bc.Mode)
+		if strings.EqualFold(bc.Mode, "ON") {
+			writeFloat("Level", bc.Level)
+		}
+		b.WriteString(`</tt:BacklightCompensation>`)
+	}
+	writeFloat("Brightness", s.Brightness)
+	writeFloat("ColorSaturation", s.ColorSaturation)
+	writeFloat("Contrast", s.Contrast)
+
+	if e := s.Exposure; e != nil {
+		fmt.Fprintf(&b, `<tt:Exposure><tt:Mode>%s</tt:Mode>`, e.Mode)
+		if e.Priority != "" {
+			fmt.Fprintf(&b, `<tt:Priority>%s</tt:Priority>`, e.Priority)
+		}
+		if strings.EqualFold(e.Mode, "MANUAL") {
+			writeFloat("ExposureTime", e.ExposureTime)
+			writeFloat("Gain", e.Gain)
+			writeFloat("Iris", e.Iris)
+		} else {
+			writeFloat("MinExposureTime", e.MinExposureTime)
+			writeFloat("MaxExposureTime", e.MaxExposureTime)
+			writeFloat("MinGain", e.MinGain)
+			writeFloat("MaxGain", e.MaxGain)
+			writeFloat("MinIris", e.MinIris)
+			writeFloat("MaxIris", e.MaxIris)
+		}
+		b.WriteString(`</tt:Exposure>`)
+	}
+	if f := s.Focus; f != nil {
+		fmt.Fprintf(&b, `<tt:Focus><tt:AutoFocusMode>%s</tt:AutoFocusMode>`, f.AutoFocusMode)
+		writeFloat("DefaultSpeed", f.DefaultSpeed)
+		writeFloat("NearLimit", f.NearLimit)
+		writeFloat("FarLimit", f.FarLimit)
+		b.WriteString(`</tt:Focus>`)
+	}
+	if s.IrCutFilter != "" {
+		fmt.Fprintf(&b, `<tt:IrCutFilter>%s</tt:IrCutFilter>`, s.IrCutFilter)
+	}
+	writeFloat("Sharpness", s.Sharpness)
+	if w := s.WideDynamicRange; w != nil {
+		fmt.Fprintf(&b, `<tt:WideDynamicRange><tt:Mode>%s</tt:Mode>`, w.Mode)
+		if strings.EqualFold(w.Mode, "ON") {
+			writeFloat("Level", w.Level)
+		}
+		b.WriteString(`</tt:WideDynamicRange>`)
+	}
+	if w := s.WhiteBalance; w != nil {
+		fmt.Fprintf(&b, `<tt:WhiteBalance><tt:Mode>%s</tt:Mode>`, w.Mode)
+		if strings.EqualFold(w.Mode, "MANUAL") {
+			writeFloat("CrGain", w.CrGain)
+			writeFloat("CbGain", w.CbGain)
+		}
+		b.WriteString(`</tt:WhiteBalance>`)
+	}
+	if is := s.ImageStabilization; is != nil {
+		fmt.Fprintf(&b, `<tt:Extension><tt:ImageStabilization><tt:Mode>%s</tt:Mode>`, is.Mode)
+		if strings.EqualFold(is.Mode, "ON") {
+			writeFloat("Level", is.Level)
+		}
+		b.WriteString(`</tt:ImageStabilization></tt:Extension>`)
+	}
+
+	b.WriteString(`</timg:ImagingSettings><timg:ForcePersistence>true</timg:ForcePersistence></timg:SetImagingSettings>`)
+	return b.String()
+}
+
+// imagingCall sends an Imaging service request for the camera's first video
+// source; body receives the source token. The raw response is returned once
+// it is known not to be a fault.
+func (c *Client) imagingCall(ctx context.Context, camera *Camera, op string, body func(token string) string) ([]byte, error) {
+	token, err := c.getVideoSourceToken(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	imagingURL := c.resolveImagingURL(ctx, camera)
+	resp, err := c.sendSOAPRequest(ctx, imagingURL, "http://www.onvif.org/ver20/imaging/wsdl/"+op, body(token))
+	if err != nil {
+		return nil, fmt.Errorf("imaging %s failed: %v", op, err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, fmt.Errorf("imaging %s failed: %w", op, err)
+	}
+	return resp, nil
+}
+
 // GetImagingSettings retrieves the current imaging settings for the camera's video source
 func (c *Client) GetImagingSettings(camera *Camera) (*ImagingSettings, error) {
-	token, err := c.getVideoSourceToken(camera)
+	return c.GetImagingSettingsContext(context.Background(), camera)
+}
+
+// GetImagingSettingsContext is like GetImagingSettings but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetImagingSettingsContext(ctx context.Context, camera *Camera) (*ImagingSettings, error) {
+	token, err := c.getVideoSourceToken(ctx, camera)
 	if err != nil {
 		return nil, err
 	}
+	return c.getImagingSettings(ctx, camera, token)
+}
 
-	imagingURL := c.resolveImagingURL(camera)
+// getImagingSettings fetches the imaging settings of the given video source.
+func (c *Client) getImagingSettings(ctx context.Context, camera *Camera, token string) (*ImagingSettings, error) {
+	imagingURL := c.resolveImagingURL(ctx, camera)
 
 	body := fmt.Sprintf(`<timg:GetImagingSettings>
 		<timg:VideoSourceToken>%s</timg:VideoSourceToken>
 	</timg:GetImagingSettings>`, token)
 
-	resp, err := c.sendSOAPRequest(imagingURL,
+	resp, err := c.sendSOAPRequest(ctx, imagingURL,
 		"http://www.onvif.org/ver20/imaging/wsdl/GetImagingSettings", body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get imaging settings: %v", err)
@@ -67,31 +323,306 @@ func (c *Client) GetImagingSettings(camera *Camera) (*ImagingSettings, error) {
 		return nil, err
 	}
 
-	settings := &ImagingSettings{
-		VideoSourceToken: token,
+	var parsed struct {
+		Settings imagingSettingsXML `xml:"Body>GetImagingSettingsResponse>ImagingSettings"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse imaging settings: %v", err)
+	}
+	return parsed.Settings.settings(token), nil
+}
+
+// SetImagingSettings changes the imaging settings of a video source
+// (settings.VideoSourceToken, or the first source when empty).
+//
+// SetImagingSettings is applied as a whole by many cameras, so it reads the
+// current settings, overlays the ones present in settings and sends the full
+// result back; settings left nil keep their current value.
+func (c *Client) SetImagingSettings(camera *Camera, settings ImagingSettings) error {
+	return c.SetImagingSettingsContext(context.Background(), camera, settings)
+}
+
+// SetImagingSettingsContext is like SetImagingSettings but uses ctx for
+// cancellation and deadlines.
+func (c *Client) SetImagingSettingsContext(ctx context.Context, camera *Camera, settings ImagingSettings) error {
+	token := settings.VideoSourceToken
+	if token == "" {
+		var err error
+		if token, err = c.getVideoSourceToken(ctx, camera); err != nil {
+			return err
+		}
+	}
+
+	// Check the requested values against the advertised options so the caller
+	// gets a descriptive error instead of an opaque ter:InvalidArgVal. Cameras
+	// that do not implement GetOptions are written unchecked.
+	if opts, err := c.getImagingOptions(ctx, camera, token); err == nil {
+		settings = settings.clone() // Validate clamps in place; keep the caller's values
+		if err := opts.Validate(&settings); err != nil {
+			return err
+		}
+	}
+
+	current, err := c.getImagingSettings(ctx, camera, token)
+	if err != nil {
+		return fmt.Errorf("failed to read current imaging settings: %w", err)
+	}
+	current.merge(settings)
+
+	imagingURL := c.resolveImagingURL(ctx, camera)
+	resp, err := c.sendSOAPRequest(ctx, imagingURL,
+		"http://www.onvif.org/ver20/imaging/wsdl/SetImagingSettings", buildSetImagingSettingsBody(token, current))
+	if err != nil {
+		return fmt.Errorf("failed to set imaging settings: %v", err)
+	}
+
+	if err := parseSOAPFault(resp); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetImagingOptions returns the ranges and modes the camera's video source
+// accepts for each imaging setting.
+func (c *Client) GetImagingOptions(camera *Camera) (*ImagingOptions, error) {
+	return c.GetImagingOptionsContext(context.Background(), camera)
+}
+
+// GetImagingOptionsContext is like GetImagingOptions but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetImagingOptionsContext(ctx context.Context, camera *Camera) (*ImagingOptions, error) {
+	token, err := c.getVideoSourceToken(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	return c.getImagingOptions(ctx, camera, token)
+}
+
+// getImagingOptions fetches the imaging options of the given video source.
+func (c *Client) getImagingOptions(ctx context.Context, camera *Camera, token string) (*ImagingOptions, error) {
+	imagingURL := c.resolveImagingURL(ctx, camera)
+	body := fmt.Sprintf(`<timg:GetOptions><timg:VideoSourceToken>%s</timg:VideoSourceToken></timg:GetOptions>`, token)
+	resp, err := c.sendSOAPRequest(ctx, imagingURL,
+		"http://www.onvif.org/ver20/imaging/wsdl/GetOptions", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get imaging options: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, err
+	}
+	return parseImagingOptions(resp)
+}
+
+// parseImagingOptions extracts the ImagingOptions20 from a GetOptions
+// response. Ranges are reported as <tt:X><tt:Min/><tt:Max/></tt:X>, which
+// FloatRange unmarshals directly.
+func parseImagingOptions(resp []byte) (*ImagingOptions, error) {
+	type modeLevel struct {
+		Modes []string    `xml:"Mode"`
+		Level *FloatRange `xml:"Level"`
+	}
+	var parsed struct {
+		Options struct {
+			BacklightCompensation modeLevel   `xml:"BacklightCompensation"`
+			Brightness            *FloatRange `xml:"Brightness"`
+			ColorSaturation       *FloatRange `xml:"ColorSaturation"`
+			Contrast              *FloatRange `xml:"Contrast"`
+			Exposure              struct {
+				Modes           []string    `xml:"Mode"`
+				Priorities      []string    `xml:"Priority"`
+				MinExposureTime *FloatRange `xml:"MinExposureTime"`
+				MaxExposureTime *FloatRange `xml:"MaxExposureTime"`
+				MinGain         *FloatRange `xml:"MinGain"`
+				MaxGain         *FloatRange `xml:"MaxGain"`
+				MinIris         *FloatRange `xml:"MinIris"`
+				MaxIris         *FloatRange `xml:"MaxIris"`
+				ExposureTime    *FloatRange `xml:"ExposureTime"`
+				Gain            *FloatRange `xml:"Gain"`
+				Iris            *FloatRange `xml:"Iris"`
+			} `xml:"Exposure"`
+			Focus struct {
+				AutoFocusModes []string    `xml:"AutoFocusModes"`
+				DefaultSpeed   *FloatRange `xml:"DefaultSpeed"`
+				NearLimit      *FloatRange `xml:"NearLimit"`
+				FarLimit       *FloatRange `xml:"FarLimit"`
+			} `xml:"Focus"`
+			IrCutFilterModes []string    `xml:"IrCutFilterModes"`
+			Sharpness        *FloatRange `xml:"Sharpness"`
+			WideDynamicRange modeLevel   `xml:"WideDynamicRange"`
+			WhiteBalance     struct {
+				Modes  []string    `xml:"Mode"`
+				YrGain *FloatRange `xml:"YrGain"`
+				YbGain *FloatRange `xml:"YbGain"`
+			} `xml:"WhiteBalance"`
+			ImageStabilization modeLevel `xml:"Extension>ImageStabilization"`
+		} `xml:"Body>GetOptionsResponse>ImagingOptions"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse imaging options: %v", err)
+	}
+
+	o := parsed.Options
+	opts := &ImagingOptions{
+		BacklightCompensationModes: trimAll(o.BacklightCompensation.Modes),
+		BacklightCompensationLevel: o.BacklightCompensation.Level,
+		Brightness:                 o.Brightness,
+		ColorSaturation:            o.ColorSaturation,
+		Contrast:                   o.Contrast,
+		Sharpness:                  o.Sharpness,
+		ExposureModes:              trimAll(o.Exposure.Modes),
+		ExposurePriorities:         trimAll(o.Exposure.Priorities),
+		MinExposureTime:            o.Exposure.MinExposureTime,
+		MaxExposureTime:            o.Exposure.MaxExposureTime,
+		MinGain:                    o.Exposure.MinGain,
+		MaxGain:                    o.Exposure.MaxGain,
+		MinIris:                    o.Exposure.MinIris,
+		MaxIris:                    o.Exposure.MaxIris,
+		ExposureTime:               o.Exposure.ExposureTime,
+		Gain:                       o.Exposure.Gain,
+		Iris:                       o.Exposure.Iris,
+		AutoFocusModes:             trimAll(o.Focus.AutoFocusModes),
+		FocusDefaultSpeed:          o.Focus.DefaultSpeed,
+		FocusNearLimit:             o.Focus.NearLimit,
+		FocusFarLimit:              o.Focus.FarLimit,
+		WideDynamicRangeModes:      trimAll(o.WideDynamicRange.Modes),
+		WideDynamicRangeLevel:      o.WideDynamicRange.Level,
+		WhiteBalanceModes:          trimAll(o.WhiteBalance.Modes),
+		WhiteBalanceCrGain:         o.WhiteBalance.YrGain,
+		WhiteBalanceCbGain:         o.WhiteBalance.YbGain,
+		ImageStabilizationModes:    trimAll(o.ImageStabilization.Modes),
+		ImageStabilizationLevel:    o.ImageStabilization.Level,
+	}
+	for _, m := range trimAll(o.IrCutFilterModes) {
+		opts.IrCutFilterModes = append(opts.IrCutFilterModes, IrCutFilterMode(m))
+	}
+	return opts, nil
+}
+
+// Validate checks the settings present in s against the options: numeric
+// values are clamped in place to the advertised ranges, and a mode the camera
+// does not list is reported as an error. Unset (nil or empty) fields and
+// settings the camera did not advertise options for are left alone, as is a
+// FarLimit of 0, which means infinity.
+func (o *ImagingOptions) Validate(s *ImagingSettings) error {
+	if len(o.IrCutFilterModes) > 0 {
+		modes := make([]string, len(o.IrCutFilterModes))
+		for i, m := range o.IrCutFilterModes {
+			modes[i] = string(m)
+		}
+		if err := checkMode("IR cut filter", string(s.IrCutFilter), modes); err != nil {
+			return err
+		}
 	}
 
-	// Try structured parsing
-	type ImagingResponse struct {
-		IrCutFilter string `xml:"Body>GetImagingSettingsResponse>ImagingSettings>IrCutFilter"`
+	s.Brightness = clampOptional(s.Brightness, o.Brightness)
+	s.ColorSaturation = clampOptional(s.ColorSaturation, o.ColorSaturation)
+	s.Contrast = clampOptional(s.Contrast, o.Contrast)
+	s.Sharpness = clampOptional(s.Sharpness, o.Sharpness)
+
+	if bc := s.BacklightCompensation; bc != nil {
+		if err := checkMode("backlight compensation", bc.Mode, o.BacklightCompensationModes); err != nil {
+			return err
+		}
+		bc.Level = clampOptional(bc.Level, o.BacklightCompensationLevel)
+	}
+	if e := s.Exposure; e != nil {
+		if err := checkMode("exposure", e.Mode, o.ExposureModes); err != nil {
+			return err
+		}
+		if err := checkMode("exposure priority", e.Priority, o.ExposurePriorities); err != nil {
+			return err
+		}
+		e.MinExposureTime = clampOptional(e.MinExposureTime, o.MinExposureTime)
+		e.MaxExposureTime = clampOptional(e.MaxExposureTime, o.MaxExposureTime)
+		e.MinGain = clampOptional(e.MinGain, o.MinGain)
+		e.MaxGain = clampOptional(e.MaxGain, o.MaxGain)
+		e.MinIris = clampOptional(e.MinIris, o.MinIris)
+		e.MaxIris = clampOptional(e.MaxIris, o.MaxIris)
+		e.ExposureTime = clampOptional(e.ExposureTime, o.ExposureTime)
+		e.Gain = clampOptional(e.Gain, o.Gain)
+		e.Iris = clampOptional(e.Iris, o.Iris)
+	}
+	if f := s.Focus; f != nil {
+		if err := checkMode("auto focus", f.AutoFocusMode, o.AutoFocusModes); err != nil {
+			return err
+		}
+		f.DefaultSpeed = clampOptional(f.DefaultSpeed, o.FocusDefaultSpeed)
+		f.NearLimit = clampOptional(f.NearLimit, o.FocusNearLimit)
+		if f.FarLimit == nil || *f.FarLimit != 0 {
+			f.FarLimit = clampOptional(f.FarLimit, o.FocusFarLimit)
+		}
+	}
+	if w := s.WideDynamicRange; w != nil {
+		if err := checkMode("wide dynamic range", w.Mode, o.WideDynamicRangeModes); err != nil {
+			return err
+		}
+		w.Level = clampOptional(w.Level, o.WideDynamicRangeLevel)
 	}
+	if w := s.WhiteBalance; w != nil {
+		if err := checkMode("white balance", w.Mode, o.WhiteBalanceModes); err != nil {
+			return err
+		}
+		w.CrGain = clampOptional(w.CrGain, o.WhiteBalanceCrGain)
+		w.CbGain = clampOptional(w.CbGain, o.WhiteBalanceCbGain)
+	}
+	if is := s.ImageStabilization; is != nil {
+		if err := checkMode("image stabilization", is.Mode, o.ImageStabilizationModes); err != nil {
+			return err
+		}
+		is.Level = clampOptional(is.Level, o.ImageStabilizationLevel)
+	}
+	return nil
+}
 
-	var imgResp ImagingResponse
-	if err := xml.Unmarshal(resp, &imgResp); err == nil {
-		settings.IrCutFilter = IrCutFilterMode(imgResp.IrCutFilter)
+// checkMode reports an error when mode is set, allowed is non-empty and
+// allowed does not contain mode (case-insensitively).
+func checkMode(setting, mode string, allowed []string) error {
+	if mode == "" || len(allowed) == 0 {
+		return nil
 	}
+	for _, m := range allowed {
+		if strings.EqualFold(m, mode) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s mode %q not supported (camera allows %s)", setting, mode, strings.Join(allowed, ", "))
+}
 
-	return settings, nil
+// clampOptional returns a clamped copy of v, leaving the caller's value
+// untouched.
+func clampOptional(v *float64, r *FloatRange) *float64 {
+	if v == nil || r == nil {
+		return v
+	}
+	clamped := r.Clamp(*v)
+	return &clamped
 }
 
 // SetIrCutFilter sets the IR cut filter (day/night) mode for the camera
 func (c *Client) SetIrCutFilter(camera *Camera, mode IrCutFilterMode) error {
-	token, err := c.getVideoSourceToken(camera)
+	return c.SetIrCutFilterContext(context.Background(), camera, mode)
+}
+
+// SetIrCutFilterContext is like SetIrCutFilter but uses ctx for cancellation
+// and deadlines.
+//
+// Unlike SetImagingSettings it sends only the IR cut filter, without reading
+// the other settings, which keeps day/night switching to a single write that
+// cameras rarely reject. The mode is still checked against GetOptions.
+func (c *Client) SetIrCutFilterContext(ctx context.Context, camera *Camera, mode IrCutFilterMode) error {
+	token, err := c.getVideoSourceToken(ctx, camera)
 	if err != nil {
 		return err
 	}
 
-	imagingURL := c.resolveImagingURL(camera)
+	if opts, err := c.getImagingOptions(ctx, camera, token); err == nil {
+		if err := opts.Validate(&ImagingSettings{IrCutFilter: mode}); err != nil {
+			return err
+		}
+	}
+
+	imagingURL := c.resolveImagingURL(ctx, camera)
 
 	body := fmt.Sprintf(`<timg:SetImagingSettings>
 		<timg:VideoSourceToken>%s</timg:VideoSourceToken>
@@ -100,7 +631,7 @@ func (c *Client) SetIrCutFilter(camera *Camera, mode IrCutFilterMode) error {
 		</timg:ImagingSettings>
 	</timg:SetImagingSettings>`, token, string(mode))
 
-	resp, err := c.sendSOAPRequest(imagingURL,
+	resp, err := c.sendSOAPRequest(ctx, imagingURL,
 		"http://www.onvif.org/ver20/imaging/wsdl/SetImagingSettings", body)
 	if err != nil {
 		return fmt.Errorf("failed to set IR cut filter: %v", err)
@@ -112,3 +643,84 @@ func (c *Client) SetIrCutFilter(camera *Camera, mode IrCutFilterMode) error {
 
 	return nil
 }
+
+// imagingPresetXML is the parsed form of a timg:Preset.
+type imagingPresetXML struct {
+	Token string `xml:"token,attr"`
+	Type  string `xml:"type,attr"`
+	Name  string `xml:"Name"`
+}
+
+// GetImagingPresets returns the scene presets (e.g. "Backlight", "Indoor",
+// "Night") of the camera's video source. Requires Imaging 2.x.
+func (c *Client) GetImagingPresets(camera *Camera) ([]ImagingPreset, error) {
+	return c.GetImagingPresetsContext(context.Background(), camera)
+}
+
+// GetImagingPresetsContext is like GetImagingPresets but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetImagingPresetsContext(ctx context.Context, camera *Camera) ([]ImagingPreset, error) {
+	resp, err := c.imagingCall(ctx, camera, "GetPresets", func(token string) string {
+		return fmt.Sprintf(`<timg:GetPresets><timg:VideoSourceToken>%s</timg:VideoSourceToken></timg:GetPresets>`, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Presets []imagingPresetXML `xml:"Body>GetPresetsResponse>Preset"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse imaging presets: %v", err)
+	}
+
+	presets := make([]ImagingPreset, 0, len(parsed.Presets))
+	for _, p := range parsed.Presets {
+		presets = append(presets, ImagingPreset(p))
+	}
+	return presets, nil
+}
+
+// GetCurrentImagingPreset returns the scene preset currently applied, or nil
+// when the settings no longer match any preset.
+func (c *Client) GetCurrentImagingPreset(camera *Camera) (*ImagingPreset, error) {
+	return c.GetCurrentImagingPresetContext(context.Background(), camera)
+}
+
+// GetCurrentImagingPresetContext is like GetCurrentImagingPreset but uses ctx
+// for cancellation and deadlines.
+func (c *Client) GetCurrentImagingPresetContext(ctx context.Context, camera *Camera) (*ImagingPreset, error) {
+	resp, err := c.imagingCall(ctx, camera, "GetCurrentPreset", func(token string) string {
+		return fmt.Sprintf(`<timg:GetCurrentPreset><timg:VideoSourceToken>%s</timg:VideoSourceToken></timg:GetCurrentPreset>`, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Preset *imagingPresetXML `xml:"Body>GetCurrentPresetResponse>Preset"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse current imaging preset: %v", err)
+	}
+	if parsed.Preset == nil {
+		return nil, nil
+	}
+	preset := ImagingPreset(*parsed.Preset)
+	return &preset, nil
+}
+
+// SetCurrentImagingPreset applies a scene preset to the camera's video source.
+func (c *Client) SetCurrentImagingPreset(camera *Camera, presetToken string) error {
+	return c.SetCurrentImagingPresetContext(context.Background(), camera, presetToken)
+}
+
+// SetCurrentImagingPresetContext is like SetCurrentImagingPreset but uses ctx
+// for cancellation and deadlines.
+func (c *Client) SetCurrentImagingPresetContext(ctx context.Context, camera *Camera, presetToken string) error {
+	_, err := c.imagingCall(ctx, camera, "SetCurrentPreset", func(token string) string {
+		return fmt.Sprintf(`<timg:SetCurrentPreset><timg:VideoSourceToken>%s</timg:VideoSourceToken><timg:PresetToken>%s</timg:PresetToken></timg:SetCurrentPreset>`,
+			token, presetToken)
+	})
+	return err
+}
@@ -0,0 +1,200 @@
+package onvif
+
+import (
+	"strings"
+	"testing"
+)
+
+const getImagingSettingsResponse = `<timg:GetImagingSettingsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"><timg:ImagingSettings>
+<tt:BacklightCompensation><tt:Mode>OFF</tt:Mode><tt:Level>10</tt:Level></tt:BacklightCompensation>
+<tt:Brightness>50</tt:Brightness><tt:ColorSaturation>40</tt:ColorSaturation><tt:Contrast>60</tt:Contrast>
+<tt:Exposure><tt:Mode>AUTO</tt:Mode><tt:MinExposureTime>33</tt:MinExposureTime><tt:MaxExposureTime>33333</tt:MaxExposureTime>
+<tt:MinGain>0</tt:MinGain><tt:MaxGain>36</tt:MaxGain><tt:ExposureTime>1000</tt:ExposureTime><tt:Gain>3</tt:Gain></tt:Exposure>
+<tt:Focus><tt:AutoFocusMode>MANUAL</tt:AutoFocusMode><tt:DefaultSpeed>0.5</tt:DefaultSpeed></tt:Focus>
+<tt:IrCutFilter>AUTO</tt:IrCutFilter>
+<tt:WideDynamicRange><tt:Mode>ON</tt:Mode><tt:Level>70</tt:Level></tt:WideDynamicRange>
+<tt:WhiteBalance><tt:Mode>AUTO</tt:Mode><tt:CrGain>12</tt:CrGain><tt:CbGain>14</tt:CbGain></tt:WhiteBalance>
+<tt:Extension><tt:ImageStabilization><tt:Mode>OFF</tt:Mode></tt:ImageStabilization></tt:Extension>
+</timg:ImagingSettings></timg:GetImagingSettingsResponse>`
+
+// fakeImagingCamera answers GetVideoSources, GetImagingSettings and GetOptions
+// and records the last SetImagingSettings body in *setBody.
+func fakeImagingCamera(setBody *string) *Client {
+	return fakeSOAPClient(func(action, body string) string {
+		switch action {
+		case "http://www.onvif.org/ver10/media/wsdl/GetVideoSources":
+			return `<trt:GetVideoSourcesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:VideoSources token="src0"/></trt:GetVideoSourcesResponse>`
+		case "http://www.onvif.org/ver20/imaging/wsdl/GetImagingSettings":
+			return getImagingSettingsResponse
+		case "http://www.onvif.org/ver20/imaging/wsdl/GetOptions":
+			return `<timg:GetOptionsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"><timg:ImagingOptions>
+<tt:IrCutFilterModes>ON</tt:IrCutFilterModes><tt:IrCutFilterModes>OFF</tt:IrCutFilterModes>
+</timg:ImagingOptions></timg:GetOptionsResponse>`
+		case "http://www.onvif.org/ver20/imaging/wsdl/SetImagingSettings":
+			*setBody = body
+		}
+		return `<timg:Response xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"/>`
+	})
+}
+
+func TestImagingSettingsRoundTrip(t *testing.T) {
+	var setBody string
+	c := fakeImagingCamera(&setBody)
+	camera := &Camera{MediaURL: "http://192.0.2.10/onvif/media", ImagingURL: "http://192.0.2.10/onvif/imaging"}
+
+	s, err := c.GetImagingSettings(camera)
+	if err != nil {
+		t.Fatalf("GetImagingSettings() error = %v", err)
+	}
+	if s.VideoSourceToken != "src0" || s.IrCutFilter != IrCutFilterAuto || s.Brightness == nil || *s.Brightness != 50 ||
+		s.Sharpness != nil || s.Exposure == nil || *s.Exposure.MaxGain != 36 || s.Exposure.Iris != nil || s.Focus == nil || *s.Focus.DefaultSpeed != 0.5 ||
+		s.WideDynamicRange == nil || *s.WideDynamicRange.Level != 70 || s.ImageStabilization == nil || s.ImageStabilization.Mode != "OFF" {
+		t.Errorf("settings = %+v", s)
+	}
+
+	// Changing only brightness must send everything else back unchanged.
+	brightness := 75.0
+	if err := c.SetImagingSettings(camera, ImagingSettings{Brightness: &brightness}); err != nil {
+		t.Fatalf("SetImagingSettings() error = %v", err)
+	}
+	for _, want := range []string{
+		`<timg:VideoSourceToken>src0</timg:VideoSourceToken>`,
+		`<tt:BacklightCompensation><tt:Mode>OFF</tt:Mode></tt:BacklightCompensation><tt:Brightness>75</tt:Brightness><tt:ColorSaturation>40</tt:ColorSaturation><tt:Contrast>60</tt:Contrast>`,
+		`<tt:Exposure><tt:Mode>AUTO</tt:Mode><tt:MinExposureTime>33</tt:MinExposureTime><tt:MaxExposureTime>33333</tt:MaxExposureTime><tt:MinGain>0</tt:MinGain><tt:MaxGain>36</tt:MaxGain></tt:Exposure>`,
+		`<tt:IrCutFilter>AUTO</tt:IrCutFilter>`,
+		`<tt:WideDynamicRange><tt:Mode>ON</tt:Mode><tt:Level>70</tt:Level></tt:WideDynamicRange>`,
+		`<tt:WhiteBalance><tt:Mode>AUTO</tt:Mode></tt:WhiteBalance>`,
+		`<tt:Extension><tt:ImageStabilization><tt:Mode>OFF</tt:Mode></tt:ImageStabilization></tt:Extension>`,
+	} {
+		if !strings.Contains(setBody, want) {
+			t.Errorf("SetImagingSettings body missing %q:\n%s", want, setBody)
+		}
+	}
+	if strings.Contains(setBody, "Sharpness") || strings.Contains(setBody, "<tt:Gain>") {
+		t.Errorf("SetImagingSettings body has unreported or inactive fields:\n%s", setBody)
+	}
+
+	// A partial nested setting keeps the camera's other values.
+	maxGain := 24.0
+	if err := c.SetImagingSettings(camera, ImagingSettings{Exposure: &ExposureSettings{MaxGain: &maxGain}}); err != nil {
+		t.Fatalf("SetImagingSettings() error = %v", err)
+	}
+	if want := `<tt:Exposure><tt:Mode>AUTO</tt:Mode><tt:MinExposureTime>33</tt:MinExposureTime><tt:MaxExposureTime>33333</tt:MaxExposureTime><tt:MinGain>0</tt:MinGain><tt:MaxGain>24</tt:MaxGain></tt:Exposure>`; !strings.Contains(setBody, want) {
+		t.Errorf("SetImagingSettings body missing %q:\n%s", want, setBody)
+	}
+
+	if err := c.SetIrCutFilter(camera, IrCutFilterOff); err != nil {
+		t.Fatalf("SetIrCutFilter() error = %v", err)
+	}
+	if !strings.Contains(setBody, `>OFF</tt:IrCutFilter>`) || strings.Contains(setBody, "Brightness") {
+		t.Errorf("SetIrCutFilter body:\n%s", setBody)
+	}
+	setBody = ""
+	if err := c.SetIrCutFilter(camera, IrCutFilterAuto); err == nil || !strings.Contains(err.Error(), "not supported") || setBody != "" {
+		t.Errorf("SetIrCutFilter(AUTO) = %v, sent %q", err, setBody)
+	}
+}
+
+func TestImagingOptionsValidate(t *testing.T) {
+	resp := []byte(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:tt="http://www.onvif.org/ver10/schema"><env:Body>
+<timg:GetOptionsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"><timg:ImagingOptions>
+<tt:Brightness><tt:Min>0</tt:Min><tt:Max>100</tt:Max></tt:Brightness>
+<tt:Exposure><tt:Mode>AUTO</tt:Mode><tt:Mode>MANUAL</tt:Mode><tt:MaxGain><tt:Min>0</tt:Min><tt:Max>48</tt:Max></tt:MaxGain></tt:Exposure>
+<tt:IrCutFilterModes>ON</tt:IrCutFilterModes><tt:IrCutFilterModes>OFF</tt:IrCutFilterModes>
+<tt:WideDynamicRange><tt:Mode>OFF</tt:Mode><tt:Mode>ON</tt:Mode><tt:Level><tt:Min>0</tt:Min><tt:Max>100</tt:Max></tt:Level></tt:WideDynamicRange>
+<tt:Extension><tt:ImageStabilization><tt:Mode>OFF</tt:Mode></tt:ImageStabilization></tt:Extension>
+</timg:ImagingOptions></timg:GetOptionsResponse></env:Body></env:Envelope>`)
+
+	opts, err := parseImagingOptions(resp)
+	if err != nil {
+		t.Fatalf("parseImagingOptions() error = %v", err)
+	}
+	if opts.Brightness == nil || *opts.Brightness != (FloatRange{0, 100}) || len(opts.ExposureModes) != 2 ||
+		opts.MaxGain == nil || opts.MaxGain.Max != 48 || opts.Sharpness != nil ||
+		len(opts.IrCutFilterModes) != 2 || opts.IrCutFilterModes[1] != IrCutFilterOff ||
+		len(opts.ImageStabilizationModes) != 1 {
+		t.Fatalf("options = %+v", opts)
+	}
+
+	brightness, sharpness := 150.0, 999.0
+	maxGain := 60.0
+	exposure := &ExposureSettings{Mode: "auto", MaxGain: &maxGain}
+	s := ImagingSettings{Brightness: &brightness, Sharpness: &sharpness, Exposure: exposure}
+	if err := opts.Validate(&s); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if *s.Brightness != 100 || brightness != 150 {
+		t.Errorf("Brightness = %v (caller's %v), want clamped copy", *s.Brightness, brightness)
+	}
+	if *s.Sharpness != 999 {
+		t.Errorf("Sharpness without options changed to %v", *s.Sharpness)
+	}
+	if *exposure.MaxGain != 48 || maxGain != 60 {
+		t.Errorf("MaxGain = %v (caller's %v), want clamped copy", *exposure.MaxGain, maxGain)
+	}
+
+	// Unset fields are neither clamped nor checked; FarLimit 0 is infinity.
+	farLimit := 0.0
+	opts.AutoFocusModes, opts.FocusFarLimit = []string{"AUTO"}, &FloatRange{1, 10}
+	partial := ImagingSettings{Exposure: &ExposureSettings{MaxGain: &maxGain}, Focus: &FocusSettings{FarLimit: &farLimit}}
+	if err := opts.Validate(&partial); err != nil {
+		t.Fatalf("Validate(partial) error = %v", err)
+	}
+	if e := partial.Exposure; e.MaxExposureTime != nil || e.MinGain != nil || *partial.Focus.FarLimit != 0 {
+		t.Errorf("Validate(partial) = %+v, %+v", *e, *partial.Focus)
+	}
+
+	for _, bad := range []ImagingSettings{
+		{IrCutFilter: IrCutFilterAuto},
+		{ImageStabilization: &ImageStabilization{Mode: "ON"}},
+	} {
+		if err := opts.Validate(&bad); err == nil || !strings.Contains(err.Error(), "not supported") {
+			t.Errorf("Validate(%+v) error = %v, want unsupported mode", bad, err)
+		}
+	}
+}
+
+func TestImagingPresets(t *testing.T) {
+	var gotBody string
+	current := `<timg:Preset token="p2" type="Night"><tt:Name>Night</tt:Name></timg:Preset>`
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		switch action {
+		case "http://www.onvif.org/ver10/media/wsdl/GetVideoSources":
+			return `<trt:GetVideoSourcesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:VideoSources token="src0"/></trt:GetVideoSourcesResponse>`
+		case "http://www.onvif.org/ver20/imaging/wsdl/GetPresets":
+			return `<timg:GetPresetsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl">
+<timg:Preset token="p1" type="Indoor"><tt:Name>Indoor</tt:Name></timg:Preset>
+<timg:Preset token="p2" type="Night"><tt:Name>Night</tt:Name></timg:Preset>
+</timg:GetPresetsResponse>`
+		case "http://www.onvif.org/ver20/imaging/wsdl/GetCurrentPreset":
+			return `<timg:GetCurrentPresetResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl">` + current + `</timg:GetCurrentPresetResponse>`
+		}
+		return `<timg:Response xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"/>`
+	})
+	camera := &Camera{MediaURL: "http://192.0.2.10/onvif/media", ImagingURL: "http://192.0.2.10/onvif/imaging"}
+
+	presets, err := c.GetImagingPresets(camera)
+	if err != nil {
+		t.Fatalf("GetImagingPresets() error = %v", err)
+	}
+	if len(presets) != 2 || presets[0] != (ImagingPreset{Token: "p1", Type: "Indoor", Name: "Indoor"}) {
+		t.Errorf("presets = %+v", presets)
+	}
+
+	preset, err := c.GetCurrentImagingPreset(camera)
+	if err != nil || preset == nil || preset.Token != "p2" || preset.Type != "Night" {
+		t.Errorf("GetCurrentImagingPreset() = %+v, %v", preset, err)
+	}
+	current = ""
+	if preset, err := c.GetCurrentImagingPreset(camera); err != nil || preset != nil {
+		t.Errorf("GetCurrentImagingPreset() with no preset = %+v, %v", preset, err)
+	}
+
+	if err := c.SetCurrentImagingPreset(camera, "p1"); err != nil {
+		t.Fatalf("SetCurrentImagingPreset() error = %v", err)
+	}
+	if !strings.Contains(gotBody, "<timg:VideoSourceToken>src0</timg:VideoSourceToken><timg:PresetToken>p1</timg:PresetToken>") {
+		t.Errorf("SetCurrentImagingPreset body = %s", gotBody)
+	}
+}
@@ -1,18 +1,62 @@
 package onvif
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strings"
 )
 
+// tokenRef captures only the token attribute of a configuration element.
+type tokenRef struct {
+	Token string `xml:"token,attr"`
+}
+
 // profileXML is the parsed form of a single <Profiles> element from a
 // GetProfiles response, including the full video encoder configuration.
 type profileXML struct {
 	Token string          `xml:"token,attr"`
+	Fixed bool            `xml:"fixed,attr"`
 	Name  string          `xml:"Name"`
 	VEC   videoEncoderXML `xml:"VideoEncoderConfiguration"`
 	VSC   videoSourceXML  `xml:"VideoSourceConfiguration"`
+	PTZ   struct {
+		Token     string `xml:"token,attr"`
+		NodeToken string `xml:"NodeToken"`
+	} `xml:"PTZConfiguration"`
+	AudioSource  tokenRef `xml:"AudioSourceConfiguration"`
+	AudioEncoder tokenRef `xml:"AudioEncoderConfiguration"`
+	Analytics    tokenRef `xml:"VideoAnalyticsConfiguration"`
+	Metadata     tokenRef `xml:"MetadataConfiguration"`
+	AudioOutput  tokenRef `xml:"Extension>AudioOutputConfiguration"`
+	AudioDecoder tokenRef `xml:"Extension>AudioDecoderConfiguration"`
+}
+
+// profile converts a Media1 profile to a MediaProfile.
+func (p profileXML) profile() MediaProfile {
+	mp := MediaProfile{
+		Token:                   p.Token,
+		Name:                    p.Name,
+		Fixed:                   p.Fixed,
+		VideoSourceConfigToken:  p.VSC.Token,
+		VideoSourceToken:        p.VSC.SourceToken,
+		VideoSourceBounds:       p.VSC.Bounds.rectangle(),
+		VideoEncoderConfigToken: p.VEC.Token,
+		AudioSourceConfigToken:  p.AudioSource.Token,
+		AudioEncoderConfigToken: p.AudioEncoder.Token,
+		AudioOutputConfigToken:  p.AudioOutput.Token,
+		AudioDecoderConfigToken: p.AudioDecoder.Token,
+		PTZConfigToken:          p.PTZ.Token,
+		PTZNodeToken:            p.PTZ.NodeToken,
+		MetadataConfigToken:     p.Metadata.Token,
+		AnalyticsConfigToken:    p.Analytics.Token,
+	}
+	if p.VEC.Token != "" {
+		vec := p.VEC.config()
+		vec.ProfileToken, vec.ProfileName = p.Token, p.Name
+		mp.VideoEncoder = &vec
+	}
+	return mp
 }
 
 // videoSourceXML captures the VideoSourceConfiguration fields needed to round
@@ -20,22 +64,29 @@ type profileXML struct {
 // whole configuration is replaced on write, so we read it and send it back
 // intact, changing only the Extension>Rotate block. Image rotation lives here.
 type videoSourceXML struct {
-	Token       string `xml:"token,attr"`
-	Name        string `xml:"Name"`
-	UseCount    int    `xml:"UseCount"`
-	SourceToken string `xml:"SourceToken"`
-	Bounds      struct {
-		X      int `xml:"x,attr"`
-		Y      int `xml:"y,attr"`
-		Width  int `xml:"width,attr"`
-		Height int `xml:"height,attr"`
-	} `xml:"Bounds"`
-	Rotate struct {
+	Token       string    `xml:"token,attr"`
+	Name        string    `xml:"Name"`
+	UseCount    int       `xml:"UseCount"`
+	SourceToken string    `xml:"SourceToken"`
+	Bounds      boundsXML `xml:"Bounds"`
+	Rotate      struct {
 		Mode   string `xml:"Mode"`   // "OFF" | "ON" | "AUTO"
 		Degree int    `xml:"Degree"` // 90/180/270 when Mode=ON
 	} `xml:"Extension>Rotate"`
 }
 
+// boundsXML is the parsed form of a tt:IntRectangle.
+type boundsXML struct {
+	X      int `xml:"x,attr"`
+	Y      int `xml:"y,attr"`
+	Width  int `xml:"width,attr"`
+	Height int `xml:"height,attr"`
+}
+
+func (b boundsXML) rectangle() Rectangle {
+	return Rectangle{X: b.X, Y: b.Y, Width: b.Width, Height: b.Height}
+}
+
 // videoEncoderXML captures every VideoEncoderConfiguration field needed to round
 // trip a SetVideoEncoderConfiguration request. SetVideoEncoderConfiguration
 // replaces the whole configuration, so to change a few fields we must read the
@@ -60,24 +111,159 @@ type videoEncoderXML struct {
 		GovLength   int    `xml:"GovLength"`
 		H264Profile string `xml:"H264Profile"`
 	} `xml:"H264"`
-	Multicast struct {
-		Address struct {
-			Type        string `xml:"Type"`
-			IPv4Address string `xml:"IPv4Address"`
-		} `xml:"Address"`
-		Port      int  `xml:"Port"`
-		TTL       int  `xml:"TTL"`
-		AutoStart bool `xml:"AutoStart"`
-	} `xml:"Multicast"`
-	SessionTimeout string `xml:"SessionTimeout"`
+	MPEG4 struct {
+		GovLength    int    `xml:"GovLength"`
+		Mpeg4Profile string `xml:"Mpeg4Profile"`
+	} `xml:"MPEG4"`
+	Multicast      multicastXML `xml:"Multicast"`
+	SessionTimeout string       `xml:"SessionTimeout"`
+}
+
+// multicastXML is the parsed form of a tt:MulticastConfiguration.
+type multicastXML struct {
+	Address struct {
+		Type        string `xml:"Type"`
+		IPv4Address string `xml:"IPv4Address"`
+		IPv6Address string `xml:"IPv6Address"`
+	} `xml:"Address"`
+	Port      int  `xml:"Port"`
+	TTL       int  `xml:"TTL"`
+	AutoStart bool `xml:"AutoStart"`
+}
+
+func (m multicastXML) config() *MulticastConfig {
+	addr := m.Address.IPv4Address
+	if m.Address.Type == "IPv6" {
+		addr = m.Address.IPv6Address
+	}
+	return &MulticastConfig{Address: strings.TrimSpace(addr), Port: m.Port, TTL: m.TTL, AutoStart: m.AutoStart}
+}
+
+func newMulticastXML(mc MulticastConfig) multicastXML {
+	var m multicastXML
+	if strings.Contains(mc.Address, ":") {
+		m.Address.Type, m.Address.IPv6Address = "IPv6", mc.Address
+	} else {
+		m.Address.Type, m.Address.IPv4Address = "IPv4", mc.Address
+	}
+	m.Port, m.TTL, m.AutoStart = mc.Port, mc.TTL, mc.AutoStart
+	return m
+}
+
+// buildMulticastXML renders a tt:Multicast element. An unset IPv4 address is
+// sent as 0.0.0.0, which cameras accept as "no multicast".
+func buildMulticastXML(m multicastXML) string {
+	var b strings.Builder
+	if m.Address.Type == "IPv6" {
+		fmt.Fprintf(&b, `<tt:Multicast><tt:Address><tt:Type>IPv6</tt:Type><tt:IPv6Address>%s</tt:IPv6Address>`, m.Address.IPv6Address)
+	} else {
+		addr := m.Address.IPv4Address
+		if addr == "" {
+			addr = "0.0.0.0"
+		}
+		fmt.Fprintf(&b, `<tt:Multicast><tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>%s</tt:IPv4Address>`, addr)
+	}
+	fmt.Fprintf(&b, `</tt:Address><tt:Port>%d</tt:Port><tt:TTL>%d</tt:TTL><tt:AutoStart>%t</tt:AutoStart></tt:Multicast>`,
+		m.Port, m.TTL, m.AutoStart)
+	return b.String()
+}
+
+// config converts the parsed configuration to a VideoEncoderConfig.
+func (v videoEncoderXML) config() VideoEncoderConfig {
+	return VideoEncoderConfig{
+		Token:            v.Token,
+		Name:             v.Name,
+		Encoding:         v.Encoding,
+		Width:            v.Resolution.Width,
+		Height:           v.Resolution.Height,
+		FrameRateLimit:   v.RateControl.FrameRateLimit,
+		BitrateLimit:     v.RateControl.BitrateLimit,
+		EncodingInterval: v.RateControl.EncodingInterval,
+		Quality:          v.Quality,
+		Profile:          v.codecProfile(),
+		GovLength:        v.govLength(),
+		Multicast:        v.Multicast.config(),
+	}
+}
+
+// codecProfile returns the profile of the active codec block.
+func (v videoEncoderXML) codecProfile() string {
+	if strings.EqualFold(v.Encoding, "MPEG4") {
+		return v.MPEG4.Mpeg4Profile
+	}
+	return v.H264.H264Profile
+}
+
+// govLength returns the GOV length of the active codec block.
+func (v videoEncoderXML) govLength() int {
+	if strings.EqualFold(v.Encoding, "MPEG4") {
+		return v.MPEG4.GovLength
+	}
+	return v.H264.GovLength
+}
+
+// apply overlays the non-zero fields of cfg onto v.
+func (v *videoEncoderXML) apply(cfg VideoEncoderConfig) {
+	if cfg.Name != "" {
+		v.Name = cfg.Name
+	}
+	if cfg.Encoding != "" {
+		v.Encoding = cfg.Encoding
+	}
+	if cfg.Width > 0 && cfg.Height > 0 {
+		v.Resolution.Width, v.Resolution.Height = cfg.Width, cfg.Height
+	}
+	if cfg.FrameRateLimit > 0 {
+		v.RateControl.FrameRateLimit = cfg.FrameRateLimit
+	}
+	if cfg.BitrateLimit > 0 {
+		v.RateControl.BitrateLimit = cfg.BitrateLimit
+	}
+	if cfg.EncodingInterval > 0 {
+		v.RateControl.EncodingInterval = cfg.EncodingInterval
+	}
+	if cfg.Quality > 0 {
+		v.Quality = cfg.Quality
+	}
+	mpeg4 := strings.EqualFold(v.Encoding, "MPEG4")
+	if cfg.Profile != "" {
+		if mpeg4 {
+			v.MPEG4.Mpeg4Profile = cfg.Profile
+		} else {
+			v.H264.H264Profile = cfg.Profile
+		}
+	}
+	if cfg.GovLength > 0 {
+		if mpeg4 {
+			v.MPEG4.GovLength = cfg.GovLength
+		} else {
+			v.H264.GovLength = cfg.GovLength
+		}
+	}
+	if cfg.Multicast != nil {
+		v.Multicast = newMulticastXML(*cfg.Multicast)
+	}
+}
+
+// checkMedia1Encoder rejects settings that a Media1 video encoder
+// configuration cannot express, rather than dropping them or writing an
+// encoding Media1 does not define.
+func checkMedia1Encoder(cfg VideoEncoderConfig) error {
+	if strings.EqualFold(cfg.Encoding, "H265") {
+		return fmt.Errorf("H265 encoding requires Media2, which the camera does not offer")
+	}
+	if cfg.ConstantBitRate != nil {
+		return fmt.Errorf("ConstantBitRate requires Media2, which the camera does not offer")
+	}
+	return nil
 }
 
 // getProfiles fetches and parses the media profiles, including each profile's
 // full video encoder configuration.
-func (c *Client) getProfiles(camera *Camera) ([]profileXML, error) {
-	mediaURL := c.resolveMediaURL(camera)
+func (c *Client) getProfiles(ctx context.Context, camera *Camera) ([]profileXML, error) {
+	mediaURL := c.resolveMediaURL(ctx, camera)
 
-	profilesResp, err := c.sendSOAPRequest(mediaURL,
+	profilesResp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/GetProfiles", `<trt:GetProfiles/>`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get profiles: %v", err)
@@ -92,13 +278,23 @@ func (c *Client) getProfiles(camera *Camera) ([]profileXML, error) {
 	return parsed.Profiles, nil
 }
 
-// GetStreamProfiles fetches all stream profiles for a camera
+// GetStreamProfiles fetches all stream profiles for a camera, via Media2 when
+// the camera offers it (which also lists H.265 streams) and Media1 otherwise.
 func (c *Client) GetStreamProfiles(camera *Camera) ([]StreamConfig, error) {
-	profiles, err := c.getProfiles(camera)
+	return c.GetStreamProfilesContext(context.Background(), camera)
+}
+
+// GetStreamProfilesContext is like GetStreamProfiles but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetStreamProfilesContext(ctx context.Context, camera *Camera) ([]StreamConfig, error) {
+	if c.useMedia2(ctx, camera) {
+		return c.getStreamProfilesMedia2(ctx, camera)
+	}
+	profiles, err := c.getProfiles(ctx, camera)
 	if err != nil {
 		return nil, err
 	}
-	mediaURL := c.resolveMediaURL(camera)
+	mediaURL := c.resolveMediaURL(ctx, camera)
 
 	var streamConfigs []StreamConfig
 
@@ -120,7 +316,7 @@ func (c *Client) GetStreamProfiles(camera *Camera) ([]StreamConfig, error) {
 			<trt:ProfileToken>%s</trt:ProfileToken>
 		</trt:GetStreamUri>`, profile.Token)
 
-		streamResp, err := c.sendSOAPRequest(mediaURL,
+		streamResp, err := c.sendSOAPRequest(ctx, mediaURL,
 			"http://www.onvif.org/ver10/media/wsdl/GetStreamUri", streamBody)
 
 		streamURI := ""
@@ -137,12 +333,8 @@ func (c *Client) GetStreamProfiles(camera *Camera) ([]StreamConfig, error) {
 			}
 		}
 
-		// Determine quality based on resolution
-		quality := "Sub"
-		if profile.VEC.Resolution.Width >= 1280 {
-			quality = "Main"
-		}
-
+		encoder := profile.VEC.config()
+		encoder.ProfileToken, encoder.ProfileName = profile.Token, profile.Name
 		config := StreamConfig{
 			ProfileName:  profile.Name,
 			ProfileToken: profile.Token,
@@ -154,7 +346,8 @@ func (c *Client) GetStreamProfiles(camera *Camera) ([]StreamConfig, error) {
 			Bitrate:   profile.VEC.RateControl.BitrateLimit,
 			Encoding:  profile.VEC.Encoding,
 			StreamURI: streamURI,
-			Quality:   quality,
+			Quality:   streamQuality(profile.VEC.Resolution.Width),
+			Encoder:   &encoder,
 		}
 
 		streamConfigs = append(streamConfigs, config)
@@ -163,6 +356,14 @@ func (c *Client) GetStreamProfiles(camera *Camera) ([]StreamConfig, error) {
 	return streamConfigs, nil
 }
 
+// streamQuality classifies a stream as "Main" or "Sub" by its width.
+func streamQuality(width int) string {
+	if width >= 1280 {
+		return "Main"
+	}
+	return "Sub"
+}
+
 // mediaURLHeuristic derives a likely Media (ver10) service URL from the
 // device-service address. Used only when service discovery reported none.
 func mediaURLHeuristic(address string) string {
@@ -173,9 +374,19 @@ func mediaURLHeuristic(address string) string {
 	return url
 }
 
-// GetStreamUri retrieves the RTSP stream URI for a given profile token
+// GetStreamUri retrieves the RTSP stream URI for a given profile token, via
+// Media2 when the camera offers it and Media1 otherwise.
 func (c *Client) GetStreamUri(camera *Camera, profileToken string) (string, error) {
-	mediaURL := c.resolveMediaURL(camera)
+	return c.GetStreamUriContext(context.Background(), camera, profileToken)
+}
+
+// GetStreamUriContext is like GetStreamUri but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetStreamUriContext(ctx context.Context, camera *Camera, profileToken string) (string, error) {
+	if c.useMedia2(ctx, camera) {
+		return c.GetStreamUriMedia2Context(ctx, camera, profileToken)
+	}
+	mediaURL := c.resolveMediaURL(ctx, camera)
 
 	body := fmt.Sprintf(`<trt:GetStreamUri>
 		<trt:StreamSetup>
@@ -187,7 +398,7 @@ func (c *Client) GetStreamUri(camera *Camera, profileToken string) (string, erro
 		<trt:ProfileToken>%s</trt:ProfileToken>
 	</trt:GetStreamUri>`, profileToken)
 
-	resp, err := c.sendSOAPRequest(mediaURL,
+	resp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/GetStreamUri", body)
 	if err != nil {
 		return "", fmt.Errorf("failed to get stream URI: %v", err)
@@ -212,10 +423,446 @@ func (c *Client) GetStreamUri(camera *Camera, profileToken string) (string, erro
 	return "", fmt.Errorf("no stream URI found in response")
 }
 
+// mediaCall sends a Media1 request and returns the raw response once it is
+// known not to be a fault.
+func (c *Client) mediaCall(ctx context.Context, camera *Camera, op, body string) ([]byte, error) {
+	mediaURL := c.resolveMediaURL(ctx, camera)
+	resp, err := c.sendSOAPRequest(ctx, mediaURL, "http://www.onvif.org/ver10/media/wsdl/"+op, body)
+	if err != nil {
+		return nil, fmt.Errorf("media %s failed: %v", op, err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, fmt.Errorf("media %s failed: %w", op, err)
+	}
+	return resp, nil
+}
+
+// buildOptionsBody renders a Get*ConfigurationOptions request for the service
+// prefix ("trt" or "tr2"); empty tokens are omitted.
+func buildOptionsBody(prefix, op, configToken, profileToken string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<%s:%s>`, prefix, op)
+	if configToken != "" {
+		fmt.Fprintf(&b, `<%s:ConfigurationToken>%s</%s:ConfigurationToken>`, prefix, configToken, prefix)
+	}
+	if profileToken != "" {
+		fmt.Fprintf(&b, `<%s:ProfileToken>%s</%s:ProfileToken>`, prefix, profileToken, prefix)
+	}
+	fmt.Fprintf(&b, `</%s:%s>`, prefix, op)
+	return b.String()
+}
+
+// GetProfiles returns the media profiles with their bound configurations, via
+// Media2 when the camera offers it and Media1 otherwise.
+func (c *Client) GetProfiles(camera *Camera) ([]MediaProfile, error) {
+	return c.GetProfilesContext(context.Background(), camera)
+}
+
+// GetProfilesContext is like GetProfiles but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetProfilesContext(ctx context.Context, camera *Camera) ([]MediaProfile, error) {
+	if c.useMedia2(ctx, camera) {
+		return c.GetProfilesMedia2Context(ctx, camera)
+	}
+	raw, err := c.getProfiles(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]MediaProfile, 0, len(raw))
+	for _, p := range raw {
+		profiles = append(profiles, p.profile())
+	}
+	return profiles, nil
+}
+
+// CreateProfile creates an empty, deletable profile and returns its token,
+// via Media2 when the camera offers it and Media1 otherwise.
+func (c *Client) CreateProfile(camera *Camera, name string) (string, error) {
+	return c.CreateProfileContext(context.Background(), camera, name)
+}
+
+// CreateProfileContext is like CreateProfile but uses ctx for cancellation and
+// deadlines.
+func (c *Client) CreateProfileContext(ctx context.Context, camera *Camera, name string) (string, error) {
+	if c.useMedia2(ctx, camera) {
+		return c.CreateProfileMedia2Context(ctx, camera, name)
+	}
+	body := fmt.Sprintf(`<trt:CreateProfile><trt:Name>%s</trt:Name></trt:CreateProfile>`, escapeXML(name))
+	resp, err := c.mediaCall(ctx, camera, "CreateProfile", body)
+	if err != nil {
+		return "", err
+	}
+
+	var parsed struct {
+		Profile tokenRef `xml:"Body>CreateProfileResponse>Profile"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse CreateProfile response: %v", err)
+	}
+	return parsed.Profile.Token, nil
+}
+
+// DeleteProfile deletes a profile, via Media2 when the camera offers it and
+// Media1 otherwise. Fixed profiles cannot be deleted.
+func (c *Client) DeleteProfile(camera *Camera, profileToken string) error {
+	return c.DeleteProfileContext(context.Background(), camera, profileToken)
+}
+
+// DeleteProfileContext is like DeleteProfile but uses ctx for cancellation and
+// deadlines.
+func (c *Client) DeleteProfileContext(ctx context.Context, camera *Camera, profileToken string) error {
+	if c.useMedia2(ctx, camera) {
+		return c.DeleteProfileMedia2Context(ctx, camera, profileToken)
+	}
+	body := fmt.Sprintf(`<trt:DeleteProfile><trt:ProfileToken>%s</trt:ProfileToken></trt:DeleteProfile>`, profileToken)
+	_, err := c.mediaCall(ctx, camera, "DeleteProfile", body)
+	return err
+}
+
+// GetProfile returns a single profile with its bound configurations, via
+// Media2 when the camera offers it and Media1 otherwise.
+func (c *Client) GetProfile(camera *Camera, profileToken string) (*MediaProfile, error) {
+	return c.GetProfileContext(context.Background(), camera, profileToken)
+}
+
+// GetProfileContext is like GetProfile but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetProfileContext(ctx context.Context, camera *Camera, profileToken string) (*MediaProfile, error) {
+	if c.useMedia2(ctx, camera) {
+		body := fmt.Sprintf(`<tr2:GetProfiles><tr2:Token>%s</tr2:Token><tr2:Type>All</tr2:Type></tr2:GetProfiles>`, profileToken)
+		profiles, err := c.getProfilesMedia2(ctx, camera, body)
+		if err != nil {
+			return nil, err
+		}
+		if len(profiles) == 0 {
+			return nil, fmt.Errorf("profile %q not found", profileToken)
+		}
+		return &profiles[0], nil
+	}
+
+	body := fmt.Sprintf(`<trt:GetProfile><trt:ProfileToken>%s</trt:ProfileToken></trt:GetProfile>`, profileToken)
+	resp, err := c.mediaCall(ctx, camera, "GetProfile", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Profile profileXML `xml:"Body>GetProfileResponse>Profile"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %v", err)
+	}
+	profile := parsed.Profile.profile()
+	return &profile, nil
+}
+
+// bindConfiguration sends a Media1 Add<kind>Configuration request, or
+// Remove<kind>Configuration when configToken is empty.
+func (c *Client) bindConfiguration(ctx context.Context, camera *Camera, kind, profileToken, configToken string) error {
+	if configToken == "" {
+		op := "Remove" + kind + "Configuration"
+		body := fmt.Sprintf(`<trt:%s><trt:ProfileToken>%s</trt:ProfileToken></trt:%s>`, op, profileToken, op)
+		_, err := c.mediaCall(ctx, camera, op, body)
+		return err
+	}
+	op := "Add" + kind + "Configuration"
+	body := fmt.Sprintf(`<trt:%s><trt:ProfileToken>%s</trt:ProfileToken><trt:ConfigurationToken>%s</trt:ConfigurationToken></trt:%s>`,
+		op, profileToken, configToken, op)
+	_, err := c.mediaCall(ctx, camera, op, body)
+	return err
+}
+
+// AddVideoEncoderConfiguration binds a video encoder configuration to a Media1 profile,
+// replacing any video encoder configuration already bound.
+func (c *Client) AddVideoEncoderConfiguration(camera *Camera, profileToken, configToken string) error {
+	return c.AddVideoEncoderConfigurationContext(context.Background(), camera, profileToken, configToken)
+}
+
+// AddVideoEncoderConfigurationContext is like AddVideoEncoderConfiguration but uses ctx
+// for cancellation and deadlines.
+func (c *Client) AddVideoEncoderConfigurationContext(ctx context.Context, camera *Camera, profileToken, configToken string) error {
+	if configToken == "" {
+		return fmt.Errorf("video encoder configuration token is required")
+	}
+	return c.bindConfiguration(ctx, camera, "VideoEncoder", profileToken, configToken)
+}
+
+// RemoveVideoEncoderConfiguration unbinds the video encoder configuration from a Media1
+// profile.
+func (c *Client) RemoveVideoEncoderConfiguration(camera *Camera, profileToken string) error {
+	return c.RemoveVideoEncoderConfigurationContext(context.Background(), camera, profileToken)
+}
+
+// RemoveVideoEncoderConfigurationContext is like RemoveVideoEncoderConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) RemoveVideoEncoderConfigurationContext(ctx context.Context, camera *Camera, profileToken string) error {
+	return c.bindConfiguration(ctx, camera, "VideoEncoder", profileToken, "")
+}
+
+// AddVideoSourceConfiguration binds a video source configuration to a Media1 profile,
+// replacing any video source configuration already bound.
+func (c *Client) AddVideoSourceConfiguration(camera *Camera, profileToken, configToken string) error {
+	return c.AddVideoSourceConfigurationContext(context.Background(), camera, profileToken, configToken)
+}
+
+// AddVideoSourceConfigurationContext is like AddVideoSourceConfiguration but uses ctx
+// for cancellation and deadlines.
+func (c *Client) AddVideoSourceConfigurationContext(ctx context.Context, camera *Camera, profileToken, configToken string) error {
+	if configToken == "" {
+		return fmt.Errorf("video source configuration token is required")
+	}
+	return c.bindConfiguration(ctx, camera, "VideoSource", profileToken, configToken)
+}
+
+// RemoveVideoSourceConfiguration unbinds the video source configuration from a Media1
+// profile.
+func (c *Client) RemoveVideoSourceConfiguration(camera *Camera, profileToken string) error {
+	return c.RemoveVideoSourceConfigurationContext(context.Background(), camera, profileToken)
+}
+
+// RemoveVideoSourceConfigurationContext is like RemoveVideoSourceConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) RemoveVideoSourceConfigurationContext(ctx context.Context, camera *Camera, profileToken string) error {
+	return c.bindConfiguration(ctx, camera, "VideoSource", profileToken, "")
+}
+
+// AddPTZConfiguration binds a PTZ configuration to a Media1 profile,
+// replacing any PTZ configuration already bound.
+func (c *Client) AddPTZConfiguration(camera *Camera, profileToken, configToken string) error {
+	return c.AddPTZConfigurationContext(context.Background(), camera, profileToken, configToken)
+}
+
+// AddPTZConfigurationContext is like AddPTZConfiguration but uses ctx
+// for cancellation and deadlines.
+func (c *Client) AddPTZConfigurationContext(ctx context.Context, camera *Camera, profileToken, configToken string) error {
+	if configToken == "" {
+		return fmt.Errorf("PTZ configuration token is required")
+	}
+	return c.bindConfiguration(ctx, camera, "PTZ", profileToken, configToken)
+}
+
+// RemovePTZConfiguration unbinds the PTZ configuration from a Media1
+// profile.
+func (c *Client) RemovePTZConfiguration(camera *Camera, profileToken string) error {
+	return c.RemovePTZConfigurationContext(context.Background(), camera, profileToken)
+}
+
+// RemovePTZConfigurationContext is like RemovePTZConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) RemovePTZConfigurationContext(ctx context.Context, camera *Camera, profileToken string) error {
+	return c.bindConfiguration(ctx, camera, "PTZ", profileToken, "")
+}
+
+// AddAudioSourceConfiguration binds an audio source configuration to a Media1
+// profile, replacing any audio source configuration already bound.
+func (c *Client) AddAudioSourceConfiguration(camera *Camera, profileToken, configToken string) error {
+	return c.AddAudioSourceConfigurationContext(context.Background(), camera, profileToken, configToken)
+}
+
+// AddAudioSourceConfigurationContext is like AddAudioSourceConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) AddAudioSourceConfigurationContext(ctx context.Context, camera *Camera, profileToken, configToken string) error {
+	if configToken == "" {
+		return fmt.Errorf("audio source configuration token is required")
+	}
+	return c.bindConfiguration(ctx, camera, "AudioSource", profileToken, configToken)
+}
+
+// RemoveAudioSourceConfiguration unbinds the audio source configuration from a
+// Media1 profile.
+func (c *Client) RemoveAudioSourceConfiguration(camera *Camera, profileToken string) error {
+	return c.RemoveAudioSourceConfigurationContext(context.Background(), camera, profileToken)
+}
+
+// RemoveAudioSourceConfigurationContext is like RemoveAudioSourceConfiguration
+// but uses ctx for cancellation and deadlines.
+func (c *Client) RemoveAudioSourceConfigurationContext(ctx context.Context, camera *Camera, profileToken string) error {
+	return c.bindConfiguration(ctx, camera, "AudioSource", profileToken, "")
+}
+
+// AddAudioEncoderConfiguration binds an audio encoder configuration to a Media1 profile,
+// replacing any audio encoder configuration already bound.
+func (c *Client) AddAudioEncoderConfiguration(camera *Camera, profileToken, configToken string) error {
+	return c.AddAudioEncoderConfigurationContext(context.Background(), camera, profileToken, configToken)
+}
+
+// AddAudioEncoderConfigurationContext is like AddAudioEncoderConfiguration but uses ctx
+// for cancellation and deadlines.
+func (c *Client) AddAudioEncoderConfigurationContext(ctx context.Context, camera *Camera, profileToken, configToken string) error {
+	if configToken == "" {
+		return fmt.Errorf("audio encoder configuration token is required")
+	}
+	return c.bindConfiguration(ctx, camera, "AudioEncoder", profileToken, configToken)
+}
+
+// RemoveAudioEncoderConfiguration unbinds the audio encoder configuration from a Media1
+// profile.
+func (c *Client) RemoveAudioEncoderConfiguration(camera *Camera, profileToken string) error {
+	return c.RemoveAudioEncoderConfigurationContext(context.Background(), camera, profileToken)
+}
+
+// RemoveAudioEncoderConfigurationContext is like RemoveAudioEncoderConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) RemoveAudioEncoderConfigurationContext(ctx context.Context, camera *Camera, profileToken string) error {
+	return c.bindConfiguration(ctx, camera, "AudioEncoder", profileToken, "")
+}
+
+// AddAudioOutputConfiguration binds an audio output configuration to a Media1
+// profile, replacing any audio output configuration already bound.
+func (c *Client) AddAudioOutputConfiguration(camera *Camera, profileToken, configToken string) error {
+	return c.AddAudioOutputConfigurationContext(context.Background(), camera, profileToken, configToken)
+}
+
+// AddAudioOutputConfigurationContext is like AddAudioOutputConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) AddAudioOutputConfigurationContext(ctx context.Context, camera *Camera, profileToken, configToken string) error {
+	if configToken == "" {
+		return fmt.Errorf("audio output configuration token is required")
+	}
+	return c.bindConfiguration(ctx, camera, "AudioOutput", profileToken, configToken)
+}
+
+// RemoveAudioOutputConfiguration unbinds the audio output configuration from a
+// Media1 profile.
+func (c *Client) RemoveAudioOutputConfiguration(camera *Camera, profileToken string) error {
+	return c.RemoveAudioOutputConfigurationContext(context.Background(), camera, profileToken)
+}
+
+// RemoveAudioOutputConfigurationContext is like RemoveAudioOutputConfiguration
+// but uses ctx for cancellation and deadlines.
+func (c *Client) RemoveAudioOutputConfigurationContext(ctx context.Context, camera *Camera, profileToken string) error {
+	return c.bindConfiguration(ctx, camera, "AudioOutput", profileToken, "")
+}
+
+// AddAudioDecoderConfiguration binds an audio decoder configuration to a Media1
+// profile, replacing any audio decoder configuration already bound.
+func (c *Client) AddAudioDecoderConfiguration(camera *Camera, profileToken, configToken string) error {
+	return c.AddAudioDecoderConfigurationContext(context.Background(), camera, profileToken, configToken)
+}
+
+// AddAudioDecoderConfigurationContext is like AddAudioDecoderConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) AddAudioDecoderConfigurationContext(ctx context.Context, camera *Camera, profileToken, configToken string) error {
+	if configToken == "" {
+		return fmt.Errorf("audio decoder configuration token is required")
+	}
+	return c.bindConfiguration(ctx, camera, "AudioDecoder", profileToken, configToken)
+}
+
+// RemoveAudioDecoderConfiguration unbinds the audio decoder configuration from
+// a Media1 profile.
+func (c *Client) RemoveAudioDecoderConfiguration(camera *Camera, profileToken string) error {
+	return c.RemoveAudioDecoderConfigurationContext(context.Background(), camera, profileToken)
+}
+
+// RemoveAudioDecoderConfigurationContext is like
+// RemoveAudioDecoderConfiguration but uses ctx for cancellation and deadlines.
+func (c *Client) RemoveAudioDecoderConfigurationContext(ctx context.Context, camera *Camera, profileToken string) error {
+	return c.bindConfiguration(ctx, camera, "AudioDecoder", profileToken, "")
+}
+
+// AddMetadataConfiguration binds a metadata configuration to a Media1 profile,
+// replacing any metadata configuration already bound.
+func (c *Client) AddMetadataConfiguration(camera *Camera, profileToken, configToken string) error {
+	return c.AddMetadataConfigurationContext(context.Background(), camera, profileToken, configToken)
+}
+
+// AddMetadataConfigurationContext is like AddMetadataConfiguration but uses ctx
+// for cancellation and deadlines.
+func (c *Client) AddMetadataConfigurationContext(ctx context.Context, camera *Camera, profileToken, configToken string) error {
+	if configToken == "" {
+		return fmt.Errorf("metadata configuration token is required")
+	}
+	return c.bindConfiguration(ctx, camera, "Metadata", profileToken, configToken)
+}
+
+// RemoveMetadataConfiguration unbinds the metadata configuration from a Media1
+// profile.
+func (c *Client) RemoveMetadataConfiguration(camera *Camera, profileToken string) error {
+	return c.RemoveMetadataConfigurationContext(context.Background(), camera, profileToken)
+}
+
+// RemoveMetadataConfigurationContext is like RemoveMetadataConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) RemoveMetadataConfigurationContext(ctx context.Context, camera *Camera, profileToken string) error {
+	return c.bindConfiguration(ctx, camera, "Metadata", profileToken, "")
+}
+
+// getVideoEncoderConfigurations fetches the raw Media1 video encoder
+// configurations, including those not bound to any profile.
+func (c *Client) getVideoEncoderConfigurations(ctx context.Context, camera *Camera) ([]videoEncoderXML, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetVideoEncoderConfigurations", `<trt:GetVideoEncoderConfigurations/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Configs []videoEncoderXML `xml:"Body>GetVideoEncoderConfigurationsResponse>Configurations"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse video encoder configurations: %v", err)
+	}
+	return parsed.Configs, nil
+}
+
+// GetVideoEncoderConfigurations returns every video encoder configuration on
+// the device, via Media2 when the camera offers it and Media1 otherwise.
+func (c *Client) GetVideoEncoderConfigurations(camera *Camera) ([]VideoEncoderConfig, error) {
+	return c.GetVideoEncoderConfigurationsContext(context.Background(), camera)
+}
+
+// GetVideoEncoderConfigurationsContext is like GetVideoEncoderConfigurations
+// but uses ctx for cancellation and deadlines.
+func (c *Client) GetVideoEncoderConfigurationsContext(ctx context.Context, camera *Camera) ([]VideoEncoderConfig, error) {
+	if c.useMedia2(ctx, camera) {
+		return c.GetVideoEncoderConfigurationsMedia2Context(ctx, camera)
+	}
+	raw, err := c.getVideoEncoderConfigurations(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]VideoEncoderConfig, 0, len(raw))
+	for _, v := range raw {
+		configs = append(configs, v.config())
+	}
+	return configs, nil
+}
+
+// SetVideoEncoderConfiguration updates the video encoder configuration
+// cfg.Token, via Media2 when the camera offers it and Media1 otherwise. The
+// current configuration is read first and only cfg's non-zero fields are
+// changed, since the device replaces the whole configuration.
+func (c *Client) SetVideoEncoderConfiguration(camera *Camera, cfg VideoEncoderConfig) error {
+	return c.SetVideoEncoderConfigurationContext(context.Background(), camera, cfg)
+}
+
+// SetVideoEncoderConfigurationContext is like SetVideoEncoderConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) SetVideoEncoderConfigurationContext(ctx context.Context, camera *Camera, cfg VideoEncoderConfig) error {
+	if c.useMedia2(ctx, camera) {
+		return c.SetVideoEncoderConfigurationMedia2Context(ctx, camera, cfg)
+	}
+	if err := checkMedia1Encoder(cfg); err != nil {
+		return err
+	}
+	raw, err := c.getVideoEncoderConfigurations(ctx, camera)
+	if err != nil {
+		return err
+	}
+	for _, v := range raw {
+		if v.Token == cfg.Token {
+			v.apply(cfg)
+			_, err := c.mediaCall(ctx, camera, "SetVideoEncoderConfiguration", buildSetVideoEncoderBody(v))
+			return err
+		}
+	}
+	return fmt.Errorf("video encoder configuration %q not found", cfg.Token)
+}
+
 // findVideoEncoderConfig returns the current video encoder configuration with
 // the given token, read from the device's profiles.
-func (c *Client) findVideoEncoderConfig(camera *Camera, encoderToken string) (videoEncoderXML, error) {
-	profiles, err := c.getProfiles(camera)
+func (c *Client) findVideoEncoderConfig(ctx context.Context, camera *Camera, encoderToken string) (videoEncoderXML, error) {
+	profiles, err := c.getProfiles(ctx, camera)
 	if err != nil {
 		return videoEncoderXML{}, err
 	}
@@ -227,50 +874,60 @@ func (c *Client) findVideoEncoderConfig(camera *Camera, encoderToken string) (vi
 	return videoEncoderXML{}, fmt.Errorf("video encoder configuration %q not found", encoderToken)
 }
 
+// withDefaults fills the fields a SetVideoEncoderConfiguration must carry but
+// the camera left empty, so what is written can also be reported back.
+func (v videoEncoderXML) withDefaults() videoEncoderXML {
+	if v.RateControl.EncodingInterval <= 0 {
+		v.RateControl.EncodingInterval = 1
+	}
+	if strings.EqualFold(v.Encoding, "H264") {
+		if v.H264.GovLength <= 0 {
+			v.H264.GovLength = 30
+		}
+		if v.H264.H264Profile == "" {
+			v.H264.H264Profile = "Main"
+		}
+	}
+	if strings.EqualFold(v.Encoding, "MPEG4") {
+		if v.MPEG4.GovLength <= 0 {
+			v.MPEG4.GovLength = 30
+		}
+		if v.MPEG4.Mpeg4Profile == "" {
+			v.MPEG4.Mpeg4Profile = "SP"
+		}
+	}
+	if v.SessionTimeout == "" {
+		v.SessionTimeout = "PT60S"
+	}
+	if v.Name == "" {
+		v.Name = "Configuration"
+	}
+	return v
+}
+
 // buildSetVideoEncoderBody renders a SetVideoEncoderConfiguration request body
 // from a full video encoder configuration. The element order follows the ONVIF
-// schema (Name, UseCount, Encoding, Resolution, Quality, RateControl, codec,
-// Multicast, SessionTimeout).
+// schema (Name, UseCount, Encoding, Resolution, Quality, RateControl, MPEG4 or
+// H264, Multicast, SessionTimeout).
 func buildSetVideoEncoderBody(v videoEncoderXML) string {
-	encInterval := v.RateControl.EncodingInterval
-	if encInterval <= 0 {
-		encInterval = 1
-	}
+	v = v.withDefaults()
 
-	// Codec-specific block (H264). Omitted for other encodings so we don't send
-	// an H264 block for an H265/MJPEG stream.
+	// Codec-specific block, matching the encoding. Omitted for JPEG so we don't
+	// send an H264 block for an MJPEG stream.
 	codecBlock := ""
-	if strings.EqualFold(v.Encoding, "H264") {
-		gov := v.H264.GovLength
-		if gov <= 0 {
-			gov = 30
-		}
-		profile := v.H264.H264Profile
-		if profile == "" {
-			profile = "Main"
-		}
+	switch {
+	case strings.EqualFold(v.Encoding, "H264"):
 		codecBlock = fmt.Sprintf(`
 			<tt:H264 xmlns:tt="http://www.onvif.org/ver10/schema">
 				<tt:GovLength>%d</tt:GovLength>
 				<tt:H264Profile>%s</tt:H264Profile>
-			</tt:H264>`, gov, profile)
-	}
-
-	mcastType := v.Multicast.Address.Type
-	if mcastType == "" {
-		mcastType = "IPv4"
-	}
-	mcastAddr := v.Multicast.Address.IPv4Address
-	if mcastAddr == "" {
-		mcastAddr = "0.0.0.0"
-	}
-	sessionTimeout := v.SessionTimeout
-	if sessionTimeout == "" {
-		sessionTimeout = "PT60S"
-	}
-	name := v.Name
-	if name == "" {
-		name = "Configuration"
+			</tt:H264>`, v.H264.GovLength, v.H264.H264Profile)
+	case strings.EqualFold(v.Encoding, "MPEG4"):
+		codecBlock = fmt.Sprintf(`
+			<tt:MPEG4 xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tt:GovLength>%d</tt:GovLength>
+				<tt:Mpeg4Profile>%s</tt:Mpeg4Profile>
+			</tt:MPEG4>`, v.MPEG4.GovLength, v.MPEG4.Mpeg4Profile)
 	}
 
 	return fmt.Sprintf(`
@@ -289,26 +946,18 @@ func buildSetVideoEncoderBody(v videoEncoderXML) string {
 				<tt:EncodingInterval>%d</tt:EncodingInterval>
 				<tt:BitrateLimit>%d</tt:BitrateLimit>
 			</tt:RateControl>%s
-			<tt:Multicast xmlns:tt="http://www.onvif.org/ver10/schema">
-				<tt:Address>
-					<tt:Type>%s</tt:Type>
-					<tt:IPv4Address>%s</tt:IPv4Address>
-				</tt:Address>
-				<tt:Port>%d</tt:Port>
-				<tt:TTL>%d</tt:TTL>
-				<tt:AutoStart>%t</tt:AutoStart>
-			</tt:Multicast>
+			%s
 			<tt:SessionTimeout xmlns:tt="http://www.onvif.org/ver10/schema">%s</tt:SessionTimeout>
 		</trt:Configuration>
 		<trt:ForcePersistence>true</trt:ForcePersistence>
 	</trt:SetVideoEncoderConfiguration>`,
-		v.Token, name, v.UseCount, v.Encoding,
+		v.Token, escapeXML(v.Name), v.UseCount, v.Encoding,
 		v.Resolution.Width, v.Resolution.Height,
 		v.Quality,
-		v.RateControl.FrameRateLimit, encInterval, v.RateControl.BitrateLimit,
+		v.RateControl.FrameRateLimit, v.RateControl.EncodingInterval, v.RateControl.BitrateLimit,
 		codecBlock,
-		mcastType, mcastAddr, v.Multicast.Port, v.Multicast.TTL, v.Multicast.AutoStart,
-		sessionTimeout)
+		buildMulticastXML(v.Multicast),
+		v.SessionTimeout)
 }
 
 // UpdateStreamConfiguration updates a stream's video encoder configuration.
@@ -319,46 +968,109 @@ func buildSetVideoEncoderBody(v videoEncoderXML) string {
 // multicast, session timeout). This round-trip is required because
 // SetVideoEncoderConfiguration replaces the whole configuration and stricter
 // cameras reject partial or invented values.
+//
+// When the camera reports GetVideoEncoderConfigurationOptions, config is first
+// fitted to them (see ApplyStreamConfiguration); an unsupported encoding is an
+// error. Like GetStreamProfiles, it goes through Media2 when the camera offers
+// it and Media1 otherwise.
 func (c *Client) UpdateStreamConfiguration(camera *Camera, encoderToken string, config StreamUpdateConfig) error {
-	mediaURL := c.resolveMediaURL(camera)
+	return c.UpdateStreamConfigurationContext(context.Background(), camera, encoderToken, config)
+}
 
-	existing, err := c.findVideoEncoderConfig(camera, encoderToken)
-	if err != nil {
-		return err
-	}
+// UpdateStreamConfigurationContext is like UpdateStreamConfiguration but uses
+// ctx for cancellation and deadlines.
+func (c *Client) UpdateStreamConfigurationContext(ctx context.Context, camera *Camera, encoderToken string, config StreamUpdateConfig) error {
+	_, err := c.ApplyStreamConfigurationContext(ctx, camera, encoderToken, config)
+	return err
+}
 
-	// Apply overrides (only non-zero fields).
-	if config.Encoding != "" {
-		existing.Encoding = config.Encoding
+// ApplyStreamConfiguration is like UpdateStreamConfiguration but also returns
+// the configuration actually written: the resolution snapped to the nearest
+// one the encoder offers and framerate and bitrate clamped to its ranges.
+func (c *Client) ApplyStreamConfiguration(camera *Camera, encoderToken string, config StreamUpdateConfig) (StreamUpdateConfig, error) {
+	return c.ApplyStreamConfigurationContext(context.Background(), camera, encoderToken, config)
+}
+
+// ApplyStreamConfigurationContext is like ApplyStreamConfiguration but uses
+// ctx for cancellation and deadlines.
+func (c *Client) ApplyStreamConfigurationContext(ctx context.Context, camera *Camera, encoderToken string, config StreamUpdateConfig) (StreamUpdateConfig, error) {
+	if c.useMedia2(ctx, camera) {
+		return c.applyStreamConfigurationMedia2(ctx, camera, encoderToken, config)
 	}
-	if config.Resolution.Width > 0 && config.Resolution.Height > 0 {
-		existing.Resolution.Width = config.Resolution.Width
-		existing.Resolution.Height = config.Resolution.Height
+	if err := checkMedia1Encoder(config.encoderConfig()); err != nil {
+		return StreamUpdateConfig{}, err
 	}
-	if config.Framerate > 0 {
-		existing.RateControl.FrameRateLimit = config.Framerate
+	mediaURL := c.resolveMediaURL(ctx, camera)
+
+	existing, err := c.findVideoEncoderConfig(ctx, camera, encoderToken)
+	if err != nil {
+		return StreamUpdateConfig{}, err
 	}
-	if config.Bitrate > 0 {
-		existing.RateControl.BitrateLimit = config.Bitrate
+
+	// Fit the request to the encoder's options. Cameras that do not answer
+	// GetVideoEncoderConfigurationOptions are written unvalidated.
+	if opts, err := c.getVideoEncoderOptions(ctx, camera, encoderToken, ""); err == nil {
+		if config, err = opts.fit(config, existing.Encoding, encoderToken); err != nil {
+			return StreamUpdateConfig{}, err
+		}
 	}
 
-	updateResp, err := c.sendSOAPRequest(mediaURL,
+	// Apply overrides (only non-zero fields).
+	existing.apply(config.encoderConfig())
+
+	updateResp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/SetVideoEncoderConfiguration",
 		buildSetVideoEncoderBody(existing))
 	if err != nil {
-		return fmt.Errorf("failed to update configuration: %v", err)
+		return StreamUpdateConfig{}, fmt.Errorf("failed to update configuration: %v", err)
 	}
 
 	if err := parseSOAPFault(updateResp); err != nil {
-		return err
+		return StreamUpdateConfig{}, err
 	}
 
-	return nil
+	return streamUpdate(existing.withDefaults().config()), nil
+}
+
+// encoderConfig converts a stream update to the VideoEncoderConfig overlay
+// understood by the encoder apply methods.
+func (u StreamUpdateConfig) encoderConfig() VideoEncoderConfig {
+	return VideoEncoderConfig{
+		Encoding:        u.Encoding,
+		Width:           u.Resolution.Width,
+		Height:          u.Resolution.Height,
+		FrameRateLimit:  u.Framerate,
+		BitrateLimit:    u.Bitrate,
+		Profile:         u.Profile,
+		GovLength:       u.GovLength,
+		ConstantBitRate: u.ConstantBitRate,
+		Multicast:       u.Multicast,
+	}
+}
+
+// streamUpdate reports a written encoder configuration as a StreamUpdateConfig.
+func streamUpdate(cfg VideoEncoderConfig) StreamUpdateConfig {
+	return StreamUpdateConfig{
+		Resolution:      Resolution{Width: cfg.Width, Height: cfg.Height},
+		Framerate:       cfg.FrameRateLimit,
+		Bitrate:         cfg.BitrateLimit,
+		Encoding:        cfg.Encoding,
+		Profile:         cfg.Profile,
+		GovLength:       cfg.GovLength,
+		ConstantBitRate: cfg.ConstantBitRate,
+		Multicast:       cfg.Multicast,
+	}
 }
 
 // UpdateSubStream finds and updates the sub stream to specified configuration
 func (c *Client) UpdateSubStream(camera *Camera, config StreamUpdateConfig) error {
-	streams, err := c.GetStreamProfiles(camera)
+	return c.UpdateSubStreamContext(context.Background(), camera, config)
+}
+
+// UpdateSubStreamContext is like UpdateSubStream but uses ctx for cancellation
+// and deadlines.
+func (c *Client) UpdateSubStreamContext(ctx context.Context, camera *Camera, config StreamUpdateConfig) error {
+	streams, err := c.GetStreamProfilesContext(ctx, camera)
 	if err != nil {
 		return fmt.Errorf("failed to get stream profiles: %v", err)
 	}
@@ -381,13 +1093,13 @@ func (c *Client) UpdateSubStream(camera *Camera, config StreamUpdateConfig) erro
 		return fmt.Errorf("sub stream %q has no encoder configuration token", subStream.ProfileName)
 	}
 
-	return c.UpdateStreamConfiguration(camera, subStream.EncoderToken, config)
+	return c.UpdateStreamConfigurationContext(ctx, camera, subStream.EncoderToken, config)
 }
 
 // findVideoSourceConfig returns the first profile's VideoSourceConfiguration
 // that carries a token (the configuration that holds image rotation).
-func (c *Client) findVideoSourceConfig(camera *Camera) (videoSourceXML, error) {
-	profiles, err := c.getProfiles(camera)
+func (c *Client) findVideoSourceConfig(ctx context.Context, camera *Camera) (videoSourceXML, error) {
+	profiles, err := c.getProfiles(ctx, camera)
 	if err != nil {
 		return videoSourceXML{}, err
 	}
@@ -401,7 +1113,13 @@ func (c *Client) findVideoSourceConfig(camera *Camera) (videoSourceXML, error) {
 
 // GetVideoSourceConfiguration reads the camera's VideoSourceConfiguration.
 func (c *Client) GetVideoSourceConfiguration(camera *Camera) (videoSourceXML, error) {
-	return c.findVideoSourceConfig(camera)
+	return c.GetVideoSourceConfigurationContext(context.Background(), camera)
+}
+
+// GetVideoSourceConfigurationContext is like GetVideoSourceConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) GetVideoSourceConfigurationContext(ctx context.Context, camera *Camera) (videoSourceXML, error) {
+	return c.findVideoSourceConfig(ctx, camera)
 }
 
 // buildSetVideoSourceBody renders a SetVideoSourceConfiguration request that
@@ -445,8 +1163,14 @@ func buildSetVideoSourceBody(v videoSourceXML) string {
 
 // SetVideoSourceConfiguration writes back a (modified) VideoSourceConfiguration.
 func (c *Client) SetVideoSourceConfiguration(camera *Camera, vsc videoSourceXML) error {
-	mediaURL := c.resolveMediaURL(camera)
-	resp, err := c.sendSOAPRequest(mediaURL,
+	return c.SetVideoSourceConfigurationContext(context.Background(), camera, vsc)
+}
+
+// SetVideoSourceConfigurationContext is like SetVideoSourceConfiguration but
+// uses ctx for cancellation and deadlines.
+func (c *Client) SetVideoSourceConfigurationContext(ctx context.Context, camera *Camera, vsc videoSourceXML) error {
+	mediaURL := c.resolveMediaURL(ctx, camera)
+	resp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/SetVideoSourceConfiguration",
 		buildSetVideoSourceBody(vsc))
 	if err != nil {
@@ -485,7 +1209,13 @@ func rotateToRotation(rotateMode string, degree int) RotationMode {
 
 // GetRotation returns the camera's current image rotation.
 func (c *Client) GetRotation(camera *Camera) (RotationMode, error) {
-	vsc, err := c.findVideoSourceConfig(camera)
+	return c.GetRotationContext(context.Background(), camera)
+}
+
+// GetRotationContext is like GetRotation but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetRotationContext(ctx context.Context, camera *Camera) (RotationMode, error) {
+	vsc, err := c.findVideoSourceConfig(ctx, camera)
 	if err != nil {
 		return RotationOff, err
 	}
@@ -495,22 +1225,34 @@ func (c *Client) GetRotation(camera *Camera) (RotationMode, error) {
 // SetRotation sets the image rotation (Off/90/180/270) via a read-modify-write
 // of the VideoSourceConfiguration, changing only the Rotate block.
 func (c *Client) SetRotation(camera *Camera, mode RotationMode) error {
-	vsc, err := c.findVideoSourceConfig(camera)
+	return c.SetRotationContext(context.Background(), camera, mode)
+}
+
+// SetRotationContext is like SetRotation but uses ctx for cancellation and
+// deadlines.
+func (c *Client) SetRotationContext(ctx context.Context, camera *Camera, mode RotationMode) error {
+	vsc, err := c.findVideoSourceConfig(ctx, camera)
 	if err != nil {
 		return err
 	}
 	vsc.Rotate.Mode, vsc.Rotate.Degree = rotationToRotate(mode)
-	return c.SetVideoSourceConfiguration(camera, vsc)
+	return c.SetVideoSourceConfigurationContext(ctx, camera, vsc)
 }
 
 // RotationDiagnostics returns the raw GetVideoSourceConfigurations and
 // GetVideoSourceConfigurationOptions SOAP responses, for diagnosing why a camera
 // rejects or ignores a rotation change.
 func (c *Client) RotationDiagnostics(camera *Camera) string {
-	mediaURL := c.resolveMediaURL(camera)
+	return c.RotationDiagnosticsContext(context.Background(), camera)
+}
+
+// RotationDiagnosticsContext is like RotationDiagnostics but uses ctx for
+// cancellation and deadlines.
+func (c *Client) RotationDiagnosticsContext(ctx context.Context, camera *Camera) string {
+	mediaURL := c.resolveMediaURL(ctx, camera)
 	var b strings.Builder
 
-	if resp, err := c.sendSOAPRequest(mediaURL,
+	if resp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/GetVideoSourceConfigurations",
 		`<trt:GetVideoSourceConfigurations/>`); err != nil {
 		fmt.Fprintf(&b, "GetVideoSourceConfigurations error: %v\n\n", err)
@@ -519,11 +1261,11 @@ func (c *Client) RotationDiagnostics(camera *Camera) string {
 	}
 
 	token := ""
-	if cfg, err := c.findVideoSourceConfig(camera); err == nil {
+	if cfg, err := c.findVideoSourceConfig(ctx, camera); err == nil {
 		token = cfg.Token
 	}
 	optBody := fmt.Sprintf(`<trt:GetVideoSourceConfigurationOptions><trt:ConfigurationToken>%s</trt:ConfigurationToken></trt:GetVideoSourceConfigurationOptions>`, token)
-	if resp, err := c.sendSOAPRequest(mediaURL,
+	if resp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/GetVideoSourceConfigurationOptions", optBody); err != nil {
 		fmt.Fprintf(&b, "GetVideoSourceConfigurationOptions error: %v\n", err)
 	} else {
@@ -537,13 +1279,19 @@ func (c *Client) RotationDiagnostics(camera *Camera) string {
 // allowed degrees, and whether applying it requires a reboot. Cameras that don't
 // implement the call (or don't support rotation) yield Supported=false.
 func (c *Client) GetRotationOptions(camera *Camera) (RotationOptions, error) {
-	vsc, err := c.findVideoSourceConfig(camera)
+	return c.GetRotationOptionsContext(context.Background(), camera)
+}
+
+// GetRotationOptionsContext is like GetRotationOptions but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetRotationOptionsContext(ctx context.Context, camera *Camera) (RotationOptions, error) {
+	vsc, err := c.findVideoSourceConfig(ctx, camera)
 	if err != nil {
 		return RotationOptions{}, err
 	}
-	mediaURL := c.resolveMediaURL(camera)
+	mediaURL := c.resolveMediaURL(ctx, camera)
 	body := fmt.Sprintf(`<trt:GetVideoSourceConfigurationOptions><trt:ConfigurationToken>%s</trt:ConfigurationToken></trt:GetVideoSourceConfigurationOptions>`, vsc.Token)
-	resp, err := c.sendSOAPRequest(mediaURL,
+	resp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/GetVideoSourceConfigurationOptions", body)
 	if err != nil {
 		return RotationOptions{}, fmt.Errorf("failed to get video source configuration options: %v", err)
@@ -0,0 +1,229 @@
+package onvif
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMedia2Profiles(t *testing.T) {
+	var gotAction, gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotAction, gotBody = action, body
+		switch action {
+		case "http://www.onvif.org/ver20/media/wsdl/GetProfiles":
+			return `<tr2:GetProfilesResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+<tr2:Profiles token="main" fixed="true"><tr2:Name>MainStream</tr2:Name><tr2:Configurations>
+<tr2:VideoSource token="vsc0"><tt:Name>VS</tt:Name><tt:SourceToken>vs0</tt:SourceToken></tr2:VideoSource>
+<tr2:VideoEncoder token="ve0" GovLength="50" Profile="Main"><tt:Name>VE</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H265</tt:Encoding>
+<tt:Resolution><tt:Width>2560</tt:Width><tt:Height>1440</tt:Height></tt:Resolution>
+<tt:RateControl ConstantBitRate="false"><tt:FrameRateLimit>25</tt:FrameRateLimit><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl>
+<tt:Quality>4</tt:Quality></tr2:VideoEncoder>
+<tr2:PTZ token="ptz0"/><tr2:Metadata token="meta0"/></tr2:Configurations></tr2:Profiles>
+</tr2:GetProfilesResponse>`
+		case "http://www.onvif.org/ver20/media/wsdl/CreateProfile":
+			return `<tr2:CreateProfileResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"><tr2:Token>p9</tr2:Token></tr2:CreateProfileResponse>`
+		case "http://www.onvif.org/ver20/media/wsdl/GetVideoEncoderConfigurations":
+			return `<tr2:GetVideoEncoderConfigurationsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+<tr2:Configurations token="ve0" GovLength="50" Profile="Main"><tt:Name>VE</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H265</tt:Encoding>
+<tt:Resolution><tt:Width>2560</tt:Width><tt:Height>1440</tt:Height></tt:Resolution>
+<tt:RateControl ConstantBitRate="true"><tt:FrameRateLimit>25</tt:FrameRateLimit><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl>
+<tt:Multicast><tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>239.0.0.1</tt:IPv4Address></tt:Address><tt:Port>5000</tt:Port><tt:TTL>1</tt:TTL><tt:AutoStart>false</tt:AutoStart></tt:Multicast>
+<tt:Quality>4</tt:Quality></tr2:Configurations></tr2:GetVideoEncoderConfigurationsResponse>`
+		case "http://www.onvif.org/ver20/media/wsdl/GetStreamUri":
+			return `<tr2:GetStreamUriResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"><tr2:Uri>rtsp://192.0.2.10/main</tr2:Uri></tr2:GetStreamUriResponse>`
+		}
+		return `<tr2:Response xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"/>`
+	})
+	camera := &Camera{MediaURL: "http://192.0.2.10/onvif/media", Media2URL: "http://192.0.2.10/onvif/media2"}
+
+	profiles, err := c.GetProfiles(camera)
+	if err != nil {
+		t.Fatalf("GetProfiles() error = %v", err)
+	}
+	if !strings.Contains(gotBody, `<tr2:Type>All</tr2:Type>`) {
+		t.Errorf("GetProfiles body = %s", gotBody)
+	}
+	if len(profiles) != 1 {
+		t.Fatalf("GetProfiles() = %+v", profiles)
+	}
+	p := profiles[0]
+	if p.Token != "main" || !p.Fixed || p.VideoSourceToken != "vs0" || p.VideoSourceConfigToken != "vsc0" ||
+		p.VideoEncoderConfigToken != "ve0" || p.PTZConfigToken != "ptz0" || p.MetadataConfigToken != "meta0" || p.AudioEncoderConfigToken != "" {
+		t.Errorf("profile = %+v", p)
+	}
+	if v := p.VideoEncoder; v == nil || v.Encoding != "H265" || v.Width != 2560 || v.FrameRateLimit != 25 || v.ProfileToken != "main" {
+		t.Errorf("profile encoder = %+v", p.VideoEncoder)
+	}
+
+	if _, err := c.GetProfilesMedia2(camera, ConfigTypeVideoEncoder, ConfigTypePTZ); err != nil {
+		t.Fatalf("GetProfilesMedia2() error = %v", err)
+	}
+	if !strings.Contains(gotBody, `<tr2:Type>VideoEncoder</tr2:Type><tr2:Type>PTZ</tr2:Type>`) {
+		t.Errorf("GetProfilesMedia2 body = %s", gotBody)
+	}
+
+	token, err := c.CreateProfileMedia2(camera, "Cam & Co", ConfigurationRef{Type: ConfigTypeVideoSource, Token: "vsc0"})
+	if err != nil || token != "p9" {
+		t.Fatalf("CreateProfileMedia2() = %q, %v", token, err)
+	}
+	want := `<tr2:Name>Cam &amp; Co</tr2:Name><tr2:Configuration><tr2:Type>VideoSource</tr2:Type><tr2:Token>vsc0</tr2:Token></tr2:Configuration>`
+	if !strings.Contains(gotBody, want) {
+		t.Errorf("CreateProfileMedia2 body = %s", gotBody)
+	}
+
+	if err := c.RemoveConfiguration(camera, "p9", ConfigurationRef{Type: ConfigTypePTZ}); err != nil {
+		t.Fatalf("RemoveConfiguration() error = %v", err)
+	}
+	want = `<tr2:ProfileToken>p9</tr2:ProfileToken><tr2:Configuration><tr2:Type>PTZ</tr2:Type></tr2:Configuration>`
+	if !strings.HasSuffix(gotAction, "/RemoveConfiguration") || !strings.Contains(gotBody, want) {
+		t.Errorf("RemoveConfiguration %s body = %s", gotAction, gotBody)
+	}
+
+	if err := c.SetVideoEncoderConfiguration(camera, VideoEncoderConfig{Token: "ve0", BitrateLimit: 2048}); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration() error = %v", err)
+	}
+	want = `<tr2:Configuration token="ve0" GovLength="50" Profile="Main"><tt:Name>VE</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H265</tt:Encoding>` +
+		`<tt:Resolution><tt:Width>2560</tt:Width><tt:Height>1440</tt:Height></tt:Resolution>` +
+		`<tt:RateControl ConstantBitRate="true"><tt:FrameRateLimit>25</tt:FrameRateLimit><tt:BitrateLimit>2048</tt:BitrateLimit></tt:RateControl>` +
+		`<tt:Multicast><tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>239.0.0.1</tt:IPv4Address></tt:Address><tt:Port>5000</tt:Port><tt:TTL>1</tt:TTL><tt:AutoStart>false</tt:AutoStart></tt:Multicast>` +
+		`<tt:Quality>4</tt:Quality></tr2:Configuration>`
+	if !strings.HasSuffix(gotAction, "/SetVideoEncoderConfiguration") || !strings.Contains(gotBody, want) {
+		t.Errorf("SetVideoEncoderConfiguration body = %s\nwant %s", gotBody, want)
+	}
+
+	uri, err := c.GetStreamUri(camera, "main")
+	if err != nil || uri != "rtsp://192.0.2.10/main" {
+		t.Errorf("GetStreamUri() = %q, %v", uri, err)
+	}
+}
+
+func TestMedia1Fallback(t *testing.T) {
+	var actions []string
+	c := fakeSOAPClient(func(action, body string) string {
+		actions = append(actions, action)
+		switch action {
+		case "http://www.onvif.org/ver10/media/wsdl/GetProfiles":
+			return `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+<trt:Profiles token="prof0" fixed="true"><tt:Name>Main</tt:Name>
+<tt:VideoSourceConfiguration token="vsc0"><tt:SourceToken>vs0</tt:SourceToken></tt:VideoSourceConfiguration>
+<tt:AudioEncoderConfiguration token="aec0"/>
+<tt:VideoEncoderConfiguration token="ve0"><tt:Encoding>H264</tt:Encoding><tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution></tt:VideoEncoderConfiguration>
+</trt:Profiles></trt:GetProfilesResponse>`
+		case "http://www.onvif.org/ver10/media/wsdl/CreateProfile":
+			return `<trt:CreateProfileResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:Profile token="prof9" fixed="false"><tt:Name>New</tt:Name></trt:Profile></trt:CreateProfileResponse>`
+		}
+		return `<tds:Response xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>`
+	})
+	camera := &Camera{
+		Address:    "http://192.0.2.10/onvif/device_service",
+		MediaURL:   "http://192.0.2.10/onvif/media",
+		ImagingURL: "http://192.0.2.10/onvif/imaging",
+		PTZURL:     "http://192.0.2.10/onvif/ptz",
+		EventsURL:  "http://192.0.2.10/onvif/events",
+	}
+
+	profiles, err := c.GetProfiles(camera)
+	if err != nil {
+		t.Fatalf("GetProfiles() error = %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].Token != "prof0" || !profiles[0].Fixed || profiles[0].AudioEncoderConfigToken != "aec0" ||
+		profiles[0].VideoEncoder == nil || profiles[0].VideoEncoder.Width != 1920 {
+		t.Errorf("GetProfiles() = %+v", profiles)
+	}
+
+	token, err := c.CreateProfile(camera, "New")
+	if err != nil || token != "prof9" {
+		t.Fatalf("CreateProfile() = %q, %v", token, err)
+	}
+	discoveries := 0
+	for _, a := range actions {
+		if strings.Contains(a, "ver20/media") {
+			t.Errorf("Media1-only camera got Media2 request %s", a)
+		}
+		if a == "http://www.onvif.org/ver10/device/wsdl/GetServices" {
+			discoveries++
+		}
+	}
+	// The absence of Media2 is remembered after the first call.
+	if discoveries != 1 {
+		t.Errorf("GetServices sent %d times, want 1", discoveries)
+	}
+}
+
+func TestMedia2StreamConfiguration(t *testing.T) {
+	var gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		switch action {
+		case "http://www.onvif.org/ver20/media/wsdl/GetProfiles":
+			return `<tr2:GetProfilesResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+<tr2:Profiles token="main" fixed="true"><tr2:Name>MainStream</tr2:Name><tr2:Configurations>
+<tr2:VideoEncoder token="ve0" GovLength="50" Profile="Main"><tt:Name>VE</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H265</tt:Encoding>
+<tt:Resolution><tt:Width>3840</tt:Width><tt:Height>2160</tt:Height></tt:Resolution>
+<tt:RateControl ConstantBitRate="false"><tt:FrameRateLimit>25</tt:FrameRateLimit><tt:BitrateLimit>8192</tt:BitrateLimit></tt:RateControl>
+<tt:Quality>4</tt:Quality></tr2:VideoEncoder></tr2:Configurations></tr2:Profiles>
+<tr2:Profiles token="audio"><tr2:Name>AudioOnly</tr2:Name></tr2:Profiles>
+</tr2:GetProfilesResponse>`
+		case "http://www.onvif.org/ver20/media/wsdl/GetVideoEncoderConfigurations":
+			return `<tr2:GetVideoEncoderConfigurationsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+<tr2:Configurations token="ve0" GovLength="50" Profile="Main"><tt:Name>VE</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H265</tt:Encoding>
+<tt:Resolution><tt:Width>3840</tt:Width><tt:Height>2160</tt:Height></tt:Resolution>
+<tt:RateControl ConstantBitRate="false"><tt:FrameRateLimit>25</tt:FrameRateLimit><tt:BitrateLimit>8192</tt:BitrateLimit></tt:RateControl>
+<tt:Quality>4</tt:Quality></tr2:Configurations></tr2:GetVideoEncoderConfigurationsResponse>`
+		case "http://www.onvif.org/ver20/media/wsdl/GetVideoEncoderConfigurationOptions":
+			return `<tr2:GetVideoEncoderConfigurationOptionsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+<tr2:Options GovLengthRange="1 100" FrameRatesSupported="25 12.5" ProfilesSupported="Main Main10" ConstantBitRateSupported="true">
+<tt:Encoding>H265</tt:Encoding><tt:QualityRange><tt:Min>0</tt:Min><tt:Max>6</tt:Max></tt:QualityRange>
+<tt:ResolutionsAvailable><tt:Width>3840</tt:Width><tt:Height>2160</tt:Height></tt:ResolutionsAvailable>
+<tt:BitrateRange><tt:Min>256</tt:Min><tt:Max>16384</tt:Max></tt:BitrateRange></tr2:Options>
+</tr2:GetVideoEncoderConfigurationOptionsResponse>`
+		case "http://www.onvif.org/ver20/media/wsdl/GetStreamUri":
+			return `<tr2:GetStreamUriResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"><tr2:Uri>rtsp://192.0.2.10/main</tr2:Uri></tr2:GetStreamUriResponse>`
+		}
+		return `<tr2:Response xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"/>`
+	})
+	camera := &Camera{MediaURL: "http://192.0.2.10/onvif/media", Media2URL: "http://192.0.2.10/onvif/media2"}
+
+	streams, err := c.GetStreamProfiles(camera)
+	if err != nil {
+		t.Fatalf("GetStreamProfiles() error = %v", err)
+	}
+	if len(streams) != 1 {
+		t.Fatalf("GetStreamProfiles() = %+v", streams)
+	}
+	s := streams[0]
+	if s.Encoding != "H265" || s.Resolution != "3840x2160" || s.StreamURI != "rtsp://192.0.2.10/main" || s.Quality != "Main" {
+		t.Errorf("stream = %+v", s)
+	}
+	if e := s.Encoder; e == nil || e.Profile != "Main" || e.GovLength != 50 || e.ConstantBitRate == nil || *e.ConstantBitRate {
+		t.Errorf("stream encoder = %+v", s.Encoder)
+	}
+
+	cbr := true
+	applied, err := c.ApplyStreamConfiguration(camera, "ve0", StreamUpdateConfig{
+		Profile:         "Main10",
+		GovLength:       250,
+		ConstantBitRate: &cbr,
+		Multicast:       &MulticastConfig{Address: "ff15::1", Port: 5004, TTL: 4},
+	})
+	if err != nil {
+		t.Fatalf("ApplyStreamConfiguration() error = %v", err)
+	}
+	if applied.Encoding != "H265" || applied.Profile != "Main10" || applied.GovLength != 100 ||
+		applied.ConstantBitRate == nil || !*applied.ConstantBitRate || applied.Multicast == nil || applied.Multicast.Address != "ff15::1" {
+		t.Errorf("ApplyStreamConfiguration() = %+v", applied)
+	}
+	for _, w := range []string{
+		`<tr2:Configuration token="ve0" GovLength="100" Profile="Main10">`,
+		`<tt:RateControl ConstantBitRate="true">`,
+		`<tt:Multicast><tt:Address><tt:Type>IPv6</tt:Type><tt:IPv6Address>ff15::1</tt:IPv6Address></tt:Address><tt:Port>5004</tt:Port><tt:TTL>4</tt:TTL>`,
+	} {
+		if !strings.Contains(gotBody, w) {
+			t.Errorf("SetVideoEncoderConfiguration body missing %q: %s", w, gotBody)
+		}
+	}
+
+	if _, err := c.ApplyStreamConfiguration(camera, "ve0", StreamUpdateConfig{Profile: "High"}); err == nil {
+		t.Error("ApplyStreamConfiguration() accepted an unsupported H265 profile")
+	}
+}
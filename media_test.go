@@ -0,0 +1,58 @@
+package onvif
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMediaProfileBindings(t *testing.T) {
+	var gotAction, gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotAction, gotBody = action, body
+		if action == "http://www.onvif.org/ver10/media/wsdl/GetProfile" {
+			return `<trt:GetProfileResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+<trt:Profile token="prof9" fixed="false"><tt:Name>Provisioned</tt:Name>
+<tt:VideoSourceConfiguration token="vsc0"><tt:SourceToken>vs0</tt:SourceToken><tt:Bounds x="0" y="0" width="1920" height="1080"/></tt:VideoSourceConfiguration>
+<tt:PTZConfiguration token="ptz0"><tt:NodeToken>node0</tt:NodeToken></tt:PTZConfiguration>
+<tt:MetadataConfiguration token="meta0"/>
+</trt:Profile></trt:GetProfileResponse>`
+		}
+		return `<trt:Response xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+	})
+	camera := &Camera{
+		Address:    "http://192.0.2.10/onvif/device_service",
+		MediaURL:   "http://192.0.2.10/onvif/media",
+		ImagingURL: "http://192.0.2.10/onvif/imaging",
+		PTZURL:     "http://192.0.2.10/onvif/ptz",
+		EventsURL:  "http://192.0.2.10/onvif/events",
+	}
+
+	if err := c.AddVideoSourceConfiguration(camera, "prof9", "vsc0"); err != nil {
+		t.Fatalf("AddVideoSourceConfiguration() error = %v", err)
+	}
+	want := `<trt:AddVideoSourceConfiguration><trt:ProfileToken>prof9</trt:ProfileToken><trt:ConfigurationToken>vsc0</trt:ConfigurationToken></trt:AddVideoSourceConfiguration>`
+	if gotAction != "http://www.onvif.org/ver10/media/wsdl/AddVideoSourceConfiguration" || !strings.Contains(gotBody, want) {
+		t.Errorf("AddVideoSourceConfiguration %s body = %s", gotAction, gotBody)
+	}
+
+	if err := c.RemovePTZConfiguration(camera, "prof9"); err != nil {
+		t.Fatalf("RemovePTZConfiguration() error = %v", err)
+	}
+	want = `<trt:RemovePTZConfiguration><trt:ProfileToken>prof9</trt:ProfileToken></trt:RemovePTZConfiguration>`
+	if gotAction != "http://www.onvif.org/ver10/media/wsdl/RemovePTZConfiguration" || !strings.Contains(gotBody, want) {
+		t.Errorf("RemovePTZConfiguration %s body = %s", gotAction, gotBody)
+	}
+
+	if err := c.AddMetadataConfiguration(camera, "prof9", ""); err == nil {
+		t.Error("AddMetadataConfiguration() with empty token succeeded")
+	}
+
+	p, err := c.GetProfile(camera, "prof9")
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if p.Token != "prof9" || p.Fixed || p.VideoSourceBounds != (Rectangle{Width: 1920, Height: 1080}) ||
+		p.PTZConfigToken != "ptz0" || p.PTZNodeToken != "node0" || p.MetadataConfigToken != "meta0" || p.VideoEncoder != nil {
+		t.Errorf("GetProfile() = %+v", p)
+	}
+}
@@ -0,0 +1,273 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// metadataXML is the parsed form of a tt:MetadataConfiguration. Like the
+// encoder configurations it is replaced whole on write, so the event filter,
+// subscription policy and analytics engine block are kept for the round trip,
+// made self-contained with withNamespaces once read.
+type metadataXML struct {
+	Token           string                `xml:"token,attr"`
+	CompressionType string                `xml:"CompressionType,attr"`
+	GeoLocation     string                `xml:"GeoLocation,attr"`
+	Name            string                `xml:"Name"`
+	UseCount        int                   `xml:"UseCount"`
+	PTZStatus       *ptzFilterXML         `xml:"PTZStatus"`
+	Events          *eventSubscriptionXML `xml:"Events"`
+	Analytics       bool                  `xml:"Analytics"`
+	Multicast       multicastXML          `xml:"Multicast"`
+	SessionTimeout  string                `xml:"SessionTimeout"`
+	AnalyticsEngine *innerXML             `xml:"AnalyticsEngineConfiguration"`
+}
+
+// innerXML captures an element's content verbatim.
+type innerXML struct {
+	Inner string `xml:",innerxml"`
+}
+
+// ptzFilterXML is the parsed form of a tt:PTZFilter.
+type ptzFilterXML struct {
+	Status   bool `xml:"Status"`
+	Position bool `xml:"Position"`
+}
+
+// eventSubscriptionXML is the parsed form of a tt:EventSubscription. The
+// filter and subscription policy are kept for the round trip.
+type eventSubscriptionXML struct {
+	Filter             *eventFilterXML `xml:"Filter"`
+	SubscriptionPolicy *innerXML       `xml:"SubscriptionPolicy"`
+}
+
+// eventFilterXML is a wsnt:FilterType. Inner holds it verbatim, including a
+// MessageContent filter and the device's dialect; a filter with no Inner is
+// rendered from TopicExpression instead.
+type eventFilterXML struct {
+	Inner           string `xml:",innerxml"`
+	TopicExpression string `xml:"TopicExpression"`
+}
+
+func (m metadataXML) config() MetadataConfig {
+	cfg := MetadataConfig{
+		Token:           m.Token,
+		Name:            m.Name,
+		UseCount:        m.UseCount,
+		CompressionType: m.CompressionType,
+		PTZStatus:       new(bool),
+		PTZPosition:     new(bool),
+		Events:          new(bool),
+		EventFilter:     new(string),
+		Analytics:       new(bool),
+		Multicast:       m.Multicast.config(),
+	}
+	if m.PTZStatus != nil {
+		*cfg.PTZStatus, *cfg.PTZPosition = m.PTZStatus.Status, m.PTZStatus.Position
+	}
+	if m.Events != nil {
+		*cfg.Events = true
+		if m.Events.Filter != nil {
+			*cfg.EventFilter = strings.TrimSpace(m.Events.Filter.TopicExpression)
+		}
+	}
+	*cfg.Analytics = m.Analytics
+	return cfg
+}
+
+// apply overlays the set fields of cfg onto m.
+func (m *metadataXML) apply(cfg MetadataConfig) {
+	if cfg.Name != "" {
+		m.Name = cfg.Name
+	}
+	if cfg.CompressionType != "" {
+		m.CompressionType = cfg.CompressionType
+	}
+	if cfg.PTZStatus != nil || cfg.PTZPosition != nil {
+		if m.PTZStatus == nil {
+			m.PTZStatus = &ptzFilterXML{}
+		}
+		if cfg.PTZStatus != nil {
+			m.PTZStatus.Status = *cfg.PTZStatus
+		}
+		if cfg.PTZPosition != nil {
+			m.PTZStatus.Position = *cfg.PTZPosition
+		}
+	}
+	if cfg.Events != nil && !*cfg.Events {
+		m.Events = nil
+	} else if cfg.Events != nil || cfg.EventFilter != nil {
+		if m.Events == nil {
+			m.Events = &eventSubscriptionXML{}
+		}
+		// The device's filter is kept unless the topic expression changes.
+		if f := m.Events.Filter; cfg.EventFilter != nil &&
+			(f == nil || strings.TrimSpace(f.TopicExpression) != strings.TrimSpace(*cfg.EventFilter)) {
+			m.Events.Filter = &eventFilterXML{TopicExpression: *cfg.EventFilter}
+		}
+	}
+	if cfg.Analytics != nil {
+		m.Analytics = *cfg.Analytics
+	}
+	if cfg.Multicast != nil {
+		m.Multicast = newMulticastXML(*cfg.Multicast)
+	}
+}
+
+// buildSetMetadataBody renders a SetMetadataConfiguration request in schema
+// order (Name, UseCount, PTZStatus, Events, Analytics, Multicast,
+// SessionTimeout, AnalyticsEngineConfiguration).
+func buildSetMetadataBody(m metadataXML) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<trt:SetMetadataConfiguration><trt:Configuration token="%s"`, m.Token)
+	if m.CompressionType != "" {
+		fmt.Fprintf(&b, ` CompressionType="%s"`, m.CompressionType)
+	}
+	if m.GeoLocation != "" {
+		fmt.Fprintf(&b, ` GeoLocation="%s"`, m.GeoLocation)
+	}
+	fmt.Fprintf(&b, `><tt:Name>%s</tt:Name><tt:UseCount>%d</tt:UseCount>`, escapeXML(m.Name), m.UseCount)
+	if s := m.PTZStatus; s != nil {
+		fmt.Fprintf(&b, `<tt:PTZStatus><tt:Status>%t</tt:Status><tt:Position>%t</tt:Position></tt:PTZStatus>`, s.Status, s.Position)
+	}
+	if e := m.Events; e != nil {
+		b.WriteString(`<tt:Events>`)
+		if f := e.Filter; f != nil && f.Inner != "" {
+			fmt.Fprintf(&b, `<tt:Filter>%s</tt:Filter>`, f.Inner)
+		} else if f != nil {
+			b.WriteString(buildTopicFilterXML("tt:Filter", f.TopicExpression))
+		}
+		if e.SubscriptionPolicy != nil {
+			fmt.Fprintf(&b, `<tt:SubscriptionPolicy>%s</tt:SubscriptionPolicy>`, e.SubscriptionPolicy.Inner)
+		}
+		b.WriteString(`</tt:Events>`)
+	}
+	fmt.Fprintf(&b, `<tt:Analytics>%t</tt:Analytics>`, m.Analytics)
+	b.WriteString(buildMulticastXML(m.Multicast))
+	sessionTimeout := m.SessionTimeout
+	if sessionTimeout == "" {
+		sessionTimeout = "PT60S"
+	}
+	fmt.Fprintf(&b, `<tt:SessionTimeout>%s</tt:SessionTimeout>`, sessionTimeout)
+	if m.AnalyticsEngine != nil {
+		fmt.Fprintf(&b, `<tt:AnalyticsEngineConfiguration>%s</tt:AnalyticsEngineConfiguration>`, m.AnalyticsEngine.Inner)
+	}
+	b.WriteString(`</trt:Configuration><trt:ForcePersistence>true</trt:ForcePersistence></trt:SetMetadataConfiguration>`)
+	return b.String()
+}
+
+// getMetadataConfigurations fetches the raw metadata configurations.
+func (c *Client) getMetadataConfigurations(ctx context.Context, camera *Camera) ([]metadataXML, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetMetadataConfigurations", `<trt:GetMetadataConfigurations/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Configs []metadataXML `xml:"Body>GetMetadataConfigurationsResponse>Configurations"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata configurations: %v", err)
+	}
+	namespaces := declaredNamespaces(resp)
+	for _, m := range parsed.Configs {
+		if m.AnalyticsEngine != nil {
+			m.AnalyticsEngine.Inner = withNamespaces(m.AnalyticsEngine.Inner, namespaces)
+		}
+		if m.Events != nil && m.Events.Filter != nil {
+			m.Events.Filter.Inner = withNamespaces(m.Events.Filter.Inner, namespaces)
+		}
+		if m.Events != nil && m.Events.SubscriptionPolicy != nil {
+			m.Events.SubscriptionPolicy.Inner = withNamespaces(m.Events.SubscriptionPolicy.Inner, namespaces)
+		}
+	}
+	return parsed.Configs, nil
+}
+
+// GetMetadataConfigurations returns every metadata configuration on the
+// device.
+func (c *Client) GetMetadataConfigurations(camera *Camera) ([]MetadataConfig, error) {
+	return c.GetMetadataConfigurationsContext(context.Background(), camera)
+}
+
+// GetMetadataConfigurationsContext is like GetMetadataConfigurations but uses
+// ctx for cancellation and deadlines.
+func (c *Client) GetMetadataConfigurationsContext(ctx context.Context, camera *Camera) ([]MetadataConfig, error) {
+	raw, err := c.getMetadataConfigurations(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]MetadataConfig, 0, len(raw))
+	for _, m := range raw {
+		configs = append(configs, m.config())
+	}
+	return configs, nil
+}
+
+// GetMetadataConfigurationOptions returns which PTZ status filters,
+// compression types and extras a metadata configuration accepts. Either
+// token may be empty.
+func (c *Client) GetMetadataConfigurationOptions(camera *Camera, configToken, profileToken string) (*MetadataOptions, error) {
+	return c.GetMetadataConfigurationOptionsContext(context.Background(), camera, configToken, profileToken)
+}
+
+// GetMetadataConfigurationOptionsContext is like
+// GetMetadataConfigurationOptions but uses ctx for cancellation and deadlines.
+func (c *Client) GetMetadataConfigurationOptionsContext(ctx context.Context, camera *Camera, configToken, profileToken string) (*MetadataOptions, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetMetadataConfigurationOptions",
+		buildOptionsBody("trt", "GetMetadataConfigurationOptions", configToken, profileToken))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Options struct {
+			GeoLocation bool `xml:"GeoLocation,attr"`
+			PTZ         struct {
+				PanTiltStatus   bool `xml:"PanTiltStatusSupported"`
+				ZoomStatus      bool `xml:"ZoomStatusSupported"`
+				PanTiltPosition bool `xml:"PanTiltPositionSupported"`
+				ZoomPosition    bool `xml:"ZoomPositionSupported"`
+			} `xml:"PTZStatusFilterOptions"`
+			CompressionTypes []string `xml:"Extension>CompressionType"`
+		} `xml:"Body>GetMetadataConfigurationOptionsResponse>Options"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata options: %v", err)
+	}
+	o := parsed.Options
+	return &MetadataOptions{
+		PanTiltStatusSupported:   o.PTZ.PanTiltStatus,
+		ZoomStatusSupported:      o.PTZ.ZoomStatus,
+		PanTiltPositionSupported: o.PTZ.PanTiltPosition,
+		ZoomPositionSupported:    o.PTZ.ZoomPosition,
+		CompressionTypes:         trimAll(o.CompressionTypes),
+		GeoLocation:              o.GeoLocation,
+	}, nil
+}
+
+// SetMetadataConfiguration updates the metadata configuration cfg.Token. The
+// current configuration is read first and only cfg's set fields change; for
+// example, Analytics: &yes turns on object data in the RTSP metadata track.
+func (c *Client) SetMetadataConfiguration(camera *Camera, cfg MetadataConfig) error {
+	return c.SetMetadataConfigurationContext(context.Background(), camera, cfg)
+}
+
+// SetMetadataConfigurationContext is like SetMetadataConfiguration but uses
+// ctx for cancellation and deadlines.
+func (c *Client) SetMetadataConfigurationContext(ctx context.Context, camera *Camera, cfg MetadataConfig) error {
+	raw, err := c.getMetadataConfigurations(ctx, camera)
+	if err != nil {
+		return err
+	}
+	for _, m := range raw {
+		if m.Token == cfg.Token {
+			m.apply(cfg)
+			_, err := c.mediaCall(ctx, camera, "SetMetadataConfiguration", buildSetMetadataBody(m))
+			return err
+		}
+	}
+	return fmt.Errorf("metadata configuration %q not found", cfg.Token)
+}
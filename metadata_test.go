@@ -0,0 +1,115 @@
+package onvif
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMetadataConfiguration(t *testing.T) {
+	var gotAction, gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotAction, gotBody = action, body
+		switch action {
+		case "http://www.onvif.org/ver10/media/wsdl/GetMetadataConfigurations":
+			return `<trt:GetMetadataConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+<trt:Configurations token="meta0" CompressionType="None" xmlns:axis="http://www.axis.com/vapix/ws/analytics" xmlns:tnsaxis="http://www.axis.com/2009/event/topics"><tt:Name>Metadata</tt:Name><tt:UseCount>1</tt:UseCount>
+<tt:PTZStatus><tt:Status>true</tt:Status><tt:Position>false</tt:Position></tt:PTZStatus>
+<tt:Events><tt:Filter><wsnt:TopicExpression Dialect="http://docs.oasis-open.org/wsn/t-1/TopicExpression/Concrete">tnsaxis:Storage/Alert</wsnt:TopicExpression>
+<wsnt:MessageContent Dialect="http://www.onvif.org/ver10/tev/messageContentFilter/ItemFilter">boolean(//SimpleItem[@Name="disk_id"])</wsnt:MessageContent></tt:Filter>
+<tt:SubscriptionPolicy><axis:Rate>5</axis:Rate></tt:SubscriptionPolicy></tt:Events>
+<tt:Analytics>false</tt:Analytics>
+<tt:Multicast><tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>0.0.0.0</tt:IPv4Address></tt:Address><tt:Port>0</tt:Port><tt:TTL>5</tt:TTL><tt:AutoStart>false</tt:AutoStart></tt:Multicast>
+<tt:SessionTimeout>PT30S</tt:SessionTimeout>
+<tt:AnalyticsEngineConfiguration><tt:AnalyticsModule Name="MotionDetector" Type="axis:CellMotion"/></tt:AnalyticsEngineConfiguration>
+</trt:Configurations></trt:GetMetadataConfigurationsResponse>`
+		case "http://www.onvif.org/ver10/media/wsdl/GetMetadataConfigurationOptions":
+			return `<trt:GetMetadataConfigurationOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:Options GeoLocation="true">
+<tt:PTZStatusFilterOptions><tt:PanTiltStatusSupported>true</tt:PanTiltStatusSupported><tt:ZoomStatusSupported>true</tt:ZoomStatusSupported>
+<tt:PanTiltPositionSupported>false</tt:PanTiltPositionSupported><tt:ZoomPositionSupported>false</tt:ZoomPositionSupported></tt:PTZStatusFilterOptions>
+<tt:Extension><tt:CompressionType>None</tt:CompressionType><tt:CompressionType>GZIP</tt:CompressionType></tt:Extension>
+</trt:Options></trt:GetMetadataConfigurationOptionsResponse>`
+		}
+		return `<trt:Response xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+	})
+	camera := &Camera{MediaURL: "http://192.0.2.10/onvif/media"}
+
+	configs, err := c.GetMetadataConfigurations(camera)
+	if err != nil {
+		t.Fatalf("GetMetadataConfigurations() error = %v", err)
+	}
+	if len(configs) != 1 {
+		t.Fatalf("GetMetadataConfigurations() = %+v", configs)
+	}
+	m := configs[0]
+	if m.Token != "meta0" || m.CompressionType != "None" || !*m.PTZStatus || *m.PTZPosition ||
+		!*m.Events || *m.EventFilter != "tnsaxis:Storage/Alert" || *m.Analytics || m.Multicast == nil || m.Multicast.TTL != 5 {
+		t.Errorf("GetMetadataConfigurations()[0] = %+v", m)
+	}
+
+	opts, err := c.GetMetadataConfigurationOptions(camera, "meta0", "")
+	if err != nil {
+		t.Fatalf("GetMetadataConfigurationOptions() error = %v", err)
+	}
+	want := &MetadataOptions{
+		PanTiltStatusSupported: true,
+		ZoomStatusSupported:    true,
+		CompressionTypes:       []string{"None", "GZIP"},
+		GeoLocation:            true,
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("GetMetadataConfigurationOptions() = %+v", opts)
+	}
+
+	// A rename keeps the device's filter whole, dialect and message content
+	// included, with the vendor topic prefix declared.
+	name := "Renamed"
+	if err := c.SetMetadataConfiguration(camera, MetadataConfig{Token: "meta0", Name: name}); err != nil {
+		t.Fatalf("SetMetadataConfiguration() error = %v", err)
+	}
+	wantFilter := `<tt:Events><tt:Filter><wsnt:TopicExpression Dialect="http://docs.oasis-open.org/wsn/t-1/TopicExpression/Concrete" ` +
+		`xmlns:tnsaxis="http://www.axis.com/2009/event/topics">tnsaxis:Storage/Alert</wsnt:TopicExpression>`
+	if !strings.Contains(gotBody, wantFilter) || !strings.Contains(gotBody, `<wsnt:MessageContent Dialect="http://www.onvif.org/ver10/tev/messageContentFilter/ItemFilter"`) {
+		t.Errorf("SetMetadataConfiguration body lost the filter: %s", gotBody)
+	}
+
+	yes, filter := true, "tns1:RuleEngine//."
+	if err := c.SetMetadataConfiguration(camera, MetadataConfig{Token: "meta0", Analytics: &yes, EventFilter: &filter}); err != nil {
+		t.Fatalf("SetMetadataConfiguration() error = %v", err)
+	}
+	if !strings.HasSuffix(gotAction, "/SetMetadataConfiguration") {
+		t.Errorf("SetMetadataConfiguration action = %s", gotAction)
+	}
+	for _, w := range []string{
+		`<trt:Configuration token="meta0" CompressionType="None">`,
+		`<tt:PTZStatus><tt:Status>true</tt:Status><tt:Position>false</tt:Position></tt:PTZStatus>`,
+		`<tt:Events><tt:Filter><wsnt:TopicExpression`,
+		`tns1:RuleEngine//.</wsnt:TopicExpression></tt:Filter>` +
+			`<tt:SubscriptionPolicy><axis:Rate xmlns:axis="http://www.axis.com/vapix/ws/analytics">5</axis:Rate></tt:SubscriptionPolicy></tt:Events><tt:Analytics>true</tt:Analytics>`,
+		`<tt:SessionTimeout>PT30S</tt:SessionTimeout>`,
+		// Prefixes the camera bound are declared again, since our envelope
+		// does not bind axis.
+		`<tt:AnalyticsEngineConfiguration><tt:AnalyticsModule Name="MotionDetector" Type="axis:CellMotion" ` +
+			`xmlns:axis="http://www.axis.com/vapix/ws/analytics" xmlns:tt="http://www.onvif.org/ver10/schema"/></tt:AnalyticsEngineConfiguration>`,
+	} {
+		if !strings.Contains(gotBody, w) {
+			t.Errorf("SetMetadataConfiguration body missing %q: %s", w, gotBody)
+		}
+	}
+
+	// An event filter turns events on, unless they are explicitly turned off.
+	var off metadataXML
+	off.apply(MetadataConfig{EventFilter: &filter})
+	if off.Events == nil || off.Events.Filter == nil || off.Events.Filter.TopicExpression != filter {
+		t.Errorf("EventFilter alone left Events = %+v", off.Events)
+	}
+	no := false
+	off.apply(MetadataConfig{Events: &no, EventFilter: &filter})
+	if off.Events != nil {
+		t.Errorf("Events false with a filter left Events = %+v", off.Events)
+	}
+
+	if err := c.SetMetadataConfiguration(camera, MetadataConfig{Token: "missing"}); err == nil {
+		t.Error("SetMetadataConfiguration(missing) returned nil error")
+	}
+}
@@ -0,0 +1,270 @@
+package onvif
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// packBits compresses data with the PackBits run-length scheme used by the
+// ActiveCells parameter: a header byte n in 0..127 is followed by n+1 literal
+// bytes, and a header in -127..-1 repeats the next byte 1-n times.
+func packBits(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); {
+		run := 1
+		for i+run < len(data) && run < 128 && data[i+run] == data[i] {
+			run++
+		}
+		if run > 1 {
+			out = append(out, byte(257-run), data[i])
+			i += run
+			continue
+		}
+		// Literal packet: up to 128 bytes, ending where a run starts.
+		start := i
+		for i++; i < len(data) && i-start < 128; i++ {
+			if i+1 < len(data) && data[i] == data[i+1] {
+				break
+			}
+		}
+		out = append(out, byte(i-start-1))
+		out = append(out, data[start:i]...)
+	}
+	return out
+}
+
+// unpackBits reverses packBits. A header of -128 is a no-op, as in the
+// original scheme.
+func unpackBits(data []byte) ([]byte, error) {
+	var out []byte
+	for i := 0; i < len(data); {
+		n := int(int8(data[i]))
+		i++
+		switch {
+		case n >= 0:
+			if i+n+1 > len(data) {
+				return nil, fmt.Errorf("packbits: literal run past end of data")
+			}
+			out = append(out, data[i:i+n+1]...)
+			i += n + 1
+		case n > -128:
+			if i >= len(data) {
+				return nil, fmt.Errorf("packbits: repeat run past end of data")
+			}
+			for j := 0; j < 1-n; j++ {
+				out = append(out, data[i])
+			}
+			i++
+		}
+	}
+	return out, nil
+}
+
+// decodeActiveCells decodes an ActiveCells value (base64 of the PackBits
+// compressed cell bitmap, row by row, most significant bit first) into a
+// rows x columns mask. An empty value means no cell is active.
+func decodeActiveCells(value string, columns, rows int) ([][]bool, error) {
+	cells := make([][]bool, rows)
+	for r := range cells {
+		cells[r] = make([]bool, columns)
+	}
+	if strings.TrimSpace(value) == "" {
+		return cells, nil
+	}
+	packed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ActiveCells: %v", err)
+	}
+	bits, err := unpackBits(packed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ActiveCells: %v", err)
+	}
+	if len(bits)*8 < columns*rows {
+		return nil, fmt.Errorf("invalid ActiveCells: %d bytes for a %dx%d grid", len(bits), columns, rows)
+	}
+	for k := 0; k < columns*rows; k++ {
+		cells[k/columns][k%columns] = bits[k/8]&(0x80>>(k%8)) != 0
+	}
+	return cells, nil
+}
+
+// encodeActiveCells is the inverse of decodeActiveCells. Every row of cells
+// must have columns entries.
+func encodeActiveCells(cells [][]bool, columns int) string {
+	bits := make([]byte, (len(cells)*columns+7)/8)
+	for r, row := range cells {
+		for c, active := range row {
+			if active {
+				k := r*columns + c
+				bits[k/8] |= 0x80 >> (k % 8)
+			}
+		}
+	}
+	return base64.StdEncoding.EncodeToString(packBits(bits))
+}
+
+// analyticsTypeIs reports whether an analytics type such as
+// "tt:CellMotionEngine" has the local name local, whatever its prefix.
+func analyticsTypeIs(typ, local string) bool {
+	if i := strings.LastIndex(typ, ":"); i >= 0 {
+		typ = typ[i+1:]
+	}
+	return typ == local
+}
+
+func (a AnalyticsConfig) simpleItem(name string) string {
+	for _, it := range a.SimpleItems {
+		if it.Name == name {
+			return it.Value
+		}
+	}
+	return ""
+}
+
+func (a *AnalyticsConfig) setSimpleItem(name, value string) {
+	for i := range a.SimpleItems {
+		if a.SimpleItems[i].Name == name {
+			a.SimpleItems[i].Value = value
+			return
+		}
+	}
+	a.SimpleItems = append(a.SimpleItems, SimpleItem{Name: name, Value: value})
+}
+
+// cellLayout returns the grid size from a CellMotionEngine module's Layout
+// item (a tt:CellLayout).
+func (a AnalyticsConfig) cellLayout() (columns, rows int, err error) {
+	for _, e := range a.ElementItems {
+		if e.Name != "Layout" {
+			continue
+		}
+		root, err := e.node()
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid cell layout: %v", err)
+		}
+		for _, n := range root.Nodes {
+			if n.XMLName.Local == "CellLayout" {
+				columns, _ = strconv.Atoi(n.attr("Columns"))
+				rows, _ = strconv.Atoi(n.attr("Rows"))
+				if columns > 0 && rows > 0 {
+					return columns, rows, nil
+				}
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("module %q has no cell layout", a.Name)
+}
+
+// findMotionConfigs returns the CellMotionEngine module and the
+// CellMotionDetector rule of configToken. Empty names pick the first of each.
+func (c *Client) findMotionConfigs(ctx context.Context, camera *Camera, configToken, moduleName, ruleName string) (module, rule *AnalyticsConfig, err error) {
+	modules, err := c.GetAnalyticsModulesContext(ctx, camera, configToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range modules {
+		if analyticsTypeIs(modules[i].Type, "CellMotionEngine") && (moduleName == "" || modules[i].Name == moduleName) {
+			module = &modules[i]
+			break
+		}
+	}
+	if module == nil {
+		return nil, nil, fmt.Errorf("no CellMotionEngine module in analytics configuration %q", configToken)
+	}
+
+	rules, err := c.GetRulesContext(ctx, camera, configToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range rules {
+		if analyticsTypeIs(rules[i].Type, "CellMotionDetector") && (ruleName == "" || rules[i].Name == ruleName) {
+			rule = &rules[i]
+			break
+		}
+	}
+	if rule == nil {
+		return nil, nil, fmt.Errorf("no CellMotionDetector rule in analytics configuration %q", configToken)
+	}
+	return module, rule, nil
+}
+
+// GetMotionRegion reads the cell motion detection setup of the video
+// analytics configuration configToken (a profile's AnalyticsConfigToken) and
+// decodes its active cells into a mask.
+func (c *Client) GetMotionRegion(camera *Camera, configToken string) (*MotionRegion, error) {
+	return c.GetMotionRegionContext(context.Background(), camera, configToken)
+}
+
+// GetMotionRegionContext is like GetMotionRegion but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetMotionRegionContext(ctx context.Context, camera *Camera, configToken string) (*MotionRegion, error) {
+	module, rule, err := c.findMotionConfigs(ctx, camera, configToken, "", "")
+	if err != nil {
+		return nil, err
+	}
+	columns, rows, err := module.cellLayout()
+	if err != nil {
+		return nil, err
+	}
+	cells, err := decodeActiveCells(rule.simpleItem("ActiveCells"), columns, rows)
+	if err != nil {
+		return nil, err
+	}
+	region := &MotionRegion{
+		ConfigToken: configToken,
+		ModuleName:  module.Name,
+		RuleName:    rule.Name,
+		Columns:     columns,
+		Rows:        rows,
+		Cells:       cells,
+	}
+	if v := module.simpleItem("Sensitivity"); v != "" {
+		sensitivity, _ := strconv.Atoi(v)
+		region.Sensitivity = &sensitivity
+	}
+	return region, nil
+}
+
+// SetMotionRegion writes region's sensitivity, if set, to its
+// CellMotionEngine module and then its mask to its CellMotionDetector rule,
+// keeping their other parameters. Start from GetMotionRegion; the mask must
+// match the camera's grid. The two writes are separate requests: if the rule
+// write fails, the new sensitivity stays applied.
+func (c *Client) SetMotionRegion(camera *Camera, region MotionRegion) error {
+	return c.SetMotionRegionContext(context.Background(), camera, region)
+}
+
+// SetMotionRegionContext is like SetMotionRegion but uses ctx for
+// cancellation and deadlines.
+func (c *Client) SetMotionRegionContext(ctx context.Context, camera *Camera, region MotionRegion) error {
+	module, rule, err := c.findMotionConfigs(ctx, camera, region.ConfigToken, region.ModuleName, region.RuleName)
+	if err != nil {
+		return err
+	}
+	columns, rows, err := module.cellLayout()
+	if err != nil {
+		return err
+	}
+	if len(region.Cells) != rows {
+		return fmt.Errorf("motion mask has %d rows, camera grid has %d", len(region.Cells), rows)
+	}
+	for r, row := range region.Cells {
+		if len(row) != columns {
+			return fmt.Errorf("motion mask row %d has %d cells, camera grid has %d columns", r, len(row), columns)
+		}
+	}
+
+	if region.Sensitivity != nil {
+		if sensitivity := strconv.Itoa(*region.Sensitivity); module.simpleItem("Sensitivity") != sensitivity {
+			module.setSimpleItem("Sensitivity", sensitivity)
+			if err := c.ModifyAnalyticsModulesContext(ctx, camera, region.ConfigToken, *module); err != nil {
+				return err
+			}
+		}
+	}
+	rule.setSimpleItem("ActiveCells", encodeActiveCells(region.Cells, columns))
+	return c.ModifyRulesContext(ctx, camera, region.ConfigToken, *rule)
+}
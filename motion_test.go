@@ -0,0 +1,147 @@
+package onvif
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPackBits(t *testing.T) {
+	// The example from Apple's PackBits technical note.
+	unpacked := []byte{0xAA, 0xAA, 0xAA, 0x80, 0x00, 0x2A, 0xAA, 0xAA, 0xAA, 0xAA, 0x80, 0x00, 0x2A, 0x22,
+		0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA}
+	packed := []byte{0xFE, 0xAA, 0x02, 0x80, 0x00, 0x2A, 0xFD, 0xAA, 0x03, 0x80, 0x00, 0x2A, 0x22, 0xF7, 0xAA}
+
+	if got := packBits(unpacked); !bytes.Equal(got, packed) {
+		t.Errorf("packBits() = % X, want % X", got, packed)
+	}
+	if got, err := unpackBits(packed); err != nil || !bytes.Equal(got, unpacked) {
+		t.Errorf("unpackBits() = % X, %v", got, err)
+	}
+
+	long := append(bytes.Repeat([]byte{0}, 300), []byte("a literal run")...)
+	if got, err := unpackBits(packBits(long)); err != nil || !bytes.Equal(got, long) {
+		t.Errorf("round trip of %d bytes = %d bytes, %v", len(long), len(got), err)
+	}
+	if _, err := unpackBits([]byte{0x05, 0x01}); err == nil {
+		t.Error("unpackBits() of a truncated literal returned nil error")
+	}
+}
+
+func TestActiveCells(t *testing.T) {
+	// 8x6 grid: the top three rows fully active, the bottom three active in
+	// their left half.
+	cells, err := decodeActiveCells("/v/+8A==", 8, 6)
+	if err != nil {
+		t.Fatalf("decodeActiveCells() error = %v", err)
+	}
+	for r, row := range cells {
+		for c, active := range row {
+			if want := r < 3 || c < 4; active != want {
+				t.Errorf("cell (%d,%d) = %v, want %v", r, c, active, want)
+			}
+		}
+	}
+	if got := encodeActiveCells(cells, 8); got != "/v/+8A==" {
+		t.Errorf("encodeActiveCells() = %q", got)
+	}
+
+	// A grid whose size is not a multiple of 8 packs rows back to back.
+	odd := [][]bool{{true, false, true}, {false, true, false}, {true, true, true}}
+	got, err := decodeActiveCells(encodeActiveCells(odd, 3), 3, 3)
+	if err != nil || !equalMasks(got, odd) {
+		t.Errorf("3x3 round trip = %v, %v", got, err)
+	}
+
+	if _, err := decodeActiveCells("/wA=", 22, 15); err == nil {
+		t.Error("decodeActiveCells() accepted too few bits")
+	}
+}
+
+func equalMasks(a, b [][]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for r := range a {
+		if len(a[r]) != len(b[r]) {
+			return false
+		}
+		for c := range a[r] {
+			if a[r][c] != b[r][c] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestMotionRegion(t *testing.T) {
+	var actions, bodies []string
+	c := fakeSOAPClient(func(action, body string) string {
+		actions, bodies = append(actions, action), append(bodies, body)
+		switch action {
+		case "http://www.onvif.org/ver20/analytics/wsdl/GetAnalyticsModules":
+			return `<tan:GetAnalyticsModulesResponse xmlns:tan="http://www.onvif.org/ver20/analytics/wsdl">
+<tan:AnalyticsModule Name="MyCellMotionModule" Type="tt:CellMotionEngine"><tt:Parameters>
+<tt:SimpleItem Name="Sensitivity" Value="50"/>
+<tt:ElementItem Name="Layout"><tt:CellLayout Columns="8" Rows="6"><tt:Transformation><tt:Translate x="-1.0" y="-1.0"/><tt:Scale x="0.25" y="0.333333"/></tt:Transformation></tt:CellLayout></tt:ElementItem>
+</tt:Parameters></tan:AnalyticsModule></tan:GetAnalyticsModulesResponse>`
+		case "http://www.onvif.org/ver20/analytics/wsdl/GetRules":
+			return `<tan:GetRulesResponse xmlns:tan="http://www.onvif.org/ver20/analytics/wsdl">
+<tan:Rule Name="Gate" Type="tt:LineDetector"><tt:Parameters/></tan:Rule>
+<tan:Rule Name="MyMotionDetectorRule" Type="tt:CellMotionDetector"><tt:Parameters>
+<tt:SimpleItem Name="MinCount" Value="5"/><tt:SimpleItem Name="AlarmOnDelay" Value="1000"/>
+<tt:SimpleItem Name="AlarmOffDelay" Value="1000"/><tt:SimpleItem Name="ActiveCells" Value="/v/+8A=="/>
+</tt:Parameters></tan:Rule></tan:GetRulesResponse>`
+		}
+		return `<tan:Response xmlns:tan="http://www.onvif.org/ver20/analytics/wsdl"/>`
+	})
+	camera := &Camera{AnalyticsURL: "http://192.0.2.10/onvif/analytics"}
+
+	region, err := c.GetMotionRegion(camera, "vac0")
+	if err != nil {
+		t.Fatalf("GetMotionRegion() error = %v", err)
+	}
+	if region.ModuleName != "MyCellMotionModule" || region.RuleName != "MyMotionDetectorRule" ||
+		region.Columns != 8 || region.Rows != 6 || region.Sensitivity == nil || *region.Sensitivity != 50 || !region.Cells[5][3] || region.Cells[5][4] {
+		t.Fatalf("GetMotionRegion() = %+v", region)
+	}
+
+	// Mask off the whole bottom half and lower the sensitivity.
+	for r := 3; r < 6; r++ {
+		for c := range region.Cells[r] {
+			region.Cells[r][c] = false
+		}
+	}
+	sensitivity := 30
+	region.Sensitivity = &sensitivity
+	actions, bodies = nil, nil
+	if err := c.SetMotionRegion(camera, *region); err != nil {
+		t.Fatalf("SetMotionRegion() error = %v", err)
+	}
+	if len(actions) != 4 || !strings.HasSuffix(actions[2], "/ModifyAnalyticsModules") || !strings.HasSuffix(actions[3], "/ModifyRules") {
+		t.Fatalf("SetMotionRegion actions = %v", actions)
+	}
+	wantRule := `<tan:Rule Name="MyMotionDetectorRule" Type="tt:CellMotionDetector"><tt:Parameters>` +
+		`<tt:SimpleItem Name="MinCount" Value="5"/><tt:SimpleItem Name="AlarmOnDelay" Value="1000"/>` +
+		`<tt:SimpleItem Name="AlarmOffDelay" Value="1000"/><tt:SimpleItem Name="ActiveCells" Value="/v/+AA=="/>` +
+		`</tt:Parameters></tan:Rule>`
+	if !strings.Contains(bodies[3], wantRule) {
+		t.Errorf("ModifyRules body = %s\nwant %s", bodies[3], wantRule)
+	}
+	if !strings.Contains(bodies[2], `<tt:SimpleItem Name="Sensitivity" Value="30"/><tt:ElementItem Name="Layout"><tt:CellLayout Columns="8" Rows="6" xmlns:tt="http://www.onvif.org/ver10/schema">`) {
+		t.Errorf("ModifyAnalyticsModules body = %s", bodies[2])
+	}
+
+	// Without a sensitivity only the rule is written.
+	region.Sensitivity = nil
+	actions, bodies = nil, nil
+	if err := c.SetMotionRegion(camera, *region); err != nil || len(actions) != 3 || !strings.HasSuffix(actions[2], "/ModifyRules") {
+		t.Errorf("SetMotionRegion() without sensitivity = %v, actions %v", err, actions)
+	}
+
+	region.Cells = region.Cells[:5]
+	if err := c.SetMotionRegion(camera, *region); err == nil || !strings.Contains(err.Error(), "5 rows") {
+		t.Errorf("SetMotionRegion() with a short mask error = %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// consumerRegistration is one handler registered on an EventConsumer. inFlight
+// tracks running dispatches so Unregister can wait for them to finish.
+type consumerRegistration struct {
+	handler  func(NotificationMessage)
+	inFlight sync.WaitGroup
+}
+
+// NewEventConsumer returns an EventConsumer whose registrations are served
+// under baseURL, the externally reachable URL of its mount point.
+func NewEventConsumer(baseURL string) *EventConsumer {
+	return &EventConsumer{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Register routes notifications posted to BaseURL/key to handler and returns
+// that consumer address, to be passed to Subscribe. Registering an existing key
+// replaces its handler.
+func (ec *EventConsumer) Register(key string, handler func(NotificationMessage)) string {
+	ec.mu.Lock()
+	if ec.handlers == nil {
+		ec.handlers = make(map[string]*consumerRegistration)
+	}
+	ec.handlers[key] = &consumerRegistration{handler: handler}
+	ec.mu.Unlock()
+	return strings.TrimRight(ec.BaseURL, "/") + "/" + key
+}
+
+// Unregister removes the handler for key and waits for any notification it is
+// still processing. Later posts to the key are answered with 404.
+func (ec *EventConsumer) Unregister(key string) {
+	ec.mu.Lock()
+	reg := ec.handlers[key]
+	delete(ec.handlers, key)
+	ec.mu.Unlock()
+	if reg != nil {
+		reg.inFlight.Wait()
+	}
+}
+
+// ServeHTTP parses a Notify request and hands each NotificationMessage to the
+// handler registered for the last path segment.
+func (ec *EventConsumer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ec.mu.RLock()
+	reg := ec.handlers[path.Base(r.URL.Path)]
+	if reg != nil {
+		reg.inFlight.Add(1)
+	}
+	ec.mu.RUnlock()
+	if reg == nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer reg.inFlight.Done()
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 4<<20))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+	messages, err := parseNotify(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, m := range messages {
+		reg.handler(m)
+	}
+
+	// Notify is one-way; answer with an empty SOAP 1.2 envelope, which every
+	// camera we have seen accepts as success.
+	w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+	_, _ = io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body/></s:Envelope>`)
+}
+
+// parseNotify extracts the notification messages from a wsnt:Notify envelope.
+func parseNotify(body []byte) ([]NotificationMessage, error) {
+	var parsed struct {
+		Notify *struct {
+			Messages []notificationMessageXML `xml:"NotificationMessage"`
+		} `xml:"Body>Notify"`
+	}
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse Notify: %v", err)
+	}
+	if parsed.Notify == nil {
+		return nil, fmt.Errorf("request is not a Notify message")
+	}
+	messages := make([]NotificationMessage, 0, len(parsed.Notify.Messages))
+	for _, m := range parsed.Notify.Messages {
+		messages = append(messages, m.message())
+	}
+	return messages, nil
+}
+
+// Subscribe creates a push (WS-BaseNotification) subscription: the camera will
+// POST Notify messages to consumerAddress, typically an address returned by
+// EventConsumer.Register. filter is a ConcreteSet topic expression or "" for
+// every topic; the subscription lapses after terminationTime unless renewed.
+func (c *Client) Subscribe(camera *Camera, consumerAddress, filter string, terminationTime time.Duration) (*EventSubscription, error) {
+	return c.SubscribeContext(context.Background(), camera, consumerAddress, filter, terminationTime)
+}
+
+// SubscribeContext is like Subscribe but uses ctx for cancellation and
+// deadlines.
+func (c *Client) SubscribeContext(ctx context.Context, camera *Camera, consumerAddress, filter string, terminationTime time.Duration) (*EventSubscription, error) {
+	eventsURL := c.resolveEventsURL(ctx, camera)
+
+	termination := ""
+	if terminationTime > 0 {
+		termination = fmt.Sprintf(`<wsnt:InitialTerminationTime>%s</wsnt:InitialTerminationTime>`, formatXSDuration(terminationTime))
+	}
+	body := fmt.Sprintf(`<wsnt:Subscribe>
+		<wsnt:ConsumerReference><wsa:Address>%s</wsa:Address></wsnt:ConsumerReference>%s%s
+	</wsnt:Subscribe>`, escapeXML(consumerAddress), buildTopicFilterXML("wsnt:Filter", filter), termination)
+
+	resp, err := c.sendSOAPRequestWithHeader(ctx, eventsURL, actionSubscribe,
+		addressingHeader(actionSubscribe, eventsURL, ""), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Reference       subscriptionReferenceXML `xml:"Body>SubscribeResponse>SubscriptionReference"`
+		CurrentTime     string                   `xml:"Body>SubscribeResponse>CurrentTime"`
+		TerminationTime string                   `xml:"Body>SubscribeResponse>TerminationTime"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse subscribe response: %v", err)
+	}
+	return parsed.Reference.subscription(declaredNamespaces(resp), parsed.CurrentTime, parsed.TerminationTime)
+}
+
+// PushEvents registers a new handler on consumer, subscribes the camera to it
+// and keeps the subscription renewed in the background, delivering pushed
+// events on the returned channel. When ctx is done the camera is unsubscribed
+// and the handler unregistered; a failed renewal is sent on the error channel
+// and also ends the subscription. Both channels are closed on exit.
+func (c *Client) PushEvents(ctx context.Context, camera *Camera, consumer *EventConsumer, opts SubscribeOptions) (<-chan NotificationMessage, <-chan error, error) {
+	if opts.TerminationTime <= 0 {
+		opts.TerminationTime = 60 * time.Second
+	}
+
+	events := make(chan NotificationMessage)
+	done := make(chan struct{})
+	key := randomHex(8)
+	address := consumer.Register(key, func(m NotificationMessage) {
+		select {
+		case events <- m:
+		case <-ctx.Done():
+		case <-done:
+		}
+	})
+
+	sub, err := c.SubscribeContext(ctx, camera, address, opts.Filter, opts.TerminationTime)
+	if err != nil {
+		close(done)
+		consumer.Unregister(key)
+		return nil, nil, err
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(events)
+		// Unregister waits for in-flight deliveries, which bail out once done
+		// is closed, so nothing can send on events after it is closed.
+		defer consumer.Unregister(key)
+		defer close(done)
+		defer c.unsubscribeDetached(sub)
+
+		for {
+			renewIn := time.Until(subscriptionExpiry(sub, opts.TerminationTime)) / 2
+			if renewIn < time.Second {
+				renewIn = time.Second
+			}
+			timer := time.NewTimer(renewIn)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			if err := c.RenewContext(ctx, sub, opts.TerminationTime); err != nil {
+				if ctx.Err() == nil {
+					errs <- err
+				}
+				return
+			}
+		}
+	}()
+	return events, errs, nil
+}
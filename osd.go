@@ -1,6 +1,7 @@
 package onvif
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strings"
@@ -15,12 +16,140 @@ func getMedia2URL(address string) string {
 	return url
 }
 
+// osdColorXML is the parsed form of a tt:OSDColor.
+type osdColorXML struct {
+	Transparent int `xml:"Transparent,attr"`
+	Color       struct {
+		X          float64 `xml:"X,attr"`
+		Y          float64 `xml:"Y,attr"`
+		Z          float64 `xml:"Z,attr"`
+		Colorspace string  `xml:"Colorspace,attr"`
+	} `xml:"Color"`
+}
+
+func (c *osdColorXML) color() *OSDColor {
+	if c == nil {
+		return nil
+	}
+	return &OSDColor{X: c.Color.X, Y: c.Color.Y, Z: c.Color.Z, Colorspace: c.Color.Colorspace, Transparent: c.Transparent}
+}
+
+// osdXML is the parsed form of a tt:OSDConfiguration.
+type osdXML struct {
+	Token            string `xml:"token,attr"`
+	VideoSourceToken string `xml:"VideoSourceConfigurationToken"`
+	Type             string `xml:"Type"`
+	Position         struct {
+		Type string `xml:"Type"`
+		Pos  struct {
+			X float64 `xml:"x,attr"`
+			Y float64 `xml:"y,attr"`
+		} `xml:"Pos"`
+	} `xml:"Position"`
+	Text struct {
+		Type            string       `xml:"Type"`
+		DateFormat      string       `xml:"DateFormat"`
+		TimeFormat      string       `xml:"TimeFormat"`
+		FontSize        int          `xml:"FontSize"`
+		FontColor       *osdColorXML `xml:"FontColor"`
+		BackgroundColor *osdColorXML `xml:"BackgroundColor"`
+		PlainText       string       `xml:"PlainText"`
+	} `xml:"TextString"`
+	ImagePath string `xml:"Image>ImgPath"`
+}
+
+func (o osdXML) config() OSDConfig {
+	return OSDConfig{
+		Token:            o.Token,
+		Type:             strings.TrimSpace(o.Type),
+		VideoSourceToken: strings.TrimSpace(o.VideoSourceToken),
+		PositionType:     strings.TrimSpace(o.Position.Type),
+		PositionX:        o.Position.Pos.X,
+		PositionY:        o.Position.Pos.Y,
+		TextType:         strings.TrimSpace(o.Text.Type),
+		PlainText:        o.Text.PlainText,
+		DateFormat:       o.Text.DateFormat,
+		TimeFormat:       o.Text.TimeFormat,
+		FontSize:         o.Text.FontSize,
+		FontColor:        o.Text.FontColor.color(),
+		BackgroundColor:  o.Text.BackgroundColor.color(),
+		ImagePath:        o.ImagePath,
+	}
+}
+
+// buildOSDXML renders a full OSD configuration wrapped in the given element
+// (tr2:OSD for CreateOSD/SetOSD). The element order follows the
+// tt:OSDConfiguration schema; text settings are sent only for text overlays
+// and the image only for image overlays.
+func buildOSDXML(elem string, osd OSDConfig) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<%s token="%s">`, elem, osd.Token)
+	fmt.Fprintf(&b, `<tt:VideoSourceConfigurationToken>%s</tt:VideoSourceConfigurationToken>`, osd.VideoSourceToken)
+	fmt.Fprintf(&b, `<tt:Type>%s</tt:Type>`, osd.Type)
+
+	positionType := osd.PositionType
+	if positionType == "" {
+		positionType = "UpperLeft"
+	}
+	fmt.Fprintf(&b, `<tt:Position><tt:Type>%s</tt:Type>`, positionType)
+	if positionType == "Custom" {
+		fmt.Fprintf(&b, `<tt:Pos x="%g" y="%g"/>`, osd.PositionX, osd.PositionY)
+	}
+	b.WriteString(`</tt:Position>`)
+
+	switch osd.Type {
+	case "Text":
+		textType := osd.TextType
+		if textType == "" {
+			textType = "Plain"
+		}
+		fmt.Fprintf(&b, `<tt:TextString><tt:Type>%s</tt:Type>`, textType)
+		if osd.DateFormat != "" && (textType == "Date" || textType == "DateAndTime") {
+			fmt.Fprintf(&b, `<tt:DateFormat>%s</tt:DateFormat>`, escapeXML(osd.DateFormat))
+		}
+		if osd.TimeFormat != "" && (textType == "Time" || textType == "DateAndTime") {
+			fmt.Fprintf(&b, `<tt:TimeFormat>%s</tt:TimeFormat>`, escapeXML(osd.TimeFormat))
+		}
+		if osd.FontSize > 0 {
+			fmt.Fprintf(&b, `<tt:FontSize>%d</tt:FontSize>`, osd.FontSize)
+		}
+		b.WriteString(osdColorXMLString("tt:FontColor", osd.FontColor))
+		b.WriteString(osdColorXMLString("tt:BackgroundColor", osd.BackgroundColor))
+		if textType == "Plain" {
+			fmt.Fprintf(&b, `<tt:PlainText>%s</tt:PlainText>`, escapeXML(osd.PlainText))
+		}
+		b.WriteString(`</tt:TextString>`)
+	case "Image":
+		fmt.Fprintf(&b, `<tt:Image><tt:ImgPath>%s</tt:ImgPath></tt:Image>`, escapeXML(osd.ImagePath))
+	}
+
+	fmt.Fprintf(&b, `</%s>`, elem)
+	return b.String()
+}
+
+func osdColorXMLString(elem string, c *OSDColor) string {
+	if c == nil {
+		return ""
+	}
+	colorspace := ""
+	if c.Colorspace != "" {
+		colorspace = fmt.Sprintf(` Colorspace="%s"`, escapeXML(c.Colorspace))
+	}
+	return fmt.Sprintf(`<%s Transparent="%d"><tt:Color X="%g" Y="%g" Z="%g"%s/></%s>`,
+		elem, c.Transparent, c.X, c.Y, c.Z, colorspace, elem)
+}
+
 // GetOSDs retrieves all OSD (On-Screen Display) configurations from the camera
 func (c *Client) GetOSDs(camera *Camera) ([]OSDConfig, error) {
-	media2URL := c.resolveMedia2URL(camera)
+	return c.GetOSDsContext(context.Background(), camera)
+}
+
+// GetOSDsContext is like GetOSDs but uses ctx for cancellation and deadlines.
+func (c *Client) GetOSDsContext(ctx context.Context, camera *Camera) ([]OSDConfig, error) {
+	media2URL := c.resolveMedia2URL(ctx, camera)
 
 	body := `<tr2:GetOSDs/>`
-	resp, err := c.sendSOAPRequest(media2URL,
+	resp, err := c.sendSOAPRequest(ctx, media2URL,
 		"http://www.onvif.org/ver20/media/wsdl/GetOSDs", body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get OSDs: %v", err)
@@ -33,14 +162,9 @@ func (c *Client) GetOSDs(camera *Camera) ([]OSDConfig, error) {
 	// Try structured parsing
 	// The ONVIF schema names the repeated response element "OSDs" (both Media1
 	// ver10 and Media2 ver20); accept the singular "OSD" too for leniency.
-	type osdEntry struct {
-		Token            string `xml:"token,attr"`
-		VideoSourceToken string `xml:"VideoSourceConfigurationToken"`
-		Type             string `xml:"Type"`
-	}
 	type OSDsResponse struct {
-		OSDs    []osdEntry `xml:"Body>GetOSDsResponse>OSDs"`
-		OSDsAlt []osdEntry `xml:"Body>GetOSDsResponse>OSD"`
+		OSDs    []osdXML `xml:"Body>GetOSDsResponse>OSDs"`
+		OSDsAlt []osdXML `xml:"Body>GetOSDsResponse>OSD"`
 	}
 
 	var osdsResp OSDsResponse
@@ -52,11 +176,7 @@ func (c *Client) GetOSDs(camera *Camera) ([]OSDConfig, error) {
 		if len(entries) > 0 {
 			var configs []OSDConfig
 			for _, osd := range entries {
-				configs = append(configs, OSDConfig{
-					Token:            osd.Token,
-					Type:             osd.Type,
-					VideoSourceToken: osd.VideoSourceToken,
-				})
+				configs = append(configs, osd.config())
 			}
 			return configs, nil
 		}
@@ -89,13 +209,19 @@ func (c *Client) GetOSDs(camera *Camera) ([]OSDConfig, error) {
 
 // DeleteOSD removes an OSD configuration by token
 func (c *Client) DeleteOSD(camera *Camera, osdToken string) error {
-	media2URL := c.resolveMedia2URL(camera)
+	return c.DeleteOSDContext(context.Background(), camera, osdToken)
+}
+
+// DeleteOSDContext is like DeleteOSD but uses ctx for cancellation and
+// deadlines.
+func (c *Client) DeleteOSDContext(ctx context.Context, camera *Camera, osdToken string) error {
+	media2URL := c.resolveMedia2URL(ctx, camera)
 
 	body := fmt.Sprintf(`<tr2:DeleteOSD>
 		<tr2:OSDToken>%s</tr2:OSDToken>
 	</tr2:DeleteOSD>`, osdToken)
 
-	resp, err := c.sendSOAPRequest(media2URL,
+	resp, err := c.sendSOAPRequest(ctx, media2URL,
 		"http://www.onvif.org/ver20/media/wsdl/DeleteOSD", body)
 	if err != nil {
 		return fmt.Errorf("failed to delete OSD: %v", err)
@@ -107,3 +233,142 @@ func (c *Client) DeleteOSD(camera *Camera, osdToken string) error {
 
 	return nil
 }
+
+// CreateOSD creates an OSD from osd (whose Token is ignored) and returns the
+// new OSD's token.
+func (c *Client) CreateOSD(camera *Camera, osd OSDConfig) (string, error) {
+	return c.CreateOSDContext(context.Background(), camera, osd)
+}
+
+// CreateOSDContext is like CreateOSD but uses ctx for cancellation and
+// deadlines.
+func (c *Client) CreateOSDContext(ctx context.Context, camera *Camera, osd OSDConfig) (string, error) {
+	media2URL := c.resolveMedia2URL(ctx, camera)
+
+	osd.Token = ""
+	body := fmt.Sprintf(`<tr2:CreateOSD>%s</tr2:CreateOSD>`, buildOSDXML("tr2:OSD", osd))
+	resp, err := c.sendSOAPRequest(ctx, media2URL,
+		"http://www.onvif.org/ver20/media/wsdl/CreateOSD", body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create OSD: %v", err)
+	}
+
+	if err := parseSOAPFault(resp); err != nil {
+		return "", err
+	}
+
+	var parsed struct {
+		Token string `xml:"Body>CreateOSDResponse>OSDToken"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse CreateOSD response: %v", err)
+	}
+	return strings.TrimSpace(parsed.Token), nil
+}
+
+// SetOSD replaces the OSD identified by osd.Token. Start from an entry of
+// GetOSDs so settings you do not change are kept.
+func (c *Client) SetOSD(camera *Camera, osd OSDConfig) error {
+	return c.SetOSDContext(context.Background(), camera, osd)
+}
+
+// SetOSDContext is like SetOSD but uses ctx for cancellation and deadlines.
+func (c *Client) SetOSDContext(ctx context.Context, camera *Camera, osd OSDConfig) error {
+	media2URL := c.resolveMedia2URL(ctx, camera)
+
+	body := fmt.Sprintf(`<tr2:SetOSD>%s</tr2:SetOSD>`, buildOSDXML("tr2:OSD", osd))
+	resp, err := c.sendSOAPRequest(ctx, media2URL,
+		"http://www.onvif.org/ver20/media/wsdl/SetOSD", body)
+	if err != nil {
+		return fmt.Errorf("failed to set OSD: %v", err)
+	}
+
+	if err := parseSOAPFault(resp); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetOSDOptions returns the OSD types, positions, text formats, font sizes
+// and colors accepted on a video source configuration.
+func (c *Client) GetOSDOptions(camera *Camera, videoSourceConfigToken string) (*OSDOptions, error) {
+	return c.GetOSDOptionsContext(context.Background(), camera, videoSourceConfigToken)
+}
+
+// GetOSDOptionsContext is like GetOSDOptions but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetOSDOptionsContext(ctx context.Context, camera *Camera, videoSourceConfigToken string) (*OSDOptions, error) {
+	media2URL := c.resolveMedia2URL(ctx, camera)
+
+	body := fmt.Sprintf(`<tr2:GetOSDOptions>
+		<tr2:ConfigurationToken>%s</tr2:ConfigurationToken>
+	</tr2:GetOSDOptions>`, videoSourceConfigToken)
+	resp, err := c.sendSOAPRequest(ctx, media2URL,
+		"http://www.onvif.org/ver20/media/wsdl/GetOSDOptions", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OSD options: %v", err)
+	}
+
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, err
+	}
+	return parseOSDOptions(resp)
+}
+
+// parseOSDOptions extracts the tt:OSDConfigurationOptions from a
+// GetOSDOptions response.
+func parseOSDOptions(resp []byte) (*OSDOptions, error) {
+	type color struct {
+		X          float64 `xml:"X,attr"`
+		Y          float64 `xml:"Y,attr"`
+		Z          float64 `xml:"Z,attr"`
+		Colorspace string  `xml:"Colorspace,attr"`
+	}
+	var parsed struct {
+		Options struct {
+			Maximum struct {
+				Total       int `xml:"Total,attr"`
+				Image       int `xml:"Image,attr"`
+				PlainText   int `xml:"PlainText,attr"`
+				Date        int `xml:"Date,attr"`
+				Time        int `xml:"Time,attr"`
+				DateAndTime int `xml:"DateAndTime,attr"`
+			} `xml:"MaximumNumberOfOSDs"`
+			Types         []string `xml:"Type"`
+			PositionTypes []string `xml:"PositionOption"`
+			Text          struct {
+				Types       []string  `xml:"Type"`
+				FontSize    *IntRange `xml:"FontSizeRange"`
+				DateFormats []string  `xml:"DateFormat"`
+				TimeFormats []string  `xml:"TimeFormat"`
+				FontColors  []color   `xml:"FontColor>Color>ColorList"`
+			} `xml:"TextOption"`
+			ImagePaths []string `xml:"ImageOption>ImagePath"`
+		} `xml:"Body>GetOSDOptionsResponse>OSDOptions"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse OSD options: %v", err)
+	}
+
+	o := parsed.Options
+	opts := &OSDOptions{
+		MaxTotal:       o.Maximum.Total,
+		MaxImage:       o.Maximum.Image,
+		MaxPlainText:   o.Maximum.PlainText,
+		MaxDate:        o.Maximum.Date,
+		MaxTime:        o.Maximum.Time,
+		MaxDateAndTime: o.Maximum.DateAndTime,
+		Types:          trimAll(o.Types),
+		PositionTypes:  trimAll(o.PositionTypes),
+		TextTypes:      trimAll(o.Text.Types),
+		DateFormats:    trimAll(o.Text.DateFormats),
+		TimeFormats:    trimAll(o.Text.TimeFormats),
+		FontSize:       o.Text.FontSize,
+		ImagePaths:     trimAll(o.ImagePaths),
+	}
+	for _, c := range o.Text.FontColors {
+		opts.FontColors = append(opts.FontColors, OSDColor{X: c.X, Y: c.Y, Z: c.Z, Colorspace: c.Colorspace})
+	}
+	return opts, nil
+}
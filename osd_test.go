@@ -0,0 +1,87 @@
+package onvif
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOSDManagement(t *testing.T) {
+	var gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		switch action {
+		case "http://www.onvif.org/ver20/media/wsdl/GetOSDs":
+			return `<tr2:GetOSDsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+<tr2:OSDs token="osd1"><tt:VideoSourceConfigurationToken>vsc0</tt:VideoSourceConfigurationToken><tt:Type>Text</tt:Type>
+<tt:Position><tt:Type>Custom</tt:Type><tt:Pos x="-0.9" y="0.8"/></tt:Position>
+<tt:TextString><tt:Type>DateAndTime</tt:Type><tt:DateFormat>yyyy-MM-dd</tt:DateFormat><tt:TimeFormat>HH:mm:ss</tt:TimeFormat>
+<tt:FontSize>32</tt:FontSize><tt:FontColor Transparent="0"><tt:Color X="235" Y="128" Z="128"/></tt:FontColor></tt:TextString>
+</tr2:OSDs>
+<tr2:OSDs token="osd2"><tt:VideoSourceConfigurationToken>vsc0</tt:VideoSourceConfigurationToken><tt:Type>Image</tt:Type>
+<tt:Position><tt:Type>LowerRight</tt:Type></tt:Position><tt:Image><tt:ImgPath>/logo.bmp</tt:ImgPath></tt:Image></tr2:OSDs>
+</tr2:GetOSDsResponse>`
+		case "http://www.onvif.org/ver20/media/wsdl/CreateOSD":
+			return `<tr2:CreateOSDResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"><tr2:OSDToken>osd3</tr2:OSDToken></tr2:CreateOSDResponse>`
+		case "http://www.onvif.org/ver20/media/wsdl/GetOSDOptions":
+			return `<tr2:GetOSDOptionsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"><tr2:OSDOptions>
+<tt:MaximumNumberOfOSDs Total="4" PlainText="2" Image="1"/>
+<tt:Type>Text</tt:Type><tt:Type>Image</tt:Type>
+<tt:PositionOption>UpperLeft</tt:PositionOption><tt:PositionOption>Custom</tt:PositionOption>
+<tt:TextOption><tt:Type>Plain</tt:Type><tt:Type>DateAndTime</tt:Type><tt:FontSizeRange><tt:Min>16</tt:Min><tt:Max>64</tt:Max></tt:FontSizeRange>
+<tt:DateFormat>yyyy-MM-dd</tt:DateFormat><tt:TimeFormat>HH:mm:ss</tt:TimeFormat>
+<tt:FontColor><tt:Color><tt:ColorList X="235" Y="128" Z="128"/><tt:ColorList X="16" Y="128" Z="128"/></tt:Color></tt:FontColor></tt:TextOption>
+</tr2:OSDOptions></tr2:GetOSDOptionsResponse>`
+		}
+		return `<tr2:Response xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"/>`
+	})
+	camera := &Camera{Media2URL: "http://192.0.2.10/onvif/media2"}
+
+	osds, err := c.GetOSDs(camera)
+	if err != nil {
+		t.Fatalf("GetOSDs() error = %v", err)
+	}
+	if len(osds) != 2 {
+		t.Fatalf("GetOSDs() = %+v", osds)
+	}
+	text := osds[0]
+	if text.Token != "osd1" || text.VideoSourceToken != "vsc0" || text.PositionType != "Custom" || text.PositionX != -0.9 ||
+		text.TextType != "DateAndTime" || text.TimeFormat != "HH:mm:ss" || text.FontSize != 32 ||
+		text.FontColor == nil || text.FontColor.X != 235 || text.BackgroundColor != nil {
+		t.Errorf("text OSD = %+v", text)
+	}
+	if osds[1].Type != "Image" || osds[1].ImagePath != "/logo.bmp" || osds[1].PositionType != "LowerRight" {
+		t.Errorf("image OSD = %+v", osds[1])
+	}
+
+	text.FontSize = 24
+	if err := c.SetOSD(camera, text); err != nil {
+		t.Fatalf("SetOSD() error = %v", err)
+	}
+	want := `<tr2:OSD token="osd1"><tt:VideoSourceConfigurationToken>vsc0</tt:VideoSourceConfigurationToken><tt:Type>Text</tt:Type>` +
+		`<tt:Position><tt:Type>Custom</tt:Type><tt:Pos x="-0.9" y="0.8"/></tt:Position>` +
+		`<tt:TextString><tt:Type>DateAndTime</tt:Type><tt:DateFormat>yyyy-MM-dd</tt:DateFormat><tt:TimeFormat>HH:mm:ss</tt:TimeFormat>` +
+		`<tt:FontSize>24</tt:FontSize><tt:FontColor Transparent="0"><tt:Color X="235" Y="128" Z="128"/></tt:FontColor></tt:TextString></tr2:OSD>`
+	if !strings.Contains(gotBody, want) {
+		t.Errorf("SetOSD body = %s\nwant %s", gotBody, want)
+	}
+
+	token, err := c.CreateOSD(camera, OSDConfig{Token: "ignored", Type: "Text", VideoSourceToken: "vsc0", PlainText: "Site <A>"})
+	if err != nil || token != "osd3" {
+		t.Fatalf("CreateOSD() = %q, %v", token, err)
+	}
+	for _, want := range []string{`<tr2:OSD token="">`, `<tt:Type>UpperLeft</tt:Type>`, `<tt:PlainText>Site &lt;A&gt;</tt:PlainText>`} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("CreateOSD body missing %q: %s", want, gotBody)
+		}
+	}
+
+	opts, err := c.GetOSDOptions(camera, "vsc0")
+	if err != nil {
+		t.Fatalf("GetOSDOptions() error = %v", err)
+	}
+	if opts.MaxTotal != 4 || opts.MaxPlainText != 2 || len(opts.Types) != 2 || len(opts.PositionTypes) != 2 ||
+		len(opts.TextTypes) != 2 || opts.FontSize == nil || *opts.FontSize != (IntRange{16, 64}) ||
+		len(opts.FontColors) != 2 || opts.FontColors[1].X != 16 {
+		t.Errorf("options = %+v", opts)
+	}
+}
@@ -0,0 +1,297 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// presetTourSpotXML is the parsed form of a tt:TourSpot / tt:CurrentTourSpot.
+type presetTourSpotXML struct {
+	PresetToken string        `xml:"PresetDetail>PresetToken"`
+	Home        bool          `xml:"PresetDetail>Home"`
+	Position    *ptzVectorXML `xml:"PresetDetail>PTZPosition"`
+	Speed       *ptzVectorXML `xml:"Speed"`
+	StayTime    string        `xml:"StayTime"`
+}
+
+func (s presetTourSpotXML) spot() TourSpot {
+	spot := TourSpot{PresetToken: s.PresetToken, Home: s.Home}
+	if s.Position != nil {
+		v := s.Position.setting()
+		spot.Position = &v
+	}
+	if s.Speed != nil {
+		v := s.Speed.setting()
+		spot.Speed = &v
+	}
+	spot.StayTime, _ = parseXSDuration(s.StayTime)
+	return spot
+}
+
+// presetTourXML is the parsed form of a tt:PresetTour.
+type presetTourXML struct {
+	Token             string             `xml:"token,attr"`
+	Name              string             `xml:"Name"`
+	State             string             `xml:"Status>State"`
+	CurrentSpot       *presetTourSpotXML `xml:"Status>CurrentTourSpot"`
+	AutoStart         bool               `xml:"AutoStart"`
+	StartingCondition struct {
+		RandomOrder       bool   `xml:"RandomPresetOrder,attr"`
+		RecurringTime     int    `xml:"RecurringTime"`
+		RecurringDuration string `xml:"RecurringDuration"`
+		Direction         string `xml:"Direction"`
+	} `xml:"StartingCondition"`
+	Spots []presetTourSpotXML `xml:"TourSpot"`
+}
+
+func (t presetTourXML) tour() PresetTour {
+	tour := PresetTour{
+		Token:         t.Token,
+		Name:          t.Name,
+		State:         t.State,
+		AutoStart:     t.AutoStart,
+		RecurringTime: t.StartingCondition.RecurringTime,
+		Direction:     t.StartingCondition.Direction,
+		RandomOrder:   t.StartingCondition.RandomOrder,
+	}
+	tour.RecurringDuration, _ = parseXSDuration(t.StartingCondition.RecurringDuration)
+	for _, s := range t.Spots {
+		tour.Spots = append(tour.Spots, s.spot())
+	}
+	if t.CurrentSpot != nil {
+		spot := t.CurrentSpot.spot()
+		tour.CurrentSpot = &spot
+	}
+	return tour
+}
+
+// buildPresetTourXML renders a tptz:PresetTour for ModifyPresetTour. The
+// elements follow the tt:PresetTour sequence, which strict cameras enforce.
+func buildPresetTourXML(tour PresetTour) string {
+	state := tour.State
+	if state == "" {
+		state = "Idle"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<tptz:PresetTour token="%s">`, tour.Token)
+	if tour.Name != "" {
+		fmt.Fprintf(&b, `<tt:Name>%s</tt:Name>`, escapeXML(tour.Name))
+	}
+	fmt.Fprintf(&b, `<tt:Status><tt:State>%s</tt:State></tt:Status>`, state)
+	fmt.Fprintf(&b, `<tt:AutoStart>%t</tt:AutoStart>`, tour.AutoStart)
+	fmt.Fprintf(&b, `<tt:StartingCondition RandomPresetOrder="%t">`, tour.RandomOrder)
+	if tour.RecurringTime > 0 {
+		fmt.Fprintf(&b, `<tt:RecurringTime>%d</tt:RecurringTime>`, tour.RecurringTime)
+	}
+	if tour.RecurringDuration > 0 {
+		fmt.Fprintf(&b, `<tt:RecurringDuration>%s</tt:RecurringDuration>`, formatXSDuration(tour.RecurringDuration))
+	}
+	if tour.Direction != "" {
+		fmt.Fprintf(&b, `<tt:Direction>%s</tt:Direction>`, tour.Direction)
+	}
+	b.WriteString(`</tt:StartingCondition>`)
+
+	for _, spot := range tour.Spots {
+		b.WriteString(`<tt:TourSpot><tt:PresetDetail>`)
+		switch {
+		case spot.PresetToken != "":
+			fmt.Fprintf(&b, `<tt:PresetToken>%s</tt:PresetToken>`, spot.PresetToken)
+		case spot.Home:
+			b.WriteString(`<tt:Home>true</tt:Home>`)
+		case spot.Position != nil:
+			panTilt, zoom := spot.Position.axes()
+			b.WriteString(buildPTZVectorXML("tt:PTZPosition", *spot.Position, panTilt, zoom))
+		}
+		b.WriteString(`</tt:PresetDetail>`)
+		if spot.Speed != nil {
+			panTilt, zoom := spot.Speed.axes()
+			b.WriteString(buildPTZVectorXML("tt:Speed", *spot.Speed, panTilt, zoom))
+		}
+		if spot.StayTime > 0 {
+			fmt.Fprintf(&b, `<tt:StayTime>%s</tt:StayTime>`, formatXSDuration(spot.StayTime))
+		}
+		b.WriteString(`</tt:TourSpot>`)
+	}
+	b.WriteString(`</tptz:PresetTour>`)
+	return b.String()
+}
+
+// GetPresetTours returns the preset tours defined for the given media profile.
+func (c *Client) GetPresetTours(camera *Camera, profileToken string) ([]PresetTour, error) {
+	return c.GetPresetToursContext(context.Background(), camera, profileToken)
+}
+
+// GetPresetToursContext is like GetPresetTours but uses ctx for cancellation
+// and deadlines.
+func (c *Client) GetPresetToursContext(ctx context.Context, camera *Camera, profileToken string) ([]PresetTour, error) {
+	body := fmt.Sprintf(`<tptz:GetPresetTours><tptz:ProfileToken>%s</tptz:ProfileToken></tptz:GetPresetTours>`, profileToken)
+	resp, err := c.ptzRequest(ctx, camera, "GetPresetTours", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Tours []presetTourXML `xml:"Body>GetPresetToursResponse>PresetTour"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse preset tours: %v", err)
+	}
+
+	tours := make([]PresetTour, 0, len(parsed.Tours))
+	for _, t := range parsed.Tours {
+		tours = append(tours, t.tour())
+	}
+	return tours, nil
+}
+
+// GetPresetTour returns a single preset tour.
+func (c *Client) GetPresetTour(camera *Camera, profileToken, tourToken string) (*PresetTour, error) {
+	return c.GetPresetTourContext(context.Background(), camera, profileToken, tourToken)
+}
+
+// GetPresetTourContext is like GetPresetTour but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetPresetTourContext(ctx context.Context, camera *Camera, profileToken, tourToken string) (*PresetTour, error) {
+	body := fmt.Sprintf(`<tptz:GetPresetTour><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PresetTourToken>%s</tptz:PresetTourToken></tptz:GetPresetTour>`,
+		profileToken, tourToken)
+	resp, err := c.ptzRequest(ctx, camera, "GetPresetTour", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Tour presetTourXML `xml:"Body>GetPresetTourResponse>PresetTour"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse preset tour: %v", err)
+	}
+	tour := parsed.Tour.tour()
+	return &tour, nil
+}
+
+// CreatePresetTour creates an empty preset tour and returns its token; fill it
+// in with ModifyPresetTour.
+func (c *Client) CreatePresetTour(camera *Camera, profileToken string) (string, error) {
+	return c.CreatePresetTourContext(context.Background(), camera, profileToken)
+}
+
+// CreatePresetTourContext is like CreatePresetTour but uses ctx for
+// cancellation and deadlines.
+func (c *Client) CreatePresetTourContext(ctx context.Context, camera *Camera, profileToken string) (string, error) {
+	body := fmt.Sprintf(`<tptz:CreatePresetTour><tptz:ProfileToken>%s</tptz:ProfileToken></tptz:CreatePresetTour>`, profileToken)
+	resp, err := c.ptzRequest(ctx, camera, "CreatePresetTour", body)
+	if err != nil {
+		return "", err
+	}
+
+	var parsed struct {
+		Token string `xml:"Body>CreatePresetTourResponse>PresetTourToken"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse CreatePresetTour response: %v", err)
+	}
+	return parsed.Token, nil
+}
+
+// ModifyPresetTour replaces the definition of the tour identified by
+// tour.Token.
+func (c *Client) ModifyPresetTour(camera *Camera, profileToken string, tour PresetTour) error {
+	return c.ModifyPresetTourContext(context.Background(), camera, profileToken, tour)
+}
+
+// ModifyPresetTourContext is like ModifyPresetTour but uses ctx for
+// cancellation and deadlines.
+func (c *Client) ModifyPresetTourContext(ctx context.Context, camera *Camera, profileToken string, tour PresetTour) error {
+	body := fmt.Sprintf(`<tptz:ModifyPresetTour><tptz:ProfileToken>%s</tptz:ProfileToken>%s</tptz:ModifyPresetTour>`,
+		profileToken, buildPresetTourXML(tour))
+	return c.ptzCall(ctx, camera, "ModifyPresetTour", body)
+}
+
+// OperatePresetTour starts, stops or pauses a preset tour.
+func (c *Client) OperatePresetTour(camera *Camera, profileToken, tourToken string, op PresetTourOperation) error {
+	return c.OperatePresetTourContext(context.Background(), camera, profileToken, tourToken, op)
+}
+
+// OperatePresetTourContext is like OperatePresetTour but uses ctx for
+// cancellation and deadlines.
+func (c *Client) OperatePresetTourContext(ctx context.Context, camera *Camera, profileToken, tourToken string, op PresetTourOperation) error {
+	body := fmt.Sprintf(`<tptz:OperatePresetTour><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PresetTourToken>%s</tptz:PresetTourToken><tptz:Operation>%s</tptz:Operation></tptz:OperatePresetTour>`,
+		profileToken, tourToken, op)
+	return c.ptzCall(ctx, camera, "OperatePresetTour", body)
+}
+
+// RemovePresetTour deletes a preset tour.
+func (c *Client) RemovePresetTour(camera *Camera, profileToken, tourToken string) error {
+	return c.RemovePresetTourContext(context.Background(), camera, profileToken, tourToken)
+}
+
+// RemovePresetTourContext is like RemovePresetTour but uses ctx for
+// cancellation and deadlines.
+func (c *Client) RemovePresetTourContext(ctx context.Context, camera *Camera, profileToken, tourToken string) error {
+	body := fmt.Sprintf(`<tptz:RemovePresetTour><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PresetTourToken>%s</tptz:PresetTourToken></tptz:RemovePresetTour>`,
+		profileToken, tourToken)
+	return c.ptzCall(ctx, camera, "RemovePresetTour", body)
+}
+
+// GetPresetTourOptions returns what the camera accepts in a preset tour. An
+// empty tourToken asks for the options of a new tour.
+func (c *Client) GetPresetTourOptions(camera *Camera, profileToken, tourToken string) (*PresetTourOptions, error) {
+	return c.GetPresetTourOptionsContext(context.Background(), camera, profileToken, tourToken)
+}
+
+// GetPresetTourOptionsContext is like GetPresetTourOptions but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetPresetTourOptionsContext(ctx context.Context, camera *Camera, profileToken, tourToken string) (*PresetTourOptions, error) {
+	tourXML := ""
+	if tourToken != "" {
+		tourXML = fmt.Sprintf(`<tptz:PresetTourToken>%s</tptz:PresetTourToken>`, tourToken)
+	}
+	body := fmt.Sprintf(`<tptz:GetPresetTourOptions><tptz:ProfileToken>%s</tptz:ProfileToken>%s</tptz:GetPresetTourOptions>`,
+		profileToken, tourXML)
+	resp, err := c.ptzRequest(ctx, camera, "GetPresetTourOptions", body)
+	if err != nil {
+		return nil, err
+	}
+
+	type durationRange struct {
+		Min string `xml:"Min"`
+		Max string `xml:"Max"`
+	}
+	var parsed struct {
+		Options struct {
+			AutoStart         bool `xml:"AutoStart"`
+			StartingCondition struct {
+				RecurringTime     IntRange      `xml:"RecurringTime"`
+				RecurringDuration durationRange `xml:"RecurringDuration"`
+				Directions        []string      `xml:"Direction"`
+			} `xml:"StartingCondition"`
+			TourSpot struct {
+				PresetTokens []string      `xml:"PresetDetail>PresetToken"`
+				Home         bool          `xml:"PresetDetail>Home"`
+				StayTime     durationRange `xml:"StayTime"`
+			} `xml:"TourSpot"`
+		} `xml:"Body>GetPresetTourOptionsResponse>Options"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse preset tour options: %v", err)
+	}
+
+	o := parsed.Options
+	toRange := func(r durationRange) DurationRange {
+		min, _ := parseXSDuration(r.Min)
+		max, _ := parseXSDuration(r.Max)
+		return DurationRange{Min: min, Max: max}
+	}
+	return &PresetTourOptions{
+		AutoStart:         o.AutoStart,
+		RecurringTime:     o.StartingCondition.RecurringTime,
+		RecurringDuration: toRange(o.StartingCondition.RecurringDuration),
+		Directions:        o.StartingCondition.Directions,
+		PresetTokens:      o.TourSpot.PresetTokens,
+		Home:              o.TourSpot.Home,
+		StayTime:          toRange(o.TourSpot.StayTime),
+	}, nil
+}
@@ -0,0 +1,101 @@
+package onvif
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresetTours(t *testing.T) {
+	var gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		switch action {
+		case "http://www.onvif.org/ver20/ptz/wsdl/GetPresetTours":
+			return `<tptz:GetPresetToursResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl">
+<tptz:PresetTour token="tour1"><tt:Name>Perimeter</tt:Name>
+<tt:Status><tt:State>Touring</tt:State><tt:CurrentTourSpot><tt:PresetDetail><tt:PresetToken>2</tt:PresetToken></tt:PresetDetail><tt:StayTime>PT10S</tt:StayTime></tt:CurrentTourSpot></tt:Status>
+<tt:AutoStart>true</tt:AutoStart>
+<tt:StartingCondition RandomPresetOrder="true"><tt:RecurringTime>3</tt:RecurringTime><tt:Direction>Backward</tt:Direction></tt:StartingCondition>
+<tt:TourSpot><tt:PresetDetail><tt:PresetToken>1</tt:PresetToken></tt:PresetDetail><tt:Speed><tt:PanTilt x="0.5" y="0.5"/></tt:Speed><tt:StayTime>PT5S</tt:StayTime></tt:TourSpot>
+<tt:TourSpot><tt:PresetDetail><tt:Home>true</tt:Home></tt:PresetDetail><tt:StayTime>PT1M</tt:StayTime></tt:TourSpot>
+</tptz:PresetTour></tptz:GetPresetToursResponse>`
+		case "http://www.onvif.org/ver20/ptz/wsdl/GetPresetTourOptions":
+			return `<tptz:GetPresetTourOptionsResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"><tptz:Options>
+<tt:AutoStart>true</tt:AutoStart>
+<tt:StartingCondition><tt:RecurringTime><tt:Min>1</tt:Min><tt:Max>10</tt:Max></tt:RecurringTime><tt:Direction>Forward</tt:Direction><tt:Direction>Backward</tt:Direction></tt:StartingCondition>
+<tt:TourSpot><tt:PresetDetail><tt:PresetToken>1</tt:PresetToken><tt:PresetToken>2</tt:PresetToken><tt:Home>true</tt:Home></tt:PresetDetail>
+<tt:StayTime><tt:Min>PT1S</tt:Min><tt:Max>PT1H</tt:Max></tt:StayTime></tt:TourSpot>
+</tptz:Options></tptz:GetPresetTourOptionsResponse>`
+		default:
+			return `<tptz:Response xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>`
+		}
+	})
+	camera := &Camera{PTZURL: "http://192.0.2.10/onvif/ptz"}
+
+	tours, err := c.GetPresetTours(camera, "prof")
+	if err != nil {
+		t.Fatalf("GetPresetTours() error = %v", err)
+	}
+	if len(tours) != 1 {
+		t.Fatalf("GetPresetTours() = %+v", tours)
+	}
+	tour := tours[0]
+	if tour.Token != "tour1" || tour.Name != "Perimeter" || tour.State != "Touring" || !tour.AutoStart ||
+		tour.RecurringTime != 3 || tour.Direction != "Backward" || !tour.RandomOrder {
+		t.Errorf("tour = %+v", tour)
+	}
+	if tour.CurrentSpot == nil || tour.CurrentSpot.PresetToken != "2" || tour.CurrentSpot.StayTime != 10*time.Second {
+		t.Errorf("CurrentSpot = %+v", tour.CurrentSpot)
+	}
+	if len(tour.Spots) != 2 || tour.Spots[0].PresetToken != "1" || tour.Spots[0].Speed == nil ||
+		tour.Spots[0].Speed.Pan != 0.5 || !tour.Spots[1].Home || tour.Spots[1].StayTime != time.Minute {
+		t.Errorf("Spots = %+v", tour.Spots)
+	}
+
+	if err := c.ModifyPresetTour(camera, "prof", tour); err != nil {
+		t.Fatalf("ModifyPresetTour() error = %v", err)
+	}
+	for _, want := range []string{
+		`<tptz:PresetTour token="tour1">`, `<tt:State>Touring</tt:State>`,
+		`<tt:StartingCondition RandomPresetOrder="true"><tt:RecurringTime>3</tt:RecurringTime><tt:Direction>Backward</tt:Direction>`,
+		`<tt:PresetToken>1</tt:PresetToken></tt:PresetDetail><tt:Speed>`, `<tt:StayTime>PT5S</tt:StayTime>`,
+		`<tt:Home>true</tt:Home>`, `<tt:StayTime>PT60S</tt:StayTime>`,
+	} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("ModifyPresetTour body missing %q:\n%s", want, gotBody)
+		}
+	}
+	if strings.Contains(gotBody, "CurrentTourSpot") {
+		t.Errorf("ModifyPresetTour body includes read-only status: %s", gotBody)
+	}
+	// The spot speed only has pan/tilt; a zero zoom speed would stall the zoom.
+	if !strings.Contains(gotBody, `<tt:Speed><tt:PanTilt xmlns:tt="http://www.onvif.org/ver10/schema" x="0.5" y="0.5"/></tt:Speed>`) {
+		t.Errorf("ModifyPresetTour body has wrong spot speed: %s", gotBody)
+	}
+
+	tour.Spots = []TourSpot{{Position: &PTZVector{Pan: 0.25}, Speed: &PTZVector{Zoom: 1}}}
+	if err := c.ModifyPresetTour(camera, "prof", tour); err != nil {
+		t.Fatalf("ModifyPresetTour() error = %v", err)
+	}
+	if !strings.Contains(gotBody, `<tt:PTZPosition><tt:PanTilt xmlns:tt="http://www.onvif.org/ver10/schema" x="0.25" y="0"/></tt:PTZPosition>`) ||
+		!strings.Contains(gotBody, `<tt:Speed><tt:Zoom xmlns:tt="http://www.onvif.org/ver10/schema" x="1"/></tt:Speed>`) {
+		t.Errorf("ModifyPresetTour body has wrong spot axes: %s", gotBody)
+	}
+
+	if err := c.OperatePresetTour(camera, "prof", "tour1", PresetTourPause); err != nil {
+		t.Fatalf("OperatePresetTour() error = %v", err)
+	}
+	if !strings.Contains(gotBody, "<tptz:Operation>Pause</tptz:Operation>") {
+		t.Errorf("OperatePresetTour body = %s", gotBody)
+	}
+
+	opts, err := c.GetPresetTourOptions(camera, "prof", "")
+	if err != nil {
+		t.Fatalf("GetPresetTourOptions() error = %v", err)
+	}
+	if !opts.AutoStart || opts.RecurringTime != (IntRange{1, 10}) || len(opts.Directions) != 2 ||
+		len(opts.PresetTokens) != 2 || !opts.Home || opts.StayTime != (DurationRange{time.Second, time.Hour}) {
+		t.Errorf("options = %+v", opts)
+	}
+}
@@ -1,9 +1,11 @@
 package onvif
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // ptzURLHeuristic derives a likely PTZ service URL from the device-service
@@ -18,9 +20,9 @@ func ptzURLHeuristic(address string) string {
 
 // resolvePTZURL returns the PTZ service URL, discovering it if needed and
 // falling back to a heuristic rewrite of the device-service address.
-func (c *Client) resolvePTZURL(camera *Camera) string {
+func (c *Client) resolvePTZURL(ctx context.Context, camera *Camera) string {
 	if camera.PTZURL == "" {
-		c.discoverServices(camera)
+		c.discoverServices(ctx, camera)
 	}
 	if camera.PTZURL != "" {
 		return camera.PTZURL
@@ -31,21 +33,31 @@ func (c *Client) resolvePTZURL(camera *Camera) string {
 // HasPTZ reports whether the camera advertises ONVIF PTZ support, querying
 // capabilities/services first if not yet known.
 func (c *Client) HasPTZ(camera *Camera) bool {
+	return c.HasPTZContext(context.Background(), camera)
+}
+
+// HasPTZContext is like HasPTZ but uses ctx for cancellation and deadlines.
+func (c *Client) HasPTZContext(ctx context.Context, camera *Camera) bool {
 	if !camera.PTZSupport && camera.PTZURL == "" {
-		c.discoverServices(camera)
+		c.discoverServices(ctx, camera)
 	}
 	return camera.PTZSupport || camera.PTZURL != ""
 }
 
 // PTZDiagnostics returns the raw GetNodes, GetConfigurations and GetStatus PTZ
-// responses. GetNodes' SupportedPTZSpaces reveal which move operations the
-// camera supports (Absolute / Relative / Continuous pan-tilt and zoom), which
-// determines how the tool should drive it.
+// responses, for troubleshooting. Use GetPTZNodes for the parsed
+// SupportedPTZSpaces that determine how the camera should be driven.
 func (c *Client) PTZDiagnostics(camera *Camera, profileToken string) string {
-	ptzURL := c.resolvePTZURL(camera)
+	return c.PTZDiagnosticsContext(context.Background(), camera, profileToken)
+}
+
+// PTZDiagnosticsContext is like PTZDiagnostics but uses ctx for cancellation
+// and deadlines.
+func (c *Client) PTZDiagnosticsContext(ctx context.Context, camera *Camera, profileToken string) string {
+	ptzURL := c.resolvePTZURL(ctx, camera)
 	var b strings.Builder
 	dump := func(action, body string) {
-		resp, err := c.sendSOAPRequest(ptzURL, "http://www.onvif.org/ver20/ptz/wsdl/"+action, body)
+		resp, err := c.sendSOAPRequest(ctx, ptzURL, "http://www.onvif.org/ver20/ptz/wsdl/"+action, body)
 		if err != nil {
 			fmt.Fprintf(&b, "=== PTZ %s error: %v ===\n\n", action, err)
 			return
@@ -58,10 +70,115 @@ func (c *Client) PTZDiagnostics(camera *Camera, profileToken string) string {
 	return b.String()
 }
 
+// ptzConfigurationXML is the parsed form of a tt:PTZConfiguration.
+type ptzConfigurationXML struct {
+	Token                         string        `xml:"token,attr"`
+	Name                          string        `xml:"Name"`
+	UseCount                      int           `xml:"UseCount"`
+	NodeToken                     string        `xml:"NodeToken"`
+	DefaultAbsolutePanTiltSpace   string        `xml:"DefaultAbsolutePantTiltPositionSpace"` // sic, per the schema
+	DefaultAbsoluteZoomSpace      string        `xml:"DefaultAbsoluteZoomPositionSpace"`
+	DefaultRelativePanTiltSpace   string        `xml:"DefaultRelativePanTiltTranslationSpace"`
+	DefaultRelativeZoomSpace      string        `xml:"DefaultRelativeZoomTranslationSpace"`
+	DefaultContinuousPanTiltSpace string        `xml:"DefaultContinuousPanTiltVelocitySpace"`
+	DefaultContinuousZoomSpace    string        `xml:"DefaultContinuousZoomVelocitySpace"`
+	DefaultSpeed                  *ptzVectorXML `xml:"DefaultPTZSpeed"`
+	DefaultTimeout                string        `xml:"DefaultPTZTimeout"`
+	PanTiltLimits                 *Space2D      `xml:"PanTiltLimits>Range"`
+	ZoomLimits                    *Space1D      `xml:"ZoomLimits>Range"`
+	EFlipMode                     string        `xml:"Extension>PTControlDirection>EFlip>Mode"`
+	ReverseMode                   string        `xml:"Extension>PTControlDirection>Reverse>Mode"`
+}
+
+func (p ptzConfigurationXML) config() PTZConfig {
+	cfg := PTZConfig{
+		Token:                         p.Token,
+		Name:                          p.Name,
+		UseCount:                      p.UseCount,
+		NodeToken:                     p.NodeToken,
+		DefaultAbsolutePanTiltSpace:   p.DefaultAbsolutePanTiltSpace,
+		DefaultAbsoluteZoomSpace:      p.DefaultAbsoluteZoomSpace,
+		DefaultRelativePanTiltSpace:   p.DefaultRelativePanTiltSpace,
+		DefaultRelativeZoomSpace:      p.DefaultRelativeZoomSpace,
+		DefaultContinuousPanTiltSpace: p.DefaultContinuousPanTiltSpace,
+		DefaultContinuousZoomSpace:    p.DefaultContinuousZoomSpace,
+		PanTiltLimits:                 p.PanTiltLimits,
+		ZoomLimits:                    p.ZoomLimits,
+		EFlipMode:                     p.EFlipMode,
+		ReverseMode:                   p.ReverseMode,
+	}
+	if p.DefaultSpeed != nil {
+		v := p.DefaultSpeed.setting()
+		cfg.DefaultSpeed = &v
+	}
+	cfg.DefaultTimeout, _ = parseXSDuration(p.DefaultTimeout)
+	return cfg
+}
+
+// buildPTZConfigurationXML renders a full tptz:PTZConfiguration for
+// SetConfiguration. The element order follows the tt:PTZConfiguration schema;
+// optional elements are omitted when unset.
+func buildPTZConfigurationXML(cfg PTZConfig) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<tptz:PTZConfiguration token="%s">`, cfg.Token)
+	fmt.Fprintf(&b, `<tt:Name>%s</tt:Name>`, escapeXML(cfg.Name))
+	fmt.Fprintf(&b, `<tt:UseCount>%d</tt:UseCount>`, cfg.UseCount)
+	fmt.Fprintf(&b, `<tt:NodeToken>%s</tt:NodeToken>`, cfg.NodeToken)
+	for _, e := range []struct{ elem, value string }{
+		{"DefaultAbsolutePantTiltPositionSpace", cfg.DefaultAbsolutePanTiltSpace},
+		{"DefaultAbsoluteZoomPositionSpace", cfg.DefaultAbsoluteZoomSpace},
+		{"DefaultRelativePanTiltTranslationSpace", cfg.DefaultRelativePanTiltSpace},
+		{"DefaultRelativeZoomTranslationSpace", cfg.DefaultRelativeZoomSpace},
+		{"DefaultContinuousPanTiltVelocitySpace", cfg.DefaultContinuousPanTiltSpace},
+		{"DefaultContinuousZoomVelocitySpace", cfg.DefaultContinuousZoomSpace},
+	} {
+		if e.value != "" {
+			fmt.Fprintf(&b, `<tt:%s>%s</tt:%s>`, e.elem, escapeXML(e.value), e.elem)
+		}
+	}
+	if cfg.DefaultSpeed != nil {
+		panTilt, zoom := cfg.DefaultSpeed.axes()
+		b.WriteString(buildPTZVectorXML("tt:DefaultPTZSpeed", *cfg.DefaultSpeed, panTilt, zoom))
+	}
+	if cfg.DefaultTimeout > 0 {
+		fmt.Fprintf(&b, `<tt:DefaultPTZTimeout>%s</tt:DefaultPTZTimeout>`, formatXSDuration(cfg.DefaultTimeout))
+	}
+	if l := cfg.PanTiltLimits; l != nil {
+		fmt.Fprintf(&b, `<tt:PanTiltLimits><tt:Range><tt:URI>%s</tt:URI>%s%s</tt:Range></tt:PanTiltLimits>`,
+			escapeXML(l.URI), floatRangeXML("tt:XRange", l.XRange), floatRangeXML("tt:YRange", l.YRange))
+	}
+	if l := cfg.ZoomLimits; l != nil {
+		fmt.Fprintf(&b, `<tt:ZoomLimits><tt:Range><tt:URI>%s</tt:URI>%s</tt:Range></tt:ZoomLimits>`,
+			escapeXML(l.URI), floatRangeXML("tt:XRange", l.XRange))
+	}
+	if cfg.EFlipMode != "" || cfg.ReverseMode != "" {
+		b.WriteString(`<tt:Extension><tt:PTControlDirection>`)
+		if cfg.EFlipMode != "" {
+			fmt.Fprintf(&b, `<tt:EFlip><tt:Mode>%s</tt:Mode></tt:EFlip>`, cfg.EFlipMode)
+		}
+		if cfg.ReverseMode != "" {
+			fmt.Fprintf(&b, `<tt:Reverse><tt:Mode>%s</tt:Mode></tt:Reverse>`, cfg.ReverseMode)
+		}
+		b.WriteString(`</tt:PTControlDirection></tt:Extension>`)
+	}
+	b.WriteString(`</tptz:PTZConfiguration>`)
+	return b.String()
+}
+
+func floatRangeXML(elem string, r FloatRange) string {
+	return fmt.Sprintf(`<%s><tt:Min>%g</tt:Min><tt:Max>%g</tt:Max></%s>`, elem, r.Min, r.Max, elem)
+}
+
 // GetPTZConfigurations returns the PTZ configurations the device exposes.
 func (c *Client) GetPTZConfigurations(camera *Camera) ([]PTZConfig, error) {
-	ptzURL := c.resolvePTZURL(camera)
-	resp, err := c.sendSOAPRequest(ptzURL,
+	return c.GetPTZConfigurationsContext(context.Background(), camera)
+}
+
+// GetPTZConfigurationsContext is like GetPTZConfigurations but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetPTZConfigurationsContext(ctx context.Context, camera *Camera) ([]PTZConfig, error) {
+	ptzURL := c.resolvePTZURL(ctx, camera)
+	resp, err := c.sendSOAPRequest(ctx, ptzURL,
 		"http://www.onvif.org/ver20/ptz/wsdl/GetConfigurations", `<tptz:GetConfigurations/>`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get PTZ configurations: %v", err)
@@ -71,11 +188,7 @@ func (c *Client) GetPTZConfigurations(camera *Camera) ([]PTZConfig, error) {
 	}
 
 	var parsed struct {
-		Configs []struct {
-			Token     string `xml:"token,attr"`
-			Name      string `xml:"Name"`
-			NodeToken string `xml:"NodeToken"`
-		} `xml:"Body>GetConfigurationsResponse>PTZConfiguration"`
+		Configs []ptzConfigurationXML `xml:"Body>GetConfigurationsResponse>PTZConfiguration"`
 	}
 	if err := xml.Unmarshal(resp, &parsed); err != nil {
 		return nil, fmt.Errorf("failed to parse PTZ configurations: %v", err)
@@ -83,17 +196,61 @@ func (c *Client) GetPTZConfigurations(camera *Camera) ([]PTZConfig, error) {
 
 	configs := make([]PTZConfig, 0, len(parsed.Configs))
 	for _, p := range parsed.Configs {
-		configs = append(configs, PTZConfig{Token: p.Token, Name: p.Name, NodeToken: p.NodeToken})
+		configs = append(configs, p.config())
 	}
 	return configs, nil
 }
 
+// GetPTZConfiguration returns a single PTZ configuration.
+func (c *Client) GetPTZConfiguration(camera *Camera, configToken string) (*PTZConfig, error) {
+	return c.GetPTZConfigurationContext(context.Background(), camera, configToken)
+}
+
+// GetPTZConfigurationContext is like GetPTZConfiguration but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetPTZConfigurationContext(ctx context.Context, camera *Camera, configToken string) (*PTZConfig, error) {
+	body := fmt.Sprintf(`<tptz:GetConfiguration><tptz:PTZConfigurationToken>%s</tptz:PTZConfigurationToken></tptz:GetConfiguration>`, configToken)
+	resp, err := c.ptzRequest(ctx, camera, "GetConfiguration", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Config ptzConfigurationXML `xml:"Body>GetConfigurationResponse>PTZConfiguration"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse PTZ configuration: %v", err)
+	}
+	cfg := parsed.Config.config()
+	return &cfg, nil
+}
+
+// SetPTZConfiguration replaces a PTZ configuration and persists it. Start from
+// the result of GetPTZConfiguration so fields you do not change are kept.
+func (c *Client) SetPTZConfiguration(camera *Camera, cfg PTZConfig) error {
+	return c.SetPTZConfigurationContext(context.Background(), camera, cfg)
+}
+
+// SetPTZConfigurationContext is like SetPTZConfiguration but uses ctx for
+// cancellation and deadlines.
+func (c *Client) SetPTZConfigurationContext(ctx context.Context, camera *Camera, cfg PTZConfig) error {
+	body := fmt.Sprintf(`<tptz:SetConfiguration>%s<tptz:ForcePersistence>true</tptz:ForcePersistence></tptz:SetConfiguration>`,
+		buildPTZConfigurationXML(cfg))
+	return c.ptzCall(ctx, camera, "SetConfiguration", body)
+}
+
 // GetPTZStatus returns the current PTZ position (including the zoom level) and
 // movement state for the given media profile.
 func (c *Client) GetPTZStatus(camera *Camera, profileToken string) (*PTZStatus, error) {
-	ptzURL := c.resolvePTZURL(camera)
+	return c.GetPTZStatusContext(context.Background(), camera, profileToken)
+}
+
+// GetPTZStatusContext is like GetPTZStatus but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetPTZStatusContext(ctx context.Context, camera *Camera, profileToken string) (*PTZStatus, error) {
+	ptzURL := c.resolvePTZURL(ctx, camera)
 	body := fmt.Sprintf(`<tptz:GetStatus><tptz:ProfileToken>%s</tptz:ProfileToken></tptz:GetStatus>`, profileToken)
-	resp, err := c.sendSOAPRequest(ptzURL,
+	resp, err := c.sendSOAPRequest(ctx, ptzURL,
 		"http://www.onvif.org/ver20/ptz/wsdl/GetStatus", body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get PTZ status: %v", err)
@@ -103,97 +260,435 @@ func (c *Client) GetPTZStatus(camera *Camera, profileToken string) (*PTZStatus,
 	}
 
 	var parsed struct {
-		PanTilt struct {
-			X float64 `xml:"x,attr"`
-			Y float64 `xml:"y,attr"`
-		} `xml:"Body>GetStatusResponse>PTZStatus>Position>PanTilt"`
-		Zoom struct {
-			X float64 `xml:"x,attr"`
-		} `xml:"Body>GetStatusResponse>PTZStatus>Position>Zoom"`
-		MoveState string `xml:"Body>GetStatusResponse>PTZStatus>MoveStatus>PanTilt"`
-		UTCTime   string `xml:"Body>GetStatusResponse>PTZStatus>UtcTime"`
+		Position  ptzVectorXML `xml:"Body>GetStatusResponse>PTZStatus>Position"`
+		MoveState string       `xml:"Body>GetStatusResponse>PTZStatus>MoveStatus>PanTilt"`
+		UTCTime   string       `xml:"Body>GetStatusResponse>PTZStatus>UtcTime"`
 	}
 	if err := xml.Unmarshal(resp, &parsed); err != nil {
 		return nil, fmt.Errorf("failed to parse PTZ status: %v", err)
 	}
 
 	return &PTZStatus{
-		Position:  PTZVector{Pan: parsed.PanTilt.X, Tilt: parsed.PanTilt.Y, Zoom: parsed.Zoom.X},
+		Position:  parsed.Position.vector(),
 		MoveState: parsed.MoveState,
 		UTCTime:   parsed.UTCTime,
 	}, nil
 }
 
+// ptzVectorXML is the parsed form of a tt:PTZVector (a Position, Speed, etc.).
+type ptzVectorXML struct {
+	PanTilt *struct {
+		X     float64 `xml:"x,attr"`
+		Y     float64 `xml:"y,attr"`
+		Space string  `xml:"space,attr"`
+	} `xml:"PanTilt"`
+	Zoom *struct {
+		X     float64 `xml:"x,attr"`
+		Space string  `xml:"space,attr"`
+	} `xml:"Zoom"`
+}
+
+func (v ptzVectorXML) vector() PTZVector {
+	var out PTZVector
+	if v.PanTilt != nil {
+		out.Pan, out.Tilt, out.PanTiltSpace = v.PanTilt.X, v.PanTilt.Y, v.PanTilt.Space
+	}
+	if v.Zoom != nil {
+		out.Zoom, out.ZoomSpace = v.Zoom.X, v.Zoom.Space
+	}
+	return out
+}
+
+// setting is like vector but also records which axes v contains, for a vector
+// that is written back as part of a setting (a tour spot, a default speed).
+func (v ptzVectorXML) setting() PTZVector {
+	out := v.vector()
+	out.hasPanTilt, out.hasZoom = v.PanTilt != nil, v.Zoom != nil
+	return out
+}
+
+// axes reports which axes a request built from v should carry: those the
+// device reported, and those with a non-zero component or an explicit
+// coordinate space.
+func (v PTZVector) axes() (panTilt, zoom bool) {
+	return v.hasPanTilt || v.Pan != 0 || v.Tilt != 0 || v.PanTiltSpace != "",
+		v.hasZoom || v.Zoom != 0 || v.ZoomSpace != ""
+}
+
 // buildPTZVectorXML renders a PanTilt/Zoom vector wrapped in the given element
 // (e.g. "tptz:Velocity", "tptz:Translation", "tptz:Position"). PanTilt and Zoom
-// are each emitted only when requested. This is the unit-test seam for the move
-// builders.
+// are each emitted only when requested, with a space attribute when the vector
+// names one. This is the unit-test seam for the move builders.
 func buildPTZVectorXML(elem string, v PTZVector, includePanTilt, includeZoom bool) string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "<%s>", elem)
 	if includePanTilt {
-		fmt.Fprintf(&b, `<tt:PanTilt xmlns:tt="http://www.onvif.org/ver10/schema" x="%g" y="%g"/>`, v.Pan, v.Tilt)
+		fmt.Fprintf(&b, `<tt:PanTilt xmlns:tt="http://www.onvif.org/ver10/schema" x="%g" y="%g"%s/>`, v.Pan, v.Tilt, spaceAttr(v.PanTiltSpace))
 	}
 	if includeZoom {
-		fmt.Fprintf(&b, `<tt:Zoom xmlns:tt="http://www.onvif.org/ver10/schema" x="%g"/>`, v.Zoom)
+		fmt.Fprintf(&b, `<tt:Zoom xmlns:tt="http://www.onvif.org/ver10/schema" x="%g"%s/>`, v.Zoom, spaceAttr(v.ZoomSpace))
 	}
 	fmt.Fprintf(&b, "</%s>", elem)
 	return b.String()
 }
 
+func spaceAttr(space string) string {
+	if space == "" {
+		return ""
+	}
+	return fmt.Sprintf(` space="%s"`, escapeXML(space))
+}
+
 // ContinuousMove starts a continuous PTZ move at the given velocity (each
 // component normalized -1..1). The caller must Stop. Pan/tilt and zoom are
 // included only when non-zero.
 func (c *Client) ContinuousMove(camera *Camera, profileToken string, v PTZVector) error {
-	body := fmt.Sprintf(`<tptz:ContinuousMove><tptz:ProfileToken>%s</tptz:ProfileToken>%s</tptz:ContinuousMove>`,
-		profileToken, buildPTZVectorXML("tptz:Velocity", v, v.Pan != 0 || v.Tilt != 0, v.Zoom != 0))
-	return c.ptzCall(camera, "ContinuousMove", body)
+	return c.ContinuousMoveWithTimeoutContext(context.Background(), camera, profileToken, v, 0)
+}
+
+// ContinuousMoveContext is like ContinuousMove but uses ctx for cancellation
+// and deadlines.
+func (c *Client) ContinuousMoveContext(ctx context.Context, camera *Camera, profileToken string, v PTZVector) error {
+	return c.ContinuousMoveWithTimeoutContext(ctx, camera, profileToken, v, 0)
+}
+
+// ContinuousMoveWithTimeout is like ContinuousMove but the camera stops by
+// itself after timeout, so a client that goes away cannot leave it moving. A
+// zero timeout uses the configuration's default.
+func (c *Client) ContinuousMoveWithTimeout(camera *Camera, profileToken string, v PTZVector, timeout time.Duration) error {
+	return c.ContinuousMoveWithTimeoutContext(context.Background(), camera, profileToken, v, timeout)
+}
+
+// ContinuousMoveWithTimeoutContext is like ContinuousMoveWithTimeout but uses
+// ctx for cancellation and deadlines.
+func (c *Client) ContinuousMoveWithTimeoutContext(ctx context.Context, camera *Camera, profileToken string, v PTZVector, timeout time.Duration) error {
+	timeoutXML := ""
+	if timeout > 0 {
+		timeoutXML = fmt.Sprintf(`<tptz:Timeout>%s</tptz:Timeout>`, formatXSDuration(timeout))
+	}
+	panTilt, zoom := v.axes()
+	body := fmt.Sprintf(`<tptz:ContinuousMove><tptz:ProfileToken>%s</tptz:ProfileToken>%s%s</tptz:ContinuousMove>`,
+		profileToken, buildPTZVectorXML("tptz:Velocity", v, panTilt, zoom), timeoutXML)
+	return c.ptzCall(ctx, camera, "ContinuousMove", body)
 }
 
 // RelativeMove performs a relative PTZ move by the given translation.
 func (c *Client) RelativeMove(camera *Camera, profileToken string, v PTZVector) error {
-	body := fmt.Sprintf(`<tptz:RelativeMove><tptz:ProfileToken>%s</tptz:ProfileToken>%s</tptz:RelativeMove>`,
-		profileToken, buildPTZVectorXML("tptz:Translation", v, v.Pan != 0 || v.Tilt != 0, v.Zoom != 0))
-	return c.ptzCall(camera, "RelativeMove", body)
+	return c.RelativeMoveWithSpeedContext(context.Background(), camera, profileToken, v, nil)
+}
+
+// RelativeMoveContext is like RelativeMove but uses ctx for cancellation and
+// deadlines.
+func (c *Client) RelativeMoveContext(ctx context.Context, camera *Camera, profileToken string, v PTZVector) error {
+	return c.RelativeMoveWithSpeedContext(ctx, camera, profileToken, v, nil)
+}
+
+// RelativeMoveWithSpeed is like RelativeMove but moves at the given speed
+// (nil or zero components use the camera default). Set the vectors' space
+// fields to translate in a space other than the generic one, e.g.
+// TranslationSpaceFov.
+func (c *Client) RelativeMoveWithSpeed(camera *Camera, profileToken string, v PTZVector, speed *PTZVector) error {
+	return c.RelativeMoveWithSpeedContext(context.Background(), camera, profileToken, v, speed)
+}
+
+// RelativeMoveWithSpeedContext is like RelativeMoveWithSpeed but uses ctx for
+// cancellation and deadlines.
+func (c *Client) RelativeMoveWithSpeedContext(ctx context.Context, camera *Camera, profileToken string, v PTZVector, speed *PTZVector) error {
+	panTilt, zoom := v.axes()
+	body := fmt.Sprintf(`<tptz:RelativeMove><tptz:ProfileToken>%s</tptz:ProfileToken>%s%s</tptz:RelativeMove>`,
+		profileToken, buildPTZVectorXML("tptz:Translation", v, panTilt, zoom), buildPTZSpeedXML(speed))
+	return c.ptzCall(ctx, camera, "RelativeMove", body)
 }
 
 // AbsoluteMove moves to an absolute PTZ position.
 func (c *Client) AbsoluteMove(camera *Camera, profileToken string, v PTZVector) error {
-	body := fmt.Sprintf(`<tptz:AbsoluteMove><tptz:ProfileToken>%s</tptz:ProfileToken>%s</tptz:AbsoluteMove>`,
-		profileToken, buildPTZVectorXML("tptz:Position", v, v.Pan != 0 || v.Tilt != 0, v.Zoom != 0))
-	return c.ptzCall(camera, "AbsoluteMove", body)
+	return c.AbsoluteMoveWithSpeedContext(context.Background(), camera, profileToken, v, nil)
+}
+
+// AbsoluteMoveContext is like AbsoluteMove but uses ctx for cancellation and
+// deadlines.
+func (c *Client) AbsoluteMoveContext(ctx context.Context, camera *Camera, profileToken string, v PTZVector) error {
+	return c.AbsoluteMoveWithSpeedContext(ctx, camera, profileToken, v, nil)
+}
+
+// AbsoluteMoveWithSpeed is like AbsoluteMove but moves at the given speed (nil
+// or zero components use the camera default). Set the vector's space fields to
+// address a non-generic position space, e.g. a degrees-based spherical space.
+func (c *Client) AbsoluteMoveWithSpeed(camera *Camera, profileToken string, v PTZVector, speed *PTZVector) error {
+	return c.AbsoluteMoveWithSpeedContext(context.Background(), camera, profileToken, v, speed)
+}
+
+// AbsoluteMoveWithSpeedContext is like AbsoluteMoveWithSpeed but uses ctx for
+// cancellation and deadlines.
+func (c *Client) AbsoluteMoveWithSpeedContext(ctx context.Context, camera *Camera, profileToken string, v PTZVector, speed *PTZVector) error {
+	panTilt, zoom := v.axes()
+	body := fmt.Sprintf(`<tptz:AbsoluteMove><tptz:ProfileToken>%s</tptz:ProfileToken>%s%s</tptz:AbsoluteMove>`,
+		profileToken, buildPTZVectorXML("tptz:Position", v, panTilt, zoom), buildPTZSpeedXML(speed))
+	return c.ptzCall(ctx, camera, "AbsoluteMove", body)
 }
 
 // Stop stops PTZ movement; panTilt and zoom select which axes to halt.
 func (c *Client) Stop(camera *Camera, profileToken string, panTilt, zoom bool) error {
+	return c.StopContext(context.Background(), camera, profileToken, panTilt, zoom)
+}
+
+// StopContext is like Stop but uses ctx for cancellation and deadlines.
+func (c *Client) StopContext(ctx context.Context, camera *Camera, profileToken string, panTilt, zoom bool) error {
 	body := fmt.Sprintf(`<tptz:Stop><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PanTilt>%t</tptz:PanTilt><tptz:Zoom>%t</tptz:Zoom></tptz:Stop>`,
 		profileToken, panTilt, zoom)
-	return c.ptzCall(camera, "Stop", body)
+	return c.ptzCall(ctx, camera, "Stop", body)
 }
 
-func (c *Client) ptzCall(camera *Camera, op, body string) error {
-	ptzURL := c.resolvePTZURL(camera)
-	resp, err := c.sendSOAPRequest(ptzURL, "http://www.onvif.org/ver20/ptz/wsdl/"+op, body)
+func (c *Client) ptzCall(ctx context.Context, camera *Camera, op, body string) error {
+	_, err := c.ptzRequest(ctx, camera, op, body)
+	return err
+}
+
+// ptzRequest is ptzCall for operations whose response carries data; the raw
+// response is returned once it is known not to be a fault.
+func (c *Client) ptzRequest(ctx context.Context, camera *Camera, op, body string) ([]byte, error) {
+	ptzURL := c.resolvePTZURL(ctx, camera)
+	resp, err := c.sendSOAPRequest(ctx, ptzURL, "http://www.onvif.org/ver20/ptz/wsdl/"+op, body)
 	if err != nil {
-		return fmt.Errorf("PTZ %s failed: %v", op, err)
+		return nil, fmt.Errorf("PTZ %s failed: %v", op, err)
 	}
 	if err := parseSOAPFault(resp); err != nil {
-		return fmt.Errorf("PTZ %s failed: %w", op, err)
+		return nil, fmt.Errorf("PTZ %s failed: %w", op, err)
 	}
-	return nil
+	return resp, nil
 }
 
 // ZoomIn starts a continuous zoom in at the given speed (0..1); caller ZoomStops.
 func (c *Client) ZoomIn(camera *Camera, profileToken string, speed float64) error {
-	return c.ContinuousMove(camera, profileToken, PTZVector{Zoom: speed})
+	return c.ZoomInContext(context.Background(), camera, profileToken, speed)
+}
+
+// ZoomInContext is like ZoomIn but uses ctx for cancellation and deadlines.
+func (c *Client) ZoomInContext(ctx context.Context, camera *Camera, profileToken string, speed float64) error {
+	return c.ContinuousMoveContext(ctx, camera, profileToken, PTZVector{Zoom: speed})
 }
 
 // ZoomStop stops zoom movement.
 func (c *Client) ZoomStop(camera *Camera, profileToken string) error {
-	return c.Stop(camera, profileToken, false, true)
+	return c.ZoomStopContext(context.Background(), camera, profileToken)
+}
+
+// ZoomStopContext is like ZoomStop but uses ctx for cancellation and deadlines.
+func (c *Client) ZoomStopContext(ctx context.Context, camera *Camera, profileToken string) error {
+	return c.StopContext(ctx, camera, profileToken, false, true)
 }
 
 // ZoomTo moves to an absolute zoom level (0..1).
 func (c *Client) ZoomTo(camera *Camera, profileToken string, level float64) error {
-	return c.AbsoluteMove(camera, profileToken, PTZVector{Zoom: level})
+	return c.ZoomToContext(context.Background(), camera, profileToken, level)
+}
+
+// ZoomToContext is like ZoomTo but uses ctx for cancellation and deadlines.
+func (c *Client) ZoomToContext(ctx context.Context, camera *Camera, profileToken string, level float64) error {
+	return c.AbsoluteMoveContext(ctx, camera, profileToken, PTZVector{Zoom: level})
+}
+
+// GetPresets returns the PTZ presets stored for the given media profile.
+func (c *Client) GetPresets(camera *Camera, profileToken string) ([]PTZPreset, error) {
+	return c.GetPresetsContext(context.Background(), camera, profileToken)
+}
+
+// GetPresetsContext is like GetPresets but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetPresetsContext(ctx context.Context, camera *Camera, profileToken string) ([]PTZPreset, error) {
+	ptzURL := c.resolvePTZURL(ctx, camera)
+	body := fmt.Sprintf(`<tptz:GetPresets><tptz:ProfileToken>%s</tptz:ProfileToken></tptz:GetPresets>`, profileToken)
+	resp, err := c.sendSOAPRequest(ctx, ptzURL,
+		"http://www.onvif.org/ver20/ptz/wsdl/GetPresets", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PTZ presets: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Presets []struct {
+			Token    string       `xml:"token,attr"`
+			Name     string       `xml:"Name"`
+			Position ptzVectorXML `xml:"PTZPosition"`
+		} `xml:"Body>GetPresetsResponse>Preset"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse PTZ presets: %v", err)
+	}
+
+	presets := make([]PTZPreset, 0, len(parsed.Presets))
+	for _, p := range parsed.Presets {
+		presets = append(presets, PTZPreset{Token: p.Token, Name: p.Name, Position: p.Position.vector()})
+	}
+	return presets, nil
+}
+
+// SetPreset stores the current PTZ position as a preset. An empty presetToken
+// creates a new preset; otherwise the existing preset is overwritten. The
+// preset's token is returned.
+func (c *Client) SetPreset(camera *Camera, profileToken, presetToken, name string) (string, error) {
+	return c.SetPresetContext(context.Background(), camera, profileToken, presetToken, name)
+}
+
+// SetPresetContext is like SetPreset but uses ctx for cancellation and
+// deadlines.
+func (c *Client) SetPresetContext(ctx context.Context, camera *Camera, profileToken, presetToken, name string) (string, error) {
+	ptzURL := c.resolvePTZURL(ctx, camera)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<tptz:SetPreset><tptz:ProfileToken>%s</tptz:ProfileToken>`, profileToken)
+	if name != "" {
+		fmt.Fprintf(&b, `<tptz:PresetName>%s</tptz:PresetName>`, escapeXML(name))
+	}
+	if presetToken != "" {
+		fmt.Fprintf(&b, `<tptz:PresetToken>%s</tptz:PresetToken>`, presetToken)
+	}
+	b.WriteString(`</tptz:SetPreset>`)
+
+	resp, err := c.sendSOAPRequest(ctx, ptzURL,
+		"http://www.onvif.org/ver20/ptz/wsdl/SetPreset", b.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to set PTZ preset: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return "", err
+	}
+
+	var parsed struct {
+		PresetToken string `xml:"Body>SetPresetResponse>PresetToken"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse SetPreset response: %v", err)
+	}
+	if parsed.PresetToken == "" {
+		return presetToken, nil
+	}
+	return parsed.PresetToken, nil
+}
+
+// GotoPreset moves to a stored preset. speed is optional: nil (or a zero
+// component) lets the camera use its default speed.
+func (c *Client) GotoPreset(camera *Camera, profileToken, presetToken string, speed *PTZVector) error {
+	return c.GotoPresetContext(context.Background(), camera, profileToken, presetToken, speed)
+}
+
+// GotoPresetContext is like GotoPreset but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GotoPresetContext(ctx context.Context, camera *Camera, profileToken, presetToken string, speed *PTZVector) error {
+	body := fmt.Sprintf(`<tptz:GotoPreset><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PresetToken>%s</tptz:PresetToken>%s</tptz:GotoPreset>`,
+		profileToken, presetToken, buildPTZSpeedXML(speed))
+	return c.ptzCall(ctx, camera, "GotoPreset", body)
+}
+
+// RemovePreset deletes a stored preset.
+func (c *Client) RemovePreset(camera *Camera, profileToken, presetToken string) error {
+	return c.RemovePresetContext(context.Background(), camera, profileToken, presetToken)
+}
+
+// RemovePresetContext is like RemovePreset but uses ctx for cancellation and
+// deadlines.
+func (c *Client) RemovePresetContext(ctx context.Context, camera *Camera, profileToken, presetToken string) error {
+	body := fmt.Sprintf(`<tptz:RemovePreset><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:PresetToken>%s</tptz:PresetToken></tptz:RemovePreset>`,
+		profileToken, presetToken)
+	return c.ptzCall(ctx, camera, "RemovePreset", body)
+}
+
+// GotoHomePosition moves to the home position. speed is optional, as for
+// GotoPreset.
+func (c *Client) GotoHomePosition(camera *Camera, profileToken string, speed *PTZVector) error {
+	return c.GotoHomePositionContext(context.Background(), camera, profileToken, speed)
+}
+
+// GotoHomePositionContext is like GotoHomePosition but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GotoHomePositionContext(ctx context.Context, camera *Camera, profileToken string, speed *PTZVector) error {
+	body := fmt.Sprintf(`<tptz:GotoHomePosition><tptz:ProfileToken>%s</tptz:ProfileToken>%s</tptz:GotoHomePosition>`,
+		profileToken, buildPTZSpeedXML(speed))
+	return c.ptzCall(ctx, camera, "GotoHomePosition", body)
+}
+
+// SetHomePosition stores the current position as the home position. Cameras
+// with a fixed home position answer with a fault.
+func (c *Client) SetHomePosition(camera *Camera, profileToken string) error {
+	return c.SetHomePositionContext(context.Background(), camera, profileToken)
+}
+
+// SetHomePositionContext is like SetHomePosition but uses ctx for cancellation
+// and deadlines.
+func (c *Client) SetHomePositionContext(ctx context.Context, camera *Camera, profileToken string) error {
+	body := fmt.Sprintf(`<tptz:SetHomePosition><tptz:ProfileToken>%s</tptz:ProfileToken></tptz:SetHomePosition>`, profileToken)
+	return c.ptzCall(ctx, camera, "SetHomePosition", body)
+}
+
+// buildPTZSpeedXML renders an optional tptz:Speed element. Zero components
+// without a space are omitted so the camera falls back to its default speed
+// for that axis.
+func buildPTZSpeedXML(speed *PTZVector) string {
+	if speed == nil {
+		return ""
+	}
+	panTilt, zoom := speed.axes()
+	if !panTilt && !zoom {
+		return ""
+	}
+	return buildPTZVectorXML("tptz:Speed", *speed, panTilt, zoom)
+}
+
+// GetAuxiliaryCommands returns the auxiliary commands (e.g. "tt:Wiper|On",
+// "tt:IRLamp|Auto") advertised by the PTZ node behind the given media profile.
+func (c *Client) GetAuxiliaryCommands(camera *Camera, profileToken string) ([]string, error) {
+	return c.GetAuxiliaryCommandsContext(context.Background(), camera, profileToken)
+}
+
+// GetAuxiliaryCommandsContext is like GetAuxiliaryCommands but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetAuxiliaryCommandsContext(ctx context.Context, camera *Camera, profileToken string) ([]string, error) {
+	profiles, err := c.getProfiles(ctx, camera)
+	if err != nil {
+		return nil, err
+	}
+	nodeToken := ""
+	for _, p := range profiles {
+		if p.Token == profileToken {
+			nodeToken = p.PTZ.NodeToken
+			break
+		}
+	}
+	if nodeToken == "" {
+		return nil, fmt.Errorf("profile %q has no PTZ configuration", profileToken)
+	}
+
+	node, err := c.GetPTZNodeContext(ctx, camera, nodeToken)
+	if err != nil {
+		return nil, err
+	}
+	return node.AuxiliaryCommands, nil
+}
+
+// SendAuxiliaryCommand sends an auxiliary command such as "tt:Wiper|On" to
+// the PTZ node and returns the camera's response text, if any. Use
+// GetAuxiliaryCommands for the commands the node accepts.
+func (c *Client) SendAuxiliaryCommand(camera *Camera, profileToken, command string) (string, error) {
+	return c.SendAuxiliaryCommandContext(context.Background(), camera, profileToken, command)
+}
+
+// SendAuxiliaryCommandContext is like SendAuxiliaryCommand but uses ctx for
+// cancellation and deadlines.
+func (c *Client) SendAuxiliaryCommandContext(ctx context.Context, camera *Camera, profileToken, command string) (string, error) {
+	body := fmt.Sprintf(`<tptz:SendAuxiliaryCommand><tptz:ProfileToken>%s</tptz:ProfileToken><tptz:AuxiliaryData>%s</tptz:AuxiliaryData></tptz:SendAuxiliaryCommand>`,
+		profileToken, escapeXML(command))
+	resp, err := c.ptzRequest(ctx, camera, "SendAuxiliaryCommand", body)
+	if err != nil {
+		return "", err
+	}
+
+	var parsed struct {
+		Response string `xml:"Body>SendAuxiliaryCommandResponse>AuxiliaryResponse"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse SendAuxiliaryCommand response: %v", err)
+	}
+	return strings.TrimSpace(parsed.Response), nil
 }
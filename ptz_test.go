@@ -3,6 +3,7 @@ package onvif
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestBuildPTZVectorXML(t *testing.T) {
@@ -26,6 +27,12 @@ func TestBuildPTZVectorXML(t *testing.T) {
 			wantContains:    []string{"<tptz:Translation>", `<tt:Zoom xmlns:tt="http://www.onvif.org/ver10/schema" x="0.1"/>`, "</tptz:Translation>"},
 			wantNotContains: []string{"tt:PanTilt"},
 		},
+		{
+			name: "position with spaces",
+			elem: "tptz:Position", v: PTZVector{Pan: 90, PanTiltSpace: PanTiltSphericalPositionSpaceDegrees, Zoom: 0.5}, panTilt: true, zoom: true,
+			wantContains:    []string{`x="90" y="0" space="http://www.onvif.org/ver10/tptz/PanTiltSpaces/SphericalPositionSpaceDegrees"/>`, `x="0.5"/>`},
+			wantNotContains: []string{`ZoomSpaces`},
+		},
 		{
 			name: "position both",
 			elem: "tptz:Position", v: PTZVector{Pan: 1, Tilt: 0, Zoom: 0.5}, panTilt: true, zoom: true,
@@ -50,3 +57,198 @@ func TestBuildPTZVectorXML(t *testing.T) {
 		})
 	}
 }
+
+func TestPTZPresets(t *testing.T) {
+	var gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		switch action {
+		case "http://www.onvif.org/ver20/ptz/wsdl/GetPresets":
+			return `<tptz:GetPresetsResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl">
+<tptz:Preset token="1"><tt:Name>Door</tt:Name><tt:PTZPosition>
+<tt:PanTilt x="0.25" y="-0.5"/><tt:Zoom x="0.1"/></tt:PTZPosition></tptz:Preset>
+<tptz:Preset token="2"><tt:Name>Gate &amp; Yard</tt:Name></tptz:Preset>
+</tptz:GetPresetsResponse>`
+		case "http://www.onvif.org/ver20/ptz/wsdl/SetPreset":
+			return `<tptz:SetPresetResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"><tptz:PresetToken>7</tptz:PresetToken></tptz:SetPresetResponse>`
+		default:
+			return `<tptz:Response xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>`
+		}
+	})
+	camera := &Camera{PTZURL: "http://192.0.2.10/onvif/ptz"}
+
+	presets, err := c.GetPresets(camera, "prof")
+	if err != nil {
+		t.Fatalf("GetPresets() error = %v", err)
+	}
+	want := []PTZPreset{
+		{Token: "1", Name: "Door", Position: PTZVector{Pan: 0.25, Tilt: -0.5, Zoom: 0.1}},
+		{Token: "2", Name: "Gate & Yard"},
+	}
+	if len(presets) != len(want) {
+		t.Fatalf("GetPresets() = %+v", presets)
+	}
+	for i := range want {
+		if presets[i] != want[i] {
+			t.Errorf("preset[%d] = %+v, want %+v", i, presets[i], want[i])
+		}
+	}
+
+	token, err := c.SetPreset(camera, "prof", "", "Lobby <east>")
+	if err != nil || token != "7" {
+		t.Fatalf("SetPreset() = %q, %v", token, err)
+	}
+	if !strings.Contains(gotBody, "Lobby &lt;east&gt;") || strings.Contains(gotBody, "PresetToken") {
+		t.Errorf("SetPreset body = %s", gotBody)
+	}
+
+	if err := c.GotoPreset(camera, "prof", "7", &PTZVector{Zoom: 0.5}); err != nil {
+		t.Fatalf("GotoPreset() error = %v", err)
+	}
+	if !strings.Contains(gotBody, "<tptz:Speed>") || !strings.Contains(gotBody, "tt:Zoom") || strings.Contains(gotBody, "tt:PanTilt") {
+		t.Errorf("GotoPreset body = %s", gotBody)
+	}
+	if err := c.GotoPreset(camera, "prof", "7", nil); err != nil || strings.Contains(gotBody, "Speed") {
+		t.Errorf("GotoPreset(nil speed) err = %v, body = %s", err, gotBody)
+	}
+}
+
+func TestPTZMoveOptions(t *testing.T) {
+	var gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		return `<tptz:Response xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>`
+	})
+	camera := &Camera{PTZURL: "http://192.0.2.10/onvif/ptz"}
+
+	if err := c.ContinuousMoveWithTimeout(camera, "prof", PTZVector{Pan: 0.5}, 1500*time.Millisecond); err != nil {
+		t.Fatalf("ContinuousMoveWithTimeout() error = %v", err)
+	}
+	if !strings.Contains(gotBody, "</tptz:Velocity><tptz:Timeout>PT1.5S</tptz:Timeout>") {
+		t.Errorf("ContinuousMoveWithTimeout body = %s", gotBody)
+	}
+
+	// A zero pan/tilt in an explicit space is still sent.
+	err := c.RelativeMoveWithSpeed(camera, "prof",
+		PTZVector{PanTiltSpace: PanTiltTranslationSpaceFov}, &PTZVector{Pan: 1, Tilt: 1})
+	if err != nil {
+		t.Fatalf("RelativeMoveWithSpeed() error = %v", err)
+	}
+	for _, want := range []string{`space="` + PanTiltTranslationSpaceFov + `"`, "</tptz:Translation><tptz:Speed>"} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("RelativeMoveWithSpeed body missing %q: %s", want, gotBody)
+		}
+	}
+
+	if err := c.AbsoluteMove(camera, "prof", PTZVector{Zoom: 0.3}); err != nil {
+		t.Fatalf("AbsoluteMove() error = %v", err)
+	}
+	if strings.Contains(gotBody, "Speed") || strings.Contains(gotBody, "PanTilt") {
+		t.Errorf("AbsoluteMove body = %s", gotBody)
+	}
+}
+
+func TestPTZConfigurationRoundTrip(t *testing.T) {
+	var gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		switch action {
+		case "http://www.onvif.org/ver20/ptz/wsdl/GetConfiguration":
+			return `<tptz:GetConfigurationResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl">
+<tptz:PTZConfiguration token="ptz0"><tt:Name>PTZ &amp; Co</tt:Name><tt:UseCount>2</tt:UseCount><tt:NodeToken>node0</tt:NodeToken>
+<tt:DefaultAbsolutePantTiltPositionSpace>http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace</tt:DefaultAbsolutePantTiltPositionSpace>
+<tt:DefaultContinuousZoomVelocitySpace>http://www.onvif.org/ver10/tptz/ZoomSpaces/VelocityGenericSpace</tt:DefaultContinuousZoomVelocitySpace>
+<tt:DefaultPTZSpeed><tt:PanTilt x="0.5" y="0.5"/><tt:Zoom x="1"/></tt:DefaultPTZSpeed>
+<tt:DefaultPTZTimeout>PT5S</tt:DefaultPTZTimeout>
+<tt:PanTiltLimits><tt:Range><tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace</tt:URI>
+<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange><tt:YRange><tt:Min>-1</tt:Min><tt:Max>0</tt:Max></tt:YRange></tt:Range></tt:PanTiltLimits>
+<tt:Extension><tt:PTControlDirection><tt:EFlip><tt:Mode>OFF</tt:Mode></tt:EFlip><tt:Reverse><tt:Mode>AUTO</tt:Mode></tt:Reverse></tt:PTControlDirection></tt:Extension>
+</tptz:PTZConfiguration></tptz:GetConfigurationResponse>`
+		case "http://www.onvif.org/ver10/media/wsdl/GetProfiles":
+			return `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+<trt:Profiles token="prof"><tt:Name>main</tt:Name><tt:PTZConfiguration token="ptz0"><tt:NodeToken>node0</tt:NodeToken></tt:PTZConfiguration></trt:Profiles>
+</trt:GetProfilesResponse>`
+		case "http://www.onvif.org/ver20/ptz/wsdl/GetNode":
+			return `<tptz:GetNodeResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"><tptz:PTZNode token="node0">
+<tt:AuxiliaryCommands>tt:Wiper|On</tt:AuxiliaryCommands></tptz:PTZNode></tptz:GetNodeResponse>`
+		case "http://www.onvif.org/ver20/ptz/wsdl/SendAuxiliaryCommand":
+			return `<tptz:SendAuxiliaryCommandResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"><tptz:AuxiliaryResponse> ok </tptz:AuxiliaryResponse></tptz:SendAuxiliaryCommandResponse>`
+		default:
+			return `<tptz:Response xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>`
+		}
+	})
+	camera := &Camera{PTZURL: "http://192.0.2.10/onvif/ptz", MediaURL: "http://192.0.2.10/onvif/media"}
+
+	cfg, err := c.GetPTZConfiguration(camera, "ptz0")
+	if err != nil {
+		t.Fatalf("GetPTZConfiguration() error = %v", err)
+	}
+	if cfg.Name != "PTZ & Co" || cfg.UseCount != 2 || cfg.NodeToken != "node0" ||
+		cfg.DefaultAbsolutePanTiltSpace != PanTiltPositionGenericSpace || cfg.DefaultContinuousZoomSpace != ZoomVelocityGenericSpace ||
+		cfg.DefaultSpeed == nil || cfg.DefaultSpeed.Zoom != 1 || cfg.DefaultTimeout != 5*time.Second ||
+		cfg.PanTiltLimits == nil || cfg.PanTiltLimits.YRange != (FloatRange{-1, 0}) || cfg.ZoomLimits != nil ||
+		cfg.EFlipMode != "OFF" || cfg.ReverseMode != "AUTO" {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	cfg.DefaultTimeout = 10 * time.Second
+	if err := c.SetPTZConfiguration(camera, *cfg); err != nil {
+		t.Fatalf("SetPTZConfiguration() error = %v", err)
+	}
+	for _, want := range []string{
+		`<tptz:PTZConfiguration token="ptz0"><tt:Name>PTZ &amp; Co</tt:Name><tt:UseCount>2</tt:UseCount><tt:NodeToken>node0</tt:NodeToken>`,
+		`<tt:DefaultAbsolutePantTiltPositionSpace>` + PanTiltPositionGenericSpace,
+		`<tt:DefaultPTZTimeout>PT10S</tt:DefaultPTZTimeout>`,
+		`<tt:YRange><tt:Min>-1</tt:Min><tt:Max>0</tt:Max></tt:YRange>`,
+		`<tt:Reverse><tt:Mode>AUTO</tt:Mode></tt:Reverse>`,
+		`<tptz:ForcePersistence>true</tptz:ForcePersistence>`,
+	} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("SetPTZConfiguration body missing %q:\n%s", want, gotBody)
+		}
+	}
+	if strings.Contains(gotBody, "ZoomLimits") {
+		t.Errorf("SetPTZConfiguration body has unset ZoomLimits: %s", gotBody)
+	}
+
+	commands, err := c.GetAuxiliaryCommands(camera, "prof")
+	if err != nil || len(commands) != 1 || commands[0] != "tt:Wiper|On" {
+		t.Fatalf("GetAuxiliaryCommands() = %v, %v", commands, err)
+	}
+	reply, err := c.SendAuxiliaryCommand(camera, "prof", commands[0])
+	if err != nil || reply != "ok" {
+		t.Fatalf("SendAuxiliaryCommand() = %q, %v", reply, err)
+	}
+	if !strings.Contains(gotBody, "<tptz:AuxiliaryData>tt:Wiper|On</tptz:AuxiliaryData>") {
+		t.Errorf("SendAuxiliaryCommand body = %s", gotBody)
+	}
+}
+
+func TestPTZConfigurationPanTiltOnly(t *testing.T) {
+	var gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		if action == "http://www.onvif.org/ver20/ptz/wsdl/GetConfiguration" {
+			return `<tptz:GetConfigurationResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl">
+<tptz:PTZConfiguration token="ptz1"><tt:Name>PT</tt:Name><tt:UseCount>1</tt:UseCount><tt:NodeToken>node1</tt:NodeToken>
+<tt:DefaultPTZSpeed><tt:PanTilt x="0" y="0"/></tt:DefaultPTZSpeed>
+</tptz:PTZConfiguration></tptz:GetConfigurationResponse>`
+		}
+		return `<tptz:Response xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>`
+	})
+	camera := &Camera{PTZURL: "http://192.0.2.10/onvif/ptz"}
+
+	// The camera has no zoom, so the default speed must not gain one.
+	cfg, err := c.GetPTZConfiguration(camera, "ptz1")
+	if err != nil {
+		t.Fatalf("GetPTZConfiguration() error = %v", err)
+	}
+	cfg.Name = "Pan/tilt"
+	if err := c.SetPTZConfiguration(camera, *cfg); err != nil {
+		t.Fatalf("SetPTZConfiguration() error = %v", err)
+	}
+	want := `<tt:DefaultPTZSpeed><tt:PanTilt xmlns:tt="http://www.onvif.org/ver10/schema" x="0" y="0"/></tt:DefaultPTZSpeed>`
+	if !strings.Contains(gotBody, want) {
+		t.Errorf("SetPTZConfiguration body = %s\nwant %s", gotBody, want)
+	}
+}
@@ -0,0 +1,242 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// ptzSpacesXML is the parsed form of a tt:PTZSpaces element. Space2D and
+// Space1D unmarshal directly since their fields match the schema names.
+type ptzSpacesXML struct {
+	AbsolutePanTilt   []Space2D `xml:"AbsolutePanTiltPositionSpace"`
+	AbsoluteZoom      []Space1D `xml:"AbsoluteZoomPositionSpace"`
+	RelativePanTilt   []Space2D `xml:"RelativePanTiltTranslationSpace"`
+	RelativeZoom      []Space1D `xml:"RelativeZoomTranslationSpace"`
+	ContinuousPanTilt []Space2D `xml:"ContinuousPanTiltVelocitySpace"`
+	ContinuousZoom    []Space1D `xml:"ContinuousZoomVelocitySpace"`
+	PanTiltSpeed      []Space1D `xml:"PanTiltSpeedSpace"`
+	ZoomSpeed         []Space1D `xml:"ZoomSpeedSpace"`
+}
+
+func (s ptzSpacesXML) spaces() PTZSpaces {
+	return PTZSpaces(s)
+}
+
+// ptzNodeXML is the parsed form of a tt:PTZNode.
+type ptzNodeXML struct {
+	Token                  string       `xml:"token,attr"`
+	FixedHomePosition      bool         `xml:"FixedHomePosition,attr"`
+	Name                   string       `xml:"Name"`
+	Spaces                 ptzSpacesXML `xml:"SupportedPTZSpaces"`
+	MaximumNumberOfPresets int          `xml:"MaximumNumberOfPresets"`
+	HomeSupported          bool         `xml:"HomeSupported"`
+	AuxiliaryCommands      []string     `xml:"AuxiliaryCommands"`
+	PresetTour             struct {
+		Maximum    int      `xml:"MaximumNumberOfPresetTours"`
+		Operations []string `xml:"PTZPresetTourOperation"`
+	} `xml:"Extension>SupportedPresetTour"`
+}
+
+func (n ptzNodeXML) node() PTZNode {
+	return PTZNode{
+		Token:                      n.Token,
+		Name:                       n.Name,
+		FixedHomePosition:          n.FixedHomePosition,
+		HomeSupported:              n.HomeSupported,
+		MaximumNumberOfPresets:     n.MaximumNumberOfPresets,
+		MaximumNumberOfPresetTours: n.PresetTour.Maximum,
+		PresetTourOperations:       n.PresetTour.Operations,
+		AuxiliaryCommands:          trimAll(n.AuxiliaryCommands),
+		Spaces:                     n.Spaces.spaces(),
+	}
+}
+
+// GetPTZNodes returns the PTZ nodes (physical PTZ units) of the device with
+// their supported coordinate spaces and limits.
+func (c *Client) GetPTZNodes(camera *Camera) ([]PTZNode, error) {
+	return c.GetPTZNodesContext(context.Background(), camera)
+}
+
+// GetPTZNodesContext is like GetPTZNodes but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetPTZNodesContext(ctx context.Context, camera *Camera) ([]PTZNode, error) {
+	resp, err := c.ptzRequest(ctx, camera, "GetNodes", `<tptz:GetNodes/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Nodes []ptzNodeXML `xml:"Body>GetNodesResponse>PTZNode"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse PTZ nodes: %v", err)
+	}
+
+	nodes := make([]PTZNode, 0, len(parsed.Nodes))
+	for _, n := range parsed.Nodes {
+		nodes = append(nodes, n.node())
+	}
+	return nodes, nil
+}
+
+// GetPTZNode returns a single PTZ node, typically the NodeToken of a PTZConfig.
+func (c *Client) GetPTZNode(camera *Camera, nodeToken string) (*PTZNode, error) {
+	return c.GetPTZNodeContext(context.Background(), camera, nodeToken)
+}
+
+// GetPTZNodeContext is like GetPTZNode but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetPTZNodeContext(ctx context.Context, camera *Camera, nodeToken string) (*PTZNode, error) {
+	body := fmt.Sprintf(`<tptz:GetNode><tptz:NodeToken>%s</tptz:NodeToken></tptz:GetNode>`, nodeToken)
+	resp, err := c.ptzRequest(ctx, camera, "GetNode", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Node ptzNodeXML `xml:"Body>GetNodeResponse>PTZNode"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse PTZ node: %v", err)
+	}
+	node := parsed.Node.node()
+	return &node, nil
+}
+
+// GetPTZConfigurationOptions returns the spaces and limits accepted by a PTZ
+// configuration.
+func (c *Client) GetPTZConfigurationOptions(camera *Camera, configToken string) (*PTZConfigurationOptions, error) {
+	return c.GetPTZConfigurationOptionsContext(context.Background(), camera, configToken)
+}
+
+// GetPTZConfigurationOptionsContext is like GetPTZConfigurationOptions but
+// uses ctx for cancellation and deadlines.
+func (c *Client) GetPTZConfigurationOptionsContext(ctx context.Context, camera *Camera, configToken string) (*PTZConfigurationOptions, error) {
+	body := fmt.Sprintf(`<tptz:GetConfigurationOptions><tptz:ConfigurationToken>%s</tptz:ConfigurationToken></tptz:GetConfigurationOptions>`, configToken)
+	resp, err := c.ptzRequest(ctx, camera, "GetConfigurationOptions", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Options struct {
+			Spaces  ptzSpacesXML `xml:"Spaces"`
+			Timeout struct {
+				Min string `xml:"Min"`
+				Max string `xml:"Max"`
+			} `xml:"PTZTimeout"`
+			EFlipModes   []string `xml:"PTControlDirection>EFlip>Mode"`
+			ReverseModes []string `xml:"PTControlDirection>Reverse>Mode"`
+		} `xml:"Body>GetConfigurationOptionsResponse>PTZConfigurationOptions"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse PTZ configuration options: %v", err)
+	}
+
+	o := parsed.Options
+	min, _ := parseXSDuration(o.Timeout.Min)
+	max, _ := parseXSDuration(o.Timeout.Max)
+	return &PTZConfigurationOptions{
+		Spaces:       o.Spaces.spaces(),
+		Timeout:      DurationRange{Min: min, Max: max},
+		EFlipModes:   o.EFlipModes,
+		ReverseModes: o.ReverseModes,
+	}, nil
+}
+
+// Clamp limits v to the range.
+func (r FloatRange) Clamp(v float64) float64 {
+	if v < r.Min {
+		return r.Min
+	}
+	if v > r.Max {
+		return r.Max
+	}
+	return v
+}
+
+// Contains reports whether v lies within the range.
+func (r FloatRange) Contains(v float64) bool {
+	return v >= r.Min && v <= r.Max
+}
+
+// SupportsAbsolute reports whether AbsoluteMove is supported on any axis.
+func (s PTZSpaces) SupportsAbsolute() bool {
+	return len(s.AbsolutePanTilt) > 0 || len(s.AbsoluteZoom) > 0
+}
+
+// SupportsRelative reports whether RelativeMove is supported on any axis.
+func (s PTZSpaces) SupportsRelative() bool {
+	return len(s.RelativePanTilt) > 0 || len(s.RelativeZoom) > 0
+}
+
+// SupportsContinuous reports whether ContinuousMove is supported on any axis.
+func (s PTZSpaces) SupportsContinuous() bool {
+	return len(s.ContinuousPanTilt) > 0 || len(s.ContinuousZoom) > 0
+}
+
+// ClampAbsolute limits an AbsoluteMove position to the position space named
+// by v.PanTiltSpace/v.ZoomSpace, or the generic one when unset. Axes the node
+// cannot move absolutely in that space are zeroed.
+func (s PTZSpaces) ClampAbsolute(v PTZVector) PTZVector {
+	return clampPTZVector(v, s.AbsolutePanTilt, s.AbsoluteZoom)
+}
+
+// ClampRelative limits a RelativeMove translation to the translation space
+// named by v.PanTiltSpace/v.ZoomSpace, or the generic one when unset. Axes the
+// node cannot move relatively in that space are zeroed.
+func (s PTZSpaces) ClampRelative(v PTZVector) PTZVector {
+	return clampPTZVector(v, s.RelativePanTilt, s.RelativeZoom)
+}
+
+// ClampContinuous limits a ContinuousMove velocity to the velocity space named
+// by v.PanTiltSpace/v.ZoomSpace, or the generic one when unset. Axes the node
+// cannot move continuously in that space are zeroed.
+func (s PTZSpaces) ClampContinuous(v PTZVector) PTZVector {
+	return clampPTZVector(v, s.ContinuousPanTilt, s.ContinuousZoom)
+}
+
+// clampPTZVector clamps each axis of v against the space it names, or the
+// generic space of that axis (the one the move operations use when no space
+// is given) when it names none. The space URIs are kept in the result.
+func clampPTZVector(v PTZVector, panTilt []Space2D, zoom []Space1D) PTZVector {
+	out := PTZVector{PanTiltSpace: v.PanTiltSpace, ZoomSpace: v.ZoomSpace}
+	if i := spaceIndex(v.PanTiltSpace, len(panTilt), func(i int) string { return panTilt[i].URI }); i >= 0 {
+		out.Pan = panTilt[i].XRange.Clamp(v.Pan)
+		out.Tilt = panTilt[i].YRange.Clamp(v.Tilt)
+	}
+	if i := spaceIndex(v.ZoomSpace, len(zoom), func(i int) string { return zoom[i].URI }); i >= 0 {
+		out.Zoom = zoom[i].XRange.Clamp(v.Zoom)
+	}
+	return out
+}
+
+// spaceIndex returns the index of the space with the given URI, or -1 if the
+// node does not support it. An empty URI selects the generic space.
+func spaceIndex(want string, n int, uri func(int) string) int {
+	if want == "" {
+		return genericSpaceIndex(n, uri)
+	}
+	for i := 0; i < n; i++ {
+		if uri(i) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+// genericSpaceIndex returns the index of the first space whose URI names a
+// generic space, 0 if there is none, or -1 for an empty list.
+func genericSpaceIndex(n int, uri func(int) string) int {
+	for i := 0; i < n; i++ {
+		if strings.Contains(uri(i), "GenericSpace") {
+			return i
+		}
+	}
+	if n == 0 {
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package onvif
+
+import (
+	"testing"
+	"time"
+)
+
+const getNodesResponse = `<tptz:GetNodesResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl">
+<tptz:PTZNode token="node0" FixedHomePosition="false"><tt:Name>PTZ</tt:Name>
+<tt:SupportedPTZSpaces>
+<tt:AbsolutePanTiltPositionSpace><tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace</tt:URI>
+<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange><tt:YRange><tt:Min>-0.5</tt:Min><tt:Max>1</tt:Max></tt:YRange></tt:AbsolutePanTiltPositionSpace>
+<tt:ContinuousPanTiltVelocitySpace><tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocitySpaceDegrees</tt:URI>
+<tt:XRange><tt:Min>-90</tt:Min><tt:Max>90</tt:Max></tt:XRange><tt:YRange><tt:Min>-90</tt:Min><tt:Max>90</tt:Max></tt:YRange></tt:ContinuousPanTiltVelocitySpace>
+<tt:ContinuousPanTiltVelocitySpace><tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocityGenericSpace</tt:URI>
+<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange><tt:YRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:YRange></tt:ContinuousPanTiltVelocitySpace>
+<tt:ContinuousZoomVelocitySpace><tt:URI>http://www.onvif.org/ver10/tptz/ZoomSpaces/VelocityGenericSpace</tt:URI>
+<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange></tt:ContinuousZoomVelocitySpace>
+<tt:PanTiltSpeedSpace><tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/GenericSpeedSpace</tt:URI>
+<tt:XRange><tt:Min>0</tt:Min><tt:Max>1</tt:Max></tt:XRange></tt:PanTiltSpeedSpace>
+</tt:SupportedPTZSpaces>
+<tt:MaximumNumberOfPresets>255</tt:MaximumNumberOfPresets><tt:HomeSupported>true</tt:HomeSupported>
+<tt:AuxiliaryCommands>tt:Wiper|On</tt:AuxiliaryCommands><tt:AuxiliaryCommands>tt:Wiper|Off</tt:AuxiliaryCommands>
+<tt:Extension><tt:SupportedPresetTour><tt:MaximumNumberOfPresetTours>8</tt:MaximumNumberOfPresetTours>
+<tt:PTZPresetTourOperation>Start</tt:PTZPresetTourOperation><tt:PTZPresetTourOperation>Stop</tt:PTZPresetTourOperation></tt:SupportedPresetTour></tt:Extension>
+</tptz:PTZNode></tptz:GetNodesResponse>`
+
+func TestGetPTZNodes(t *testing.T) {
+	c := fakeSOAPClient(func(action, body string) string {
+		switch action {
+		case "http://www.onvif.org/ver20/ptz/wsdl/GetNodes":
+			return getNodesResponse
+		case "http://www.onvif.org/ver20/ptz/wsdl/GetConfigurationOptions":
+			return `<tptz:GetConfigurationOptionsResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"><tptz:PTZConfigurationOptions>
+<tt:Spaces><tt:RelativeZoomTranslationSpace><tt:URI>http://www.onvif.org/ver10/tptz/ZoomSpaces/TranslationGenericSpace</tt:URI>
+<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange></tt:RelativeZoomTranslationSpace></tt:Spaces>
+<tt:PTZTimeout><tt:Min>PT1S</tt:Min><tt:Max>PT1M</tt:Max></tt:PTZTimeout>
+<tt:PTControlDirection><tt:EFlip><tt:Mode>OFF</tt:Mode><tt:Mode>ON</tt:Mode></tt:EFlip><tt:Reverse><tt:Mode>OFF</tt:Mode></tt:Reverse></tt:PTControlDirection>
+</tptz:PTZConfigurationOptions></tptz:GetConfigurationOptionsResponse>`
+		}
+		return ""
+	})
+	camera := &Camera{PTZURL: "http://192.0.2.10/onvif/ptz"}
+
+	nodes, err := c.GetPTZNodes(camera)
+	if err != nil {
+		t.Fatalf("GetPTZNodes() error = %v", err)
+	}
+	if len(nodes) != 1 {
+		t.Fatalf("GetPTZNodes() = %+v", nodes)
+	}
+	n := nodes[0]
+	if n.Token != "node0" || n.Name != "PTZ" || !n.HomeSupported || n.MaximumNumberOfPresets != 255 ||
+		n.MaximumNumberOfPresetTours != 8 || len(n.PresetTourOperations) != 2 ||
+		len(n.AuxiliaryCommands) != 2 || n.AuxiliaryCommands[0] != "tt:Wiper|On" {
+		t.Errorf("node = %+v", n)
+	}
+	if !n.Spaces.SupportsAbsolute() || n.Spaces.SupportsRelative() || !n.Spaces.SupportsContinuous() {
+		t.Errorf("Supports* wrong for %+v", n.Spaces)
+	}
+	if r := n.Spaces.AbsolutePanTilt[0].YRange; r != (FloatRange{-0.5, 1}) {
+		t.Errorf("AbsolutePanTilt YRange = %+v", r)
+	}
+
+	// The generic velocity space wins over the degrees space listed first.
+	if got := n.Spaces.ClampContinuous(PTZVector{Pan: 5, Tilt: -0.5, Zoom: -3}); got != (PTZVector{Pan: 1, Tilt: -0.5, Zoom: -1}) {
+		t.Errorf("ClampContinuous() = %+v", got)
+	}
+	// A named space is clamped against its own range and kept.
+	deg := PTZVector{Pan: 120, Tilt: -30, PanTiltSpace: "http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocitySpaceDegrees"}
+	if got := n.Spaces.ClampContinuous(deg); got != (PTZVector{Pan: 90, Tilt: -30, PanTiltSpace: deg.PanTiltSpace}) {
+		t.Errorf("ClampContinuous(degrees) = %+v", got)
+	}
+	// No absolute zoom space: zoom is zeroed.
+	if got := n.Spaces.ClampAbsolute(PTZVector{Pan: 0.2, Tilt: -1, Zoom: 0.7}); got != (PTZVector{Pan: 0.2, Tilt: -0.5}) {
+		t.Errorf("ClampAbsolute() = %+v", got)
+	}
+
+	opts, err := c.GetPTZConfigurationOptions(camera, "cfg0")
+	if err != nil {
+		t.Fatalf("GetPTZConfigurationOptions() error = %v", err)
+	}
+	if !opts.Spaces.SupportsRelative() || opts.Timeout != (DurationRange{time.Second, time.Minute}) ||
+		len(opts.EFlipModes) != 2 || len(opts.ReverseModes) != 1 {
+		t.Errorf("options = %+v", opts)
+	}
+}
@@ -1,9 +1,10 @@
 package onvif
 
 import (
+	"bytes"
+	"context"
 	"crypto/md5"
 	"crypto/rand"
-	"crypto/tls"
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
@@ -15,9 +16,15 @@ import (
 // GetSnapshotUri returns the HTTP(S) URL of a still JPEG for the given media
 // profile. Mirrors GetStreamUri.
 func (c *Client) GetSnapshotUri(camera *Camera, profileToken string) (string, error) {
-	mediaURL := c.resolveMediaURL(camera)
+	return c.GetSnapshotUriContext(context.Background(), camera, profileToken)
+}
+
+// GetSnapshotUriContext is like GetSnapshotUri but uses ctx for cancellation
+// and deadlines.
+func (c *Client) GetSnapshotUriContext(ctx context.Context, camera *Camera, profileToken string) (string, error) {
+	mediaURL := c.resolveMediaURL(ctx, camera)
 	body := fmt.Sprintf(`<trt:GetSnapshotUri><trt:ProfileToken>%s</trt:ProfileToken></trt:GetSnapshotUri>`, profileToken)
-	resp, err := c.sendSOAPRequest(mediaURL,
+	resp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/GetSnapshotUri", body)
 	if err != nil {
 		return "", fmt.Errorf("failed to get snapshot URI: %v", err)
@@ -43,49 +50,82 @@ func (c *Client) GetSnapshotUri(camera *Camera, profileToken string) (string, er
 // WS-Security), so this tries unauthenticated first, then satisfies whichever
 // challenge the camera returns.
 func (c *Client) FetchSnapshot(camera *Camera, profileToken string) ([]byte, error) {
-	uri, err := c.GetSnapshotUri(camera, profileToken)
-	if err != nil {
-		return nil, err
-	}
-
-	client := &http.Client{Timeout: c.Timeout}
-	if c.InsecureTLS {
-		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
-	}
+	return c.FetchSnapshotContext(context.Background(), camera, profileToken)
+}
 
-	// First attempt: no auth (also reveals the auth challenge if required).
-	req, err := http.NewRequest(http.MethodGet, uri, nil)
+// FetchSnapshotContext is like FetchSnapshot but uses ctx for cancellation and
+// deadlines.
+func (c *Client) FetchSnapshotContext(ctx context.Context, camera *Camera, profileToken string) ([]byte, error) {
+	uri, err := c.GetSnapshotUriContext(ctx, camera, profileToken)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := client.Do(req)
+
+	resp, err := c.doHTTPWithAuth(ctx, c.transport(), http.MethodGet, uri, "", nil)
 	if err != nil {
 		return nil, fmt.Errorf("snapshot request failed: %v", err)
 	}
-	if resp.StatusCode == http.StatusUnauthorized && c.Username != "" {
-		challenge := resp.Header.Get("WWW-Authenticate")
-		_ = resp.Body.Close()
+	defer func() { _ = resp.Body.Close() }()
 
-		req2, err := http.NewRequest(http.MethodGet, uri, nil)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("snapshot HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// doHTTPWithAuth sends a plain HTTP request to a camera endpoint (snapshot
+// URL, firmware upload URI). These use HTTP Basic or Digest auth rather than
+// WS-Security, so the request is first sent without credentials (which also
+// reveals the challenge) and, on a 401, repeated answering whichever challenge
+// the camera returned.
+func (c *Client) doHTTPWithAuth(ctx context.Context, client *http.Client, method, uri, contentType string, body []byte) (*http.Response, error) {
+	newRequest := func() (*http.Request, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, uri, r)
 		if err != nil {
 			return nil, err
 		}
-		if strings.HasPrefix(strings.ToLower(challenge), "digest") {
-			req2.Header.Set("Authorization", digestAuthHeader(challenge, http.MethodGet, uri, c.Username, c.Password))
-		} else {
-			req2.SetBasicAuth(c.Username, c.Password)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
 		}
-		resp, err = client.Do(req2)
-		if err != nil {
-			return nil, fmt.Errorf("authenticated snapshot request failed: %v", err)
+		if len(body) > 0 {
+			// Let the camera refuse (e.g. with the auth challenge) before a
+			// large body is sent.
+			req.Header.Set("Expect", "100-continue")
 		}
+		return req, nil
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("snapshot HTTP %d", resp.StatusCode)
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
 	}
-	return io.ReadAll(resp.Body)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized || c.Username == "" {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	// Drain the challenge body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	req, err = newRequest()
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(strings.ToLower(challenge), "digest") {
+		req.Header.Set("Authorization", digestAuthHeader(challenge, method, uri, c.Username, c.Password))
+	} else {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	return client.Do(req)
 }
 
 // digestAuthHeader builds an HTTP Digest Authorization header value for the
@@ -2,13 +2,17 @@ package onvif
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/sha1"
-	"crypto/tls"
 	"encoding/base64"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -36,8 +40,24 @@ func generatePasswordDigest(password string) (digest, nonceB64, created string)
 	return digest, nonceB64, created
 }
 
-// sendSOAPRequest sends a SOAP request to an ONVIF device
-func (c *Client) sendSOAPRequest(endpoint, action, body string) ([]byte, error) {
+// sendSOAPRequest sends a SOAP request to an ONVIF device. The request is bound
+// to ctx, so cancelling ctx (or hitting its deadline) aborts it in flight.
+func (c *Client) sendSOAPRequest(ctx context.Context, endpoint, action, body string) ([]byte, error) {
+	return c.sendSOAPRequestWithHeader(ctx, endpoint, action, "", body)
+}
+
+// sendSOAPRequestWithHeader is sendSOAPRequest with extra SOAP header blocks
+// (e.g. WS-Addressing) placed after the WS-Security header.
+func (c *Client) sendSOAPRequestWithHeader(ctx context.Context, endpoint, action, extraHeader, body string) ([]byte, error) {
+	// SOAP 1.2 conveys the action as a Content-Type parameter.
+	contentType := fmt.Sprintf("application/soap+xml; charset=utf-8; action=%q", action)
+	return c.postSOAP(ctx, c.transport(), endpoint, action, contentType,
+		bytes.NewBufferString(c.soapEnvelope(extraHeader, body)))
+}
+
+// soapEnvelope wraps body in a SOAP 1.2 envelope carrying the WS-Security
+// header (when the client has credentials) followed by extraHeader.
+func (c *Client) soapEnvelope(extraHeader, body string) string {
 	digest, nonce, created := generatePasswordDigest(c.Password)
 
 	authHeader := ""
@@ -53,39 +73,37 @@ func (c *Client) sendSOAPRequest(endpoint, action, body string) ([]byte, error)
 		</wsse:Security>`, escapeXML(c.Username), digest, nonce, created)
 	}
 
-	soapRequest := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"
             xmlns:tds="http://www.onvif.org/ver10/device/wsdl"
             xmlns:trt="http://www.onvif.org/ver10/media/wsdl"
             xmlns:tt="http://www.onvif.org/ver10/schema"
             xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"
             xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"
-            xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl">
-	<s:Header>%s</s:Header>
+            xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"
+            xmlns:tev="http://www.onvif.org/ver10/events/wsdl"
+            xmlns:tan="http://www.onvif.org/ver20/analytics/wsdl"
+            xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2"
+            xmlns:wsa="http://www.w3.org/2005/08/addressing">
+	<s:Header>%s%s</s:Header>
 	<s:Body>%s</s:Body>
-</s:Envelope>`, authHeader, body)
+</s:Envelope>`, authHeader, extraHeader, body)
+}
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBufferString(soapRequest))
+// postSOAP posts a SOAP message (a plain envelope, or an MTOM package) with
+// client and returns the response body. HTTP errors are reported with the
+// SOAP fault detail when the body carries one.
+func (c *Client) postSOAP(ctx context.Context, client *http.Client, endpoint, action, contentType string, payload io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, payload)
 	if err != nil {
 		return nil, err
 	}
 
-	// SOAP 1.2 conveys the action as a Content-Type parameter. Keep the legacy
-	// SOAPAction header too for devices that still look for it (SOAP 1.1 style).
-	req.Header.Set("Content-Type", fmt.Sprintf("application/soap+xml; charset=utf-8; action=%q", action))
+	// Keep the legacy SOAPAction header too for devices that still look for it
+	// (SOAP 1.1 style).
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("SOAPAction", action)
 
-	timeout := c.Timeout
-	if timeout == 0 {
-		timeout = 10 * time.Second
-	}
-
-	client := &http.Client{Timeout: timeout}
-	if c.InsecureTLS {
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -128,6 +146,164 @@ func faultDetail(resp []byte) string {
 	return ""
 }
 
+// addressingHeader renders the WS-Addressing header blocks required when
+// talking to a WS-Notification subscription endpoint: the Action, the target
+// address, and the subscription's reference parameters echoed back verbatim.
+func addressingHeader(action, to, referenceParameters string) string {
+	return fmt.Sprintf(`
+		<wsa:Action s:mustUnderstand="1">%s</wsa:Action>
+		<wsa:To s:mustUnderstand="1">%s</wsa:To>%s`, action, escapeXML(to), referenceParameters)
+}
+
+// formatXSDuration renders d as an xs:duration in seconds (e.g. "PT60S",
+// "PT0.5S"), the form every ONVIF device accepts.
+func formatXSDuration(d time.Duration) string {
+	return "PT" + strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S"
+}
+
+// xsDurationPattern matches an xs:duration such as "PT1M30S" or "-P1DT2H".
+var xsDurationPattern = regexp.MustCompile(`^(-)?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseXSDuration parses an xs:duration. Years and months have no fixed
+// length; they are approximated as 365 and 30 days.
+func parseXSDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	m := xsDurationPattern.FindStringSubmatch(s)
+	if m == nil || s == "P" || s == "-P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid xs:duration %q", s)
+	}
+	units := []time.Duration{365 * 24 * time.Hour, 30 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[7] != "" {
+		secs, _ := strconv.ParseFloat(m[7], 64)
+		d += time.Duration(secs * float64(time.Second))
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// parseXSDateTime parses an xs:dateTime. Devices that omit the timezone are
+// taken to report UTC. Returns the zero time for empty or malformed input.
+func parseXSDateTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// declaredNamespaces maps each prefix declared anywhere in doc to its
+// namespace URI.
+func declaredNamespaces(doc []byte) map[string]string {
+	namespaces := map[string]string{}
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			break
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			for _, a := range se.Attr {
+				if a.Name.Space == "xmlns" {
+					namespaces[a.Name.Local] = a.Value
+				}
+			}
+		}
+	}
+	return namespaces
+}
+
+// qnamePrefix matches the prefix of a QName in an attribute value or text,
+// such as tt in Type="tt:LineDetector".
+var qnamePrefix = regexp.MustCompile(`(?:^|[^\w.:-])([A-Za-z_][\w.-]*):[A-Za-z_]`)
+
+// withNamespaces makes fragment, element content copied from a device
+// response, self-contained: each prefix it uses (in element or attribute
+// names, or in QName values) without declaring it is declared on its
+// top-level elements, with the URI from namespaces. The device may bind
+// prefixes our envelope does not, or bind ours differently, so content sent
+// back verbatim would otherwise be misread. A fragment that does not parse is
+// returned unchanged.
+func withNamespaces(fragment string, namespaces map[string]string) string {
+	type topLevel struct {
+		end      int // offset just past the start tag
+		declared map[string]bool
+	}
+	var tops []topLevel
+	used := map[string]bool{}
+	markValue := func(v string) {
+		for _, m := range qnamePrefix.FindAllStringSubmatch(v, -1) {
+			used[m[1]] = true
+		}
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(fragment))
+	depth := 0
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fragment
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			used[t.Name.Space] = true
+			declared := map[string]bool{}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" {
+					declared[a.Name.Local] = true
+					continue
+				}
+				used[a.Name.Space] = true
+				markValue(a.Value)
+			}
+			if depth == 0 {
+				tops = append(tops, topLevel{int(dec.InputOffset()), declared})
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			markValue(string(t))
+		}
+	}
+
+	var prefixes []string
+	for p := range used {
+		if _, ok := namespaces[p]; ok && p != "" && p != "xml" && p != "xmlns" {
+			prefixes = append(prefixes, p)
+		}
+	}
+	sort.Strings(prefixes)
+
+	for i := len(tops) - 1; i >= 0; i-- {
+		var decls strings.Builder
+		for _, p := range prefixes {
+			if !tops[i].declared[p] {
+				fmt.Fprintf(&decls, ` xmlns:%s="%s"`, p, escapeXML(namespaces[p]))
+			}
+		}
+		at := tops[i].end - 1
+		if strings.HasSuffix(fragment[:tops[i].end], "/>") {
+			at--
+		}
+		fragment = fragment[:at] + decls.String() + fragment[at:]
+	}
+	return fragment
+}
+
 // getFirstAddress extracts the first address if multiple are provided
 func getFirstAddress(address string) string {
 	addresses := strings.Fields(address)
@@ -1,6 +1,17 @@
 package onvif
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
 
 func TestFaultDetail(t *testing.T) {
 	fault := `<?xml version="1.0" encoding="UTF-8"?>
@@ -30,3 +41,154 @@ func TestFaultDetail(t *testing.T) {
 		t.Errorf("faultDetail(non-fault) = %q, want empty", d)
 	}
 }
+
+func TestSendSOAPRequestContextCancel(t *testing.T) {
+	// A camera that never answers: only ctx can end the request early.
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := NewClientWithTimeout("admin", "secret", time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.sendSOAPRequest(ctx, srv.URL,
+		"http://www.onvif.org/ver10/device/wsdl/GetHostname", `<tds:GetHostname/>`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("sendSOAPRequest() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %v, want it aborted by ctx", elapsed)
+	}
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body/></s:Envelope>`)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	c := NewClient("admin", "secret")
+	defer c.CloseIdleConnections()
+	for i := 0; i < 3; i++ {
+		if _, err := c.sendSOAPRequest(context.Background(), srv.URL,
+			"http://www.onvif.org/ver10/device/wsdl/GetHostname", `<tds:GetHostname/>`); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("opened %d connections for 3 sequential requests, want 1", n)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestClientCustomTransport(t *testing.T) {
+	var gotAction string
+	c := NewClient("admin", "secret")
+	c.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		gotAction = r.Header.Get("SOAPAction")
+		body := `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>
+<tds:GetUsersResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+<tds:User><tt:Username>admin</tt:Username><tt:UserLevel>Administrator</tt:UserLevel></tds:User>
+</tds:GetUsersResponse></s:Body></s:Envelope>`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/soap+xml"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+
+	users, err := c.GetUsers(&Camera{Address: "http://192.0.2.10/onvif/device_service"})
+	if err != nil {
+		t.Fatalf("GetUsers() error = %v", err)
+	}
+	if gotAction != "http://www.onvif.org/ver10/device/wsdl/GetUsers" {
+		t.Errorf("SOAPAction = %q", gotAction)
+	}
+	if len(users) != 1 || users[0].Username != "admin" || users[0].UserLevel != UserLevelAdministrator {
+		t.Errorf("users = %+v", users)
+	}
+}
+
+func TestXSDuration(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want time.Duration
+	}{
+		{"PT60S", time.Minute},
+		{"PT0.5S", 500 * time.Millisecond},
+		{"PT1M30S", 90 * time.Second},
+		{"P1DT2H", 26 * time.Hour},
+		{"-PT10S", -10 * time.Second},
+	} {
+		got, err := parseXSDuration(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseXSDuration(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "P", "PT", "60S", "PT1H30"} {
+		if _, err := parseXSDuration(bad); err == nil {
+			t.Errorf("parseXSDuration(%q) succeeded, want error", bad)
+		}
+	}
+
+	if got := formatXSDuration(time.Minute); got != "PT60S" {
+		t.Errorf("formatXSDuration(1m) = %q, want PT60S", got)
+	}
+	if got := formatXSDuration(1500 * time.Millisecond); got != "PT1.5S" {
+		t.Errorf("formatXSDuration(1.5s) = %q, want PT1.5S", got)
+	}
+}
+
+func TestWithNamespaces(t *testing.T) {
+	namespaces := map[string]string{"tt": "urn:tt", "v": "urn:vendor", "unused": "urn:unused"}
+	for _, tc := range []struct{ in, want string }{
+		{`<tt:Polygon><tt:Point x="1"/></tt:Polygon>`, `<tt:Polygon xmlns:tt="urn:tt"><tt:Point x="1"/></tt:Polygon>`},
+		{`<a Type="v:Rule"/><b>tt:Thing</b>`, `<a Type="v:Rule" xmlns:tt="urn:tt" xmlns:v="urn:vendor"/><b xmlns:tt="urn:tt" xmlns:v="urn:vendor">tt:Thing</b>`},
+		{`<v:x xmlns:v="urn:other"><v:y/></v:x>`, `<v:x xmlns:v="urn:other"><v:y/></v:x>`},
+		{`<a href="http://example.com/x"/>`, `<a href="http://example.com/x"/>`},
+		{`<tt:x a=>`, `<tt:x a=>`},
+	} {
+		if got := withNamespaces(tc.in, namespaces); got != tc.want {
+			t.Errorf("withNamespaces(%s) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+// fakeSOAPClient returns a client whose requests are answered in-process by
+// respond, which receives the SOAP action and request body and returns the
+// contents of the response s:Body.
+func fakeSOAPClient(respond func(action, body string) string) *Client {
+	c := NewClient("admin", "secret")
+	c.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		req, _ := io.ReadAll(r.Body)
+		body := `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:tt="http://www.onvif.org/ver10/schema"><s:Body>` +
+			respond(r.Header.Get("SOAPAction"), string(req)) + `</s:Body></s:Envelope>`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/soap+xml"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+	return c
+}
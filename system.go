@@ -0,0 +1,129 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// deviceReadyPollInterval is how often WaitForDeviceReady retries while the
+// device is not answering.
+var deviceReadyPollInterval = 2 * time.Second
+
+// SystemReboot asks the device to reboot and returns its message (often an
+// estimate of the downtime, e.g. "Rebooting in 30 seconds"). The device goes
+// down shortly after answering; use WaitForDeviceReady to wait for it.
+func (c *Client) SystemReboot(camera *Camera) (string, error) {
+	return c.SystemRebootContext(context.Background(), camera)
+}
+
+// SystemRebootContext is like SystemReboot but uses ctx for cancellation and
+// deadlines.
+func (c *Client) SystemRebootContext(ctx context.Context, camera *Camera) (string, error) {
+	address := getFirstAddress(camera.Address)
+
+	resp, err := c.sendSOAPRequest(ctx, address,
+		"http://www.onvif.org/ver10/device/wsdl/SystemReboot", `<tds:SystemReboot/>`)
+	if err != nil {
+		return "", fmt.Errorf("failed to reboot: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return "", fmt.Errorf("failed to reboot: %w", err)
+	}
+
+	var parsed struct {
+		Message string `xml:"Body>SystemRebootResponse>Message"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse reboot response: %v", err)
+	}
+	return strings.TrimSpace(parsed.Message), nil
+}
+
+// SetSystemFactoryDefault resets the device to its factory settings. A hard
+// reset also clears the network configuration and users, so the device may
+// come back on a different address with default credentials. Most devices
+// reboot afterwards.
+func (c *Client) SetSystemFactoryDefault(camera *Camera, kind FactoryDefaultType) error {
+	return c.SetSystemFactoryDefaultContext(context.Background(), camera, kind)
+}
+
+// SetSystemFactoryDefaultContext is like SetSystemFactoryDefault but uses ctx
+// for cancellation and deadlines.
+func (c *Client) SetSystemFactoryDefaultContext(ctx context.Context, camera *Camera, kind FactoryDefaultType) error {
+	if kind != FactoryDefaultHard && kind != FactoryDefaultSoft {
+		return fmt.Errorf("invalid factory default type %q", kind)
+	}
+	address := getFirstAddress(camera.Address)
+
+	body := fmt.Sprintf(`<tds:SetSystemFactoryDefault><tds:FactoryDefault>%s</tds:FactoryDefault></tds:SetSystemFactoryDefault>`, kind)
+	resp, err := c.sendSOAPRequest(ctx, address,
+		"http://www.onvif.org/ver10/device/wsdl/SetSystemFactoryDefault", body)
+	if err != nil {
+		return fmt.Errorf("failed to reset to factory defaults: %v", err)
+	}
+	if err := parseSOAPFault(resp); err != nil {
+		return fmt.Errorf("failed to reset to factory defaults: %w", err)
+	}
+	return nil
+}
+
+// pingDevice calls GetSystemDateAndTime and reports whether the device service
+// answered. A SOAP fault counts as an answer: after a factory reset the device
+// may reject our credentials but is up.
+func (c *Client) pingDevice(ctx context.Context, camera *Camera) error {
+	resp, err := c.sendSOAPRequest(ctx, getFirstAddress(camera.Address),
+		"http://www.onvif.org/ver10/device/wsdl/GetSystemDateAndTime", `<tds:GetSystemDateAndTime/>`)
+	if resp != nil && parseSOAPFault(resp) != nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var parsed struct {
+		Response *struct{} `xml:"Body>GetSystemDateAndTimeResponse"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil || parsed.Response == nil {
+		return fmt.Errorf("unexpected GetSystemDateAndTime response")
+	}
+	return nil
+}
+
+// WaitForDeviceReady polls GetSystemDateAndTime until the device answers or
+// timeout elapses. Call it once the device has gone down (for example a few
+// seconds after SystemReboot); a device that has not yet stopped answering
+// is reported ready at once. timeout must be positive.
+func (c *Client) WaitForDeviceReady(camera *Camera, timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got %v", timeout)
+	}
+	return c.WaitForDeviceReadyContext(context.Background(), camera, timeout)
+}
+
+// WaitForDeviceReadyContext is like WaitForDeviceReady but also stops when
+// ctx is done. A timeout <= 0 waits until ctx is done.
+func (c *Client) WaitForDeviceReadyContext(ctx context.Context, camera *Camera, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		err := c.pingDevice(ctx, camera)
+		if err == nil {
+			return nil
+		}
+
+		timer := time.NewTimer(deviceReadyPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("device not ready: %w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
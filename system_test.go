@@ -0,0 +1,81 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSystemRebootAndFactoryDefault(t *testing.T) {
+	var gotAction, gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotAction, gotBody = action, body
+		if action == "http://www.onvif.org/ver10/device/wsdl/SystemReboot" {
+			return `<tds:SystemRebootResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"><tds:Message>Rebooting in 30 seconds</tds:Message></tds:SystemRebootResponse>`
+		}
+		return `<tds:Response xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>`
+	})
+	camera := &Camera{Address: "http://192.0.2.10/onvif/device_service"}
+
+	msg, err := c.SystemReboot(camera)
+	if err != nil || msg != "Rebooting in 30 seconds" {
+		t.Errorf("SystemReboot() = %q, %v", msg, err)
+	}
+
+	if err := c.SetSystemFactoryDefault(camera, FactoryDefaultSoft); err != nil {
+		t.Fatalf("SetSystemFactoryDefault() error = %v", err)
+	}
+	if gotAction != "http://www.onvif.org/ver10/device/wsdl/SetSystemFactoryDefault" ||
+		!strings.Contains(gotBody, `<tds:FactoryDefault>Soft</tds:FactoryDefault>`) {
+		t.Errorf("SetSystemFactoryDefault sent %s: %s", gotAction, gotBody)
+	}
+	if err := c.SetSystemFactoryDefault(camera, "Partial"); err == nil {
+		t.Error("SetSystemFactoryDefault(Partial) returned nil error")
+	}
+}
+
+func TestWaitForDeviceReady(t *testing.T) {
+	defer func(d time.Duration) { deviceReadyPollInterval = d }(deviceReadyPollInterval)
+	deviceReadyPollInterval = time.Millisecond
+
+	// The device refuses connections twice, then answers with a fault (as
+	// after a factory reset that changed the credentials).
+	attempts := 0
+	c := NewClient("admin", "secret")
+	c.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts <= 2 {
+			return nil, errors.New("connection refused")
+		}
+		body := `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body><s:Fault>
+<s:Code><s:Value>s:Sender</s:Value></s:Code><s:Reason><s:Text xml:lang="en">Not authorized</s:Text></s:Reason>
+</s:Fault></s:Body></s:Envelope>`
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{"Content-Type": {"application/soap+xml"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+	camera := &Camera{Address: "http://192.0.2.10/onvif/device_service"}
+
+	if err := c.WaitForDeviceReady(camera, 5*time.Second); err != nil {
+		t.Fatalf("WaitForDeviceReady() error = %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("WaitForDeviceReady() made %d attempts, want 3", attempts)
+	}
+
+	attempts = -1000
+	err := c.WaitForDeviceReady(camera, 20*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("WaitForDeviceReady() on a down device error = %v", err)
+	}
+	if err := c.WaitForDeviceReady(camera, 0); err == nil {
+		t.Error("WaitForDeviceReady() with no timeout returned nil error")
+	}
+}
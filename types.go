@@ -2,6 +2,11 @@
 package onvif
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"sync"
 	"time"
 )
 
@@ -29,6 +34,9 @@ type Camera struct {
 	TimeZone string
 	DateTime string
 
+	// From GetEventProperties
+	EventProperties *EventProperties
+
 	// From GetCapabilities
 	VideoSources     int
 	VideoOutputs     int
@@ -39,21 +47,54 @@ type Camera struct {
 	AnalyticsSupport bool
 
 	// Service URLs discovered from GetCapabilities / GetServices
-	MediaURL   string
-	Media2URL  string
-	ImagingURL string
-	PTZURL     string
+	MediaURL     string
+	Media2URL    string
+	ImagingURL   string
+	PTZURL       string
+	EventsURL    string
+	AnalyticsURL string
+
+	// servicesDiscovered records that discoverServices has heard from the
+	// device, so services it did not advertise (such as Media2 on a
+	// Media1-only camera) are not asked for again on every call.
+	servicesDiscovered bool
 }
 
 // PTZVector is a normalized pan/tilt/zoom vector. For moves the components are
 // velocities (ContinuousMove) or translations/positions (Relative/AbsoluteMove);
 // ONVIF normalizes each component to roughly -1..1 (zoom typically 0..1).
+//
+// PanTiltSpace and ZoomSpace optionally name the coordinate space URI of each
+// axis (see PTZSpaces); empty means the configuration's default space. An axis
+// with a space set, or one present in a tour spot or default speed read from
+// the device, is sent even when its components are zero.
 type PTZVector struct {
 	Pan  float64
 	Tilt float64
 	Zoom float64
+
+	PanTiltSpace string
+	ZoomSpace    string
+
+	// axes present when the vector was read as part of a setting
+	hasPanTilt, hasZoom bool
 }
 
+// Well-known PTZ coordinate space URIs for PTZVector.PanTiltSpace/ZoomSpace.
+// Cameras only accept the spaces their node advertises.
+const (
+	PanTiltPositionGenericSpace          = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace"
+	PanTiltSphericalPositionSpaceDegrees = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/SphericalPositionSpaceDegrees"
+	PanTiltTranslationGenericSpace       = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/TranslationGenericSpace"
+	PanTiltTranslationSpaceFov           = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/TranslationSpaceFov"
+	PanTiltVelocityGenericSpace          = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocityGenericSpace"
+	PanTiltGenericSpeedSpace             = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/GenericSpeedSpace"
+	ZoomPositionGenericSpace             = "http://www.onvif.org/ver10/tptz/ZoomSpaces/PositionGenericSpace"
+	ZoomTranslationGenericSpace          = "http://www.onvif.org/ver10/tptz/ZoomSpaces/TranslationGenericSpace"
+	ZoomVelocityGenericSpace             = "http://www.onvif.org/ver10/tptz/ZoomSpaces/VelocityGenericSpace"
+	ZoomGenericSpeedSpace                = "http://www.onvif.org/ver10/tptz/ZoomSpaces/ZoomGenericSpeedSpace"
+)
+
 // PTZStatus is the current PTZ position and movement state.
 type PTZStatus struct {
 	Position  PTZVector
@@ -61,11 +102,151 @@ type PTZStatus struct {
 	UTCTime   string
 }
 
-// PTZConfig is a PTZ configuration attached to a profile.
+// PTZPreset is a stored PTZ position.
+type PTZPreset struct {
+	Token    string
+	Name     string
+	Position PTZVector
+}
+
+// PresetTourOperation is the action requested of a preset tour by
+// OperatePresetTour.
+type PresetTourOperation string
+
+const (
+	PresetTourStart PresetTourOperation = "Start"
+	PresetTourStop  PresetTourOperation = "Stop"
+	PresetTourPause PresetTourOperation = "Pause"
+)
+
+// PresetTour is a guard tour: a sequence of spots the camera visits in turn.
+type PresetTour struct {
+	Token     string
+	Name      string
+	State     string // "Idle" / "Touring" / "Paused" (read-only)
+	AutoStart bool
+
+	// Starting condition
+	RecurringTime     int           // number of passes (0 = camera default)
+	RecurringDuration time.Duration // total touring time (0 = camera default)
+	Direction         string        // "Forward" / "Backward" ("" = camera default)
+	RandomOrder       bool
+
+	Spots       []TourSpot
+	CurrentSpot *TourSpot // spot being visited while touring (read-only)
+}
+
+// TourSpot is one stop of a PresetTour. Exactly one of PresetToken, Home or
+// Position identifies the target.
+type TourSpot struct {
+	PresetToken string
+	Home        bool
+	Position    *PTZVector
+	Speed       *PTZVector    // nil = camera default
+	StayTime    time.Duration // time to stay before moving on
+}
+
+// IntRange is an inclusive integer range advertised by an options call.
+type IntRange struct {
+	Min int
+	Max int
+}
+
+// DurationRange is an inclusive duration range advertised by an options call.
+type DurationRange struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// PresetTourOptions describes what a camera accepts in a PresetTour.
+type PresetTourOptions struct {
+	AutoStart         bool // AutoStart is supported
+	RecurringTime     IntRange
+	RecurringDuration DurationRange
+	Directions        []string
+	PresetTokens      []string // presets usable as tour spots
+	Home              bool     // the home position may be used as a spot
+	StayTime          DurationRange
+}
+
+// FloatRange is an inclusive float range advertised by an options call.
+type FloatRange struct {
+	Min float64
+	Max float64
+}
+
+// Space2D is a pan/tilt coordinate space and its ranges.
+type Space2D struct {
+	URI    string
+	XRange FloatRange
+	YRange FloatRange
+}
+
+// Space1D is a zoom or speed coordinate space and its range.
+type Space1D struct {
+	URI    string
+	XRange FloatRange
+}
+
+// PTZSpaces lists the coordinate spaces a PTZ node supports for each kind of
+// move. An empty slice means the node does not support that move on that axis.
+type PTZSpaces struct {
+	AbsolutePanTilt   []Space2D
+	AbsoluteZoom      []Space1D
+	RelativePanTilt   []Space2D
+	RelativeZoom      []Space1D
+	ContinuousPanTilt []Space2D
+	ContinuousZoom    []Space1D
+	PanTiltSpeed      []Space1D
+	ZoomSpeed         []Space1D
+}
+
+// PTZNode is a physical PTZ unit and its capabilities.
+type PTZNode struct {
+	Token                      string
+	Name                       string
+	FixedHomePosition          bool
+	HomeSupported              bool
+	MaximumNumberOfPresets     int
+	MaximumNumberOfPresetTours int
+	PresetTourOperations       []string
+	AuxiliaryCommands          []string
+	Spaces                     PTZSpaces
+}
+
+// PTZConfigurationOptions describes the values a PTZ configuration accepts.
+type PTZConfigurationOptions struct {
+	Spaces       PTZSpaces
+	Timeout      DurationRange // allowed ContinuousMove timeout
+	EFlipModes   []string
+	ReverseModes []string
+}
+
+// PTZConfig is a PTZ configuration attached to a profile. It is read-modify-
+// write: fetch it with GetPTZConfiguration, change fields and pass it to
+// SetPTZConfiguration, which replaces the whole configuration.
 type PTZConfig struct {
 	Token     string
 	Name      string
+	UseCount  int
 	NodeToken string
+
+	// Coordinate spaces used when a move does not name one ("" = unset)
+	DefaultAbsolutePanTiltSpace   string
+	DefaultAbsoluteZoomSpace      string
+	DefaultRelativePanTiltSpace   string
+	DefaultRelativeZoomSpace      string
+	DefaultContinuousPanTiltSpace string
+	DefaultContinuousZoomSpace    string
+
+	DefaultSpeed   *PTZVector    // speed used when a move gives none
+	DefaultTimeout time.Duration // ContinuousMove timeout when none is given
+
+	PanTiltLimits *Space2D // nil = unrestricted
+	ZoomLimits    *Space1D // nil = unrestricted
+
+	EFlipMode   string // "OFF" / "ON" / "Extended" ("" = not reported)
+	ReverseMode string // "OFF" / "ON" / "AUTO" / "Extended" ("" = not reported)
 }
 
 // RotationOptions describes the image-rotation capability the camera advertises.
@@ -97,6 +278,8 @@ type StreamConfig struct {
 	Encoding     string
 	StreamURI    string
 	Quality      string // "Main" or "Sub"
+
+	Encoder *VideoEncoderConfig // full encoder configuration behind EncoderToken
 }
 
 // VideoEncoderConfig represents video encoder configuration
@@ -110,10 +293,186 @@ type VideoEncoderConfig struct {
 	BitrateLimit     int
 	EncodingInterval int
 	Quality          float32
+	Profile          string           // codec profile, e.g. "Main", "High", "Main10"
+	GovLength        int              // I-frame interval in frames
+	ConstantBitRate  *bool            // true = CBR, false = VBR; nil = unknown (Media1)
+	Multicast        *MulticastConfig // nil = not reported
 	ProfileToken     string
 	ProfileName      string
 }
 
+// MulticastConfig is the multicast destination of a stream.
+type MulticastConfig struct {
+	Address   string // IPv4 or IPv6 address
+	Port      int
+	TTL       int
+	AutoStart bool
+}
+
+// VideoEncoderOptions lists what a video encoder configuration accepts, one
+// entry per supported encoding.
+type VideoEncoderOptions struct {
+	Encodings []VideoEncodingOptions
+}
+
+// VideoEncodingOptions are the limits of one encoding. Zero ranges mean the
+// camera did not report that limit.
+type VideoEncodingOptions struct {
+	Encoding                 string // "JPEG", "MPEG4", "H264", "H265"
+	Resolutions              []Resolution
+	QualityRange             FloatRange
+	FrameRateRange           IntRange
+	FrameRates               []float64 // discrete rates (Media2 only)
+	EncodingIntervalRange    IntRange
+	BitrateRange             IntRange // kbps
+	GovLengthRange           IntRange
+	Profiles                 []string // e.g. "Baseline", "Main", "High", "Main10"
+	ConstantBitRateSupported bool
+}
+
+// MediaProfile is a media profile and the configurations bound to it. An
+// empty token means no configuration of that kind is attached.
+type MediaProfile struct {
+	Token string
+	Name  string
+	Fixed bool // the profile cannot be deleted
+
+	VideoSourceConfigToken  string
+	VideoSourceToken        string    // physical source behind VideoSourceConfigToken
+	VideoSourceBounds       Rectangle // capture window on the source
+	VideoEncoderConfigToken string
+	VideoEncoder            *VideoEncoderConfig
+	AudioSourceConfigToken  string
+	AudioEncoderConfigToken string
+	AudioOutputConfigToken  string
+	AudioDecoderConfigToken string
+	PTZConfigToken          string
+	PTZNodeToken            string // node behind PTZConfigToken
+	MetadataConfigToken     string
+	AnalyticsConfigToken    string
+}
+
+// Rectangle is an integer pixel rectangle.
+type Rectangle struct {
+	X, Y, Width, Height int
+}
+
+// Media2 configuration types, for GetProfilesMedia2 filters and
+// ConfigurationRef.
+const (
+	ConfigTypeAll          = "All"
+	ConfigTypeVideoSource  = "VideoSource"
+	ConfigTypeVideoEncoder = "VideoEncoder"
+	ConfigTypeAudioSource  = "AudioSource"
+	ConfigTypeAudioEncoder = "AudioEncoder"
+	ConfigTypeAudioOutput  = "AudioOutput"
+	ConfigTypeAudioDecoder = "AudioDecoder"
+	ConfigTypeMetadata     = "Metadata"
+	ConfigTypeAnalytics    = "Analytics"
+	ConfigTypePTZ          = "PTZ"
+)
+
+// ConfigurationRef names a configuration to add to or remove from a Media2
+// profile.
+type ConfigurationRef struct {
+	Type  string // one of the ConfigType constants
+	Token string // may be empty when removing, or to let the device choose
+}
+
+// AudioSource is a physical audio input.
+type AudioSource struct {
+	Token    string
+	Channels int // 1 = mono, 2 = stereo
+}
+
+// AudioSourceConfig binds an audio source to profiles.
+type AudioSourceConfig struct {
+	Token       string
+	Name        string
+	UseCount    int
+	SourceToken string // AudioSource token
+}
+
+// Audio encodings as named by Media1.
+const (
+	AudioEncodingG711 = "G711"
+	AudioEncodingG726 = "G726"
+	AudioEncodingAAC  = "AAC"
+)
+
+// AudioEncoderConfig is an audio encoder configuration.
+type AudioEncoderConfig struct {
+	Token      string
+	Name       string
+	UseCount   int
+	Encoding   string // one of the AudioEncoding constants
+	Bitrate    int    // kbps
+	SampleRate int    // kHz
+	Multicast  *MulticastConfig
+}
+
+// AudioEncodingOptions lists the bitrates (kbps) and sample rates (kHz) an
+// audio encoding or decoding supports.
+type AudioEncodingOptions struct {
+	Encoding    string
+	Bitrates    []int
+	SampleRates []int
+}
+
+// AudioOutputConfig binds an audio output (speaker) to profiles, for two-way
+// talk.
+type AudioOutputConfig struct {
+	Token       string
+	Name        string
+	UseCount    int
+	OutputToken string
+	SendPrimacy string // half-duplex direction URI, if supported
+	OutputLevel *int   // volume; nil leaves it unchanged on set
+}
+
+// AudioOutputOptions describes what an audio output configuration accepts.
+type AudioOutputOptions struct {
+	OutputTokens     []string
+	SendPrimacy      []string
+	OutputLevelRange IntRange
+}
+
+// AudioDecoderConfig is an audio decoder configuration, which decodes the
+// backchannel audio sent to the camera. Its formats are negotiated per
+// session, so only the name is configurable.
+type AudioDecoderConfig struct {
+	Token    string
+	Name     string
+	UseCount int
+}
+
+// MetadataConfig selects what a profile's RTSP metadata track carries. The
+// pointer fields are always set when read; on write nil leaves the camera's
+// value unchanged.
+type MetadataConfig struct {
+	Token           string
+	Name            string
+	UseCount        int
+	CompressionType string // "None", "GZIP" or "EXI"; "" leaves it unchanged
+
+	PTZStatus   *bool   // include pan/tilt/zoom move status
+	PTZPosition *bool   // include pan/tilt/zoom position
+	Events      *bool   // include event notifications
+	EventFilter *string // topic expression (ConcreteSet dialect), "" = all events; turns Events on unless Events is false; a changed one replaces the device's whole filter
+	Analytics   *bool   // include analytics scene descriptions (object data)
+	Multicast   *MulticastConfig
+}
+
+// MetadataOptions describes what a metadata configuration accepts.
+type MetadataOptions struct {
+	PanTiltStatusSupported   bool
+	ZoomStatusSupported      bool
+	PanTiltPositionSupported bool
+	ZoomPositionSupported    bool
+	CompressionTypes         []string
+	GeoLocation              bool // geo location may be included
+}
+
 // VideoSourceMode represents a sensor capture mode: the resolution (and thus
 // aspect ratio), max framerate and supported encodings, selected by token.
 type VideoSourceMode struct {
@@ -137,6 +496,33 @@ const (
 	UserLevelAnonymous     UserLevel = "Anonymous"
 )
 
+// FactoryDefaultType selects how much SetSystemFactoryDefault resets.
+type FactoryDefaultType string
+
+const (
+	// FactoryDefaultHard resets every setting, including network settings
+	// and users.
+	FactoryDefaultHard FactoryDefaultType = "Hard"
+	// FactoryDefaultSoft resets settings but keeps the device reachable (the
+	// network configuration is preserved).
+	FactoryDefaultSoft FactoryDefaultType = "Soft"
+)
+
+// FirmwareUpgrade is the upload slot a device opens for a firmware image in
+// response to StartFirmwareUpgrade.
+type FirmwareUpgrade struct {
+	UploadURI        string        // HTTP POST target for the image
+	UploadDelay      time.Duration // wait this long before uploading
+	ExpectedDownTime time.Duration // how long the device is unavailable after the upload
+}
+
+// FirmwareUpgradeResult reports a completed UpgradeFirmware.
+type FirmwareUpgradeResult struct {
+	PreviousVersion string
+	Version         string
+	Message         string // the device's message, when UpgradeSystemFirmware was used
+}
+
 // User represents an ONVIF user account
 type User struct {
 	Username  string
@@ -144,12 +530,32 @@ type User struct {
 	UserLevel UserLevel
 }
 
-// Client represents an ONVIF client with authentication
+// Client represents an ONVIF client with authentication.
+//
+// All SOAP and snapshot traffic goes through one *http.Client that the Client
+// builds on first use and then reuses, so connections to a camera are kept
+// alive and pooled across calls. Configure the transport fields before the
+// first request; later changes to them are not picked up.
 type Client struct {
 	Username    string
 	Password    string
 	Timeout     time.Duration
 	InsecureTLS bool // Skip TLS certificate verification
+
+	// HTTPClient, if set, is used as-is for every request; Timeout and the
+	// transport fields below are then ignored.
+	HTTPClient *http.Client
+	// Transport, if set, replaces the built-in pooled transport (e.g. a test
+	// RoundTripper or an instrumented one). InsecureTLS, RootCAs, Certificates
+	// and Proxy only configure the built-in transport.
+	Transport http.RoundTripper
+
+	RootCAs      *x509.CertPool                        // CAs trusted for camera certificates (nil = system pool)
+	Certificates []tls.Certificate                     // Client certificates for mutual TLS
+	Proxy        func(*http.Request) (*url.URL, error) // Proxy selector (nil = from environment)
+
+	httpOnce   sync.Once
+	httpClient *http.Client
 }
 
 // DiscoveryOptions provides options for camera discovery
@@ -170,17 +576,378 @@ const (
 	IrCutFilterAuto IrCutFilterMode = "AUTO"
 )
 
-// ImagingSettings represents imaging configuration for a video source
+// ImagingSettings represents imaging configuration for a video source.
+//
+// Every setting is optional, down to the fields of the nested settings: nil
+// (or "" for modes and IrCutFilter) means the camera did not report it and,
+// when passed to SetImagingSettings, that the current value is kept.
 type ImagingSettings struct {
 	VideoSourceToken string
 	IrCutFilter      IrCutFilterMode
+
+	Brightness      *float64
+	ColorSaturation *float64
+	Contrast        *float64
+	Sharpness       *float64
+
+	BacklightCompensation *BacklightCompensation
+	Exposure              *ExposureSettings
+	Focus                 *FocusSettings
+	WideDynamicRange      *WideDynamicRange
+	WhiteBalance          *WhiteBalance
+	ImageStabilization    *ImageStabilization
+}
+
+// BacklightCompensation is the backlight compensation setting. Level is used
+// only when Mode is "ON".
+type BacklightCompensation struct {
+	Mode  string // "OFF" / "ON"
+	Level *float64
+}
+
+// ExposureSettings is the exposure setting. The Min/Max limits apply in AUTO
+// mode; ExposureTime, Gain and Iris are the fixed values used in MANUAL mode.
+// Exposure times are in microseconds, gain and iris in dB.
+type ExposureSettings struct {
+	Mode     string // "AUTO" / "MANUAL"
+	Priority string // "LowNoise" / "FrameRate" ("" = camera default)
+
+	MinExposureTime *float64
+	MaxExposureTime *float64
+	MinGain         *float64
+	MaxGain         *float64
+	MinIris         *float64
+	MaxIris         *float64
+
+	ExposureTime *float64
+	Gain         *float64
+	Iris         *float64
+}
+
+// FocusSettings is the focus configuration. Nil limits and speed are left
+// to the camera.
+type FocusSettings struct {
+	AutoFocusMode string // "AUTO" / "MANUAL"
+	DefaultSpeed  *float64
+	NearLimit     *float64 // meters
+	FarLimit      *float64 // meters (0 = infinity)
+}
+
+// WideDynamicRange is the WDR setting. Level is used only when Mode is "ON".
+type WideDynamicRange struct {
+	Mode  string // "OFF" / "ON"
+	Level *float64
+}
+
+// WhiteBalance is the white balance setting. The gains are used only when
+// Mode is "MANUAL".
+type WhiteBalance struct {
+	Mode   string // "AUTO" / "MANUAL"
+	CrGain *float64
+	CbGain *float64
+}
+
+// ImageStabilization is the image stabilization setting. Level is used only
+// when Mode is "ON".
+type ImageStabilization struct {
+	Mode  string // "OFF" / "ON" / "AUTO"
+	Level *float64
+}
+
+// ImagingOptions describes the imaging values a video source accepts, as
+// reported by the Imaging service GetOptions. A nil range or empty mode list
+// means the camera did not advertise that setting.
+type ImagingOptions struct {
+	BacklightCompensationModes []string
+	BacklightCompensationLevel *FloatRange
+
+	Brightness      *FloatRange
+	ColorSaturation *FloatRange
+	Contrast        *FloatRange
+	Sharpness       *FloatRange
+
+	ExposureModes      []string
+	ExposurePriorities []string
+	MinExposureTime    *FloatRange
+	MaxExposureTime    *FloatRange
+	MinGain            *FloatRange
+	MaxGain            *FloatRange
+	MinIris            *FloatRange
+	MaxIris            *FloatRange
+	ExposureTime       *FloatRange
+	Gain               *FloatRange
+	Iris               *FloatRange
+
+	AutoFocusModes    []string
+	FocusDefaultSpeed *FloatRange
+	FocusNearLimit    *FloatRange
+	FocusFarLimit     *FloatRange
+
+	IrCutFilterModes []IrCutFilterMode
+
+	WideDynamicRangeModes []string
+	WideDynamicRangeLevel *FloatRange
+
+	WhiteBalanceModes  []string
+	WhiteBalanceCrGain *FloatRange // YrGain in the schema
+	WhiteBalanceCbGain *FloatRange // YbGain in the schema
+
+	ImageStabilizationModes []string
+	ImageStabilizationLevel *FloatRange
+}
+
+// ImagingPreset is an Imaging service scene preset.
+type ImagingPreset struct {
+	Token string
+	Type  string // e.g. "BacklightCompensation", "Indoor", "Night", "Custom"
+	Name  string
+}
+
+// FocusMove is an Imaging service focus move. Exactly one of Absolute,
+// Relative or Continuous should be set.
+type FocusMove struct {
+	Absolute   *AbsoluteFocus
+	Relative   *RelativeFocus
+	Continuous *ContinuousFocus
+}
+
+// AbsoluteFocus moves the focus lens to Position. Speed is optional.
+type AbsoluteFocus struct {
+	Position float64
+	Speed    *float64
+}
+
+// RelativeFocus moves the focus lens by Distance. Speed is optional.
+type RelativeFocus struct {
+	Distance float64
+	Speed    *float64
+}
+
+// ContinuousFocus moves the focus lens at Speed until StopFocus; the sign
+// selects the direction (negative = near).
+type ContinuousFocus struct {
+	Speed float64
+}
+
+// FocusMoveOptions describes the focus moves a video source supports. A nil
+// range means the move (or its speed) is not supported.
+type FocusMoveOptions struct {
+	AbsolutePosition *FloatRange
+	AbsoluteSpeed    *FloatRange
+	RelativeDistance *FloatRange
+	RelativeSpeed    *FloatRange
+	ContinuousSpeed  *FloatRange
+}
+
+// FocusStatus is the focus lens state reported by the Imaging GetStatus.
+type FocusStatus struct {
+	Position   float64
+	MoveStatus string // "IDLE" / "MOVING" / "UNKNOWN"
+	Error      string
 }
 
 // OSDConfig represents an On-Screen Display configuration
 type OSDConfig struct {
 	Token            string
-	Type             string // "Text", "Image", "DateAndTime"
-	VideoSourceToken string
+	Type             string // "Text" / "Image"
+	VideoSourceToken string // video source configuration the OSD is drawn on
+
+	// Position
+	PositionType string  // "UpperLeft" / "UpperRight" / "LowerLeft" / "LowerRight" / "Custom"
+	PositionX    float64 // Custom only, normalized -1..1
+	PositionY    float64 // Custom only, normalized -1..1
+
+	// Text overlays (Type "Text")
+	TextType        string // "Plain" / "Date" / "Time" / "DateAndTime"
+	PlainText       string // TextType "Plain"
+	DateFormat      string // e.g. "yyyy-MM-dd"
+	TimeFormat      string // e.g. "HH:mm:ss"
+	FontSize        int    // 0 = camera default
+	FontColor       *OSDColor
+	BackgroundColor *OSDColor
+
+	// Image overlays (Type "Image")
+	ImagePath string
+}
+
+// OSDColor is an OSD font or background color. X, Y and Z are the color
+// coordinates in Colorspace (YCbCr when empty); Transparent is 0 for opaque.
+type OSDColor struct {
+	X, Y, Z     float64
+	Colorspace  string
+	Transparent int
+}
+
+// OSDOptions describes the OSDs a video source configuration accepts.
+type OSDOptions struct {
+	MaxTotal       int // maximum number of OSDs
+	MaxImage       int
+	MaxPlainText   int
+	MaxDate        int
+	MaxTime        int
+	MaxDateAndTime int
+
+	Types         []string // "Text" / "Image"
+	PositionTypes []string
+	TextTypes     []string
+	DateFormats   []string
+	TimeFormats   []string
+	FontSize      *IntRange
+	FontColors    []OSDColor // fixed palette, when the camera lists one
+	ImagePaths    []string
+}
+
+// SimpleItem is a name/value pair, as carried in event message Source, Key
+// and Data sections.
+type SimpleItem struct {
+	Name  string
+	Value string
+}
+
+// ElementItem is a named complex parameter of an analytics rule or module,
+// such as the tt:Polyline of a line detector. XML is the element's content;
+// as read from the device it declares every namespace prefix it uses, so it
+// can be sent back as is. ONVIF schema types use the tt: prefix.
+type ElementItem struct {
+	Name string
+	XML  string
+}
+
+// Point is a point in normalized image coordinates (-1..1, origin at the
+// centre, y pointing up), as used by analytics polylines and polygons.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// AnalyticsConfig is an analytics rule or module instance (tt:Config).
+type AnalyticsConfig struct {
+	Name         string // instance name, unique within the analytics configuration
+	Type         string // e.g. "tt:LineDetector", "tt:FieldDetector", "tt:CellMotionEngine"
+	TypeSpace    string // namespace URI of Type's prefix; "" = tt (ONVIF schema)
+	SimpleItems  []SimpleItem
+	ElementItems []ElementItem
+}
+
+// AnalyticsMessageDescription describes the events a rule or module raises,
+// under ParentTopic (e.g. "tns1:RuleEngine/LineDetector/Crossed").
+type AnalyticsMessageDescription struct {
+	ParentTopic string
+	EventMessageDescription
+}
+
+// AnalyticsConfigDescription describes a rule or module type a camera
+// supports: the parameters an instance takes and the events it raises.
+type AnalyticsConfigDescription struct {
+	Name         string // the type, e.g. "tt:LineDetector"
+	Fixed        bool   // instances cannot be created or deleted
+	MaxInstances int    // 0 when the camera sets no limit
+	SimpleItems  []SimpleItemDescription
+	ElementItems []SimpleItemDescription
+	Messages     []AnalyticsMessageDescription
+}
+
+// MotionRegion is a camera's cell motion detection setup: the grid of a
+// CellMotionEngine module and the active cells of the CellMotionDetector rule
+// that watches it.
+type MotionRegion struct {
+	ConfigToken string // video analytics configuration holding both
+	ModuleName  string // the CellMotionEngine module
+	RuleName    string // the CellMotionDetector rule
+	Columns     int
+	Rows        int
+	Cells       [][]bool // Cells[row][column]; true where motion is detected
+	Sensitivity *int     // the module's Sensitivity, typically 0..100; nil if it has none, or to keep it
+}
+
+// NotificationMessage is a single ONVIF event, as delivered by PullMessages or
+// a WS-BaseNotification Notify.
+type NotificationMessage struct {
+	Topic                 string // e.g. "tns1:RuleEngine/CellMotionDetector/Motion"
+	SubscriptionReference string
+	ProducerReference     string
+	UtcTime               time.Time
+	PropertyOperation     string // "Initialized", "Changed" or "Deleted" for property events
+	Source                []SimpleItem
+	Key                   []SimpleItem
+	Data                  []SimpleItem
+}
+
+// SimpleItemDescription describes one item of an event message: its name and
+// XML schema type (e.g. "tt:ReferenceToken", "xs:boolean").
+type SimpleItemDescription struct {
+	Name string
+	Type string
+}
+
+// EventMessageDescription describes the payload of messages raised on a topic.
+type EventMessageDescription struct {
+	IsProperty bool // the topic reports a state (Initialized/Changed/Deleted)
+	Source     []SimpleItemDescription
+	Key        []SimpleItemDescription
+	Data       []SimpleItemDescription
+}
+
+// EventTopic is a node of a camera's topic tree. Path is the full topic
+// expression (e.g. "tns1:RuleEngine/CellMotionDetector/Motion") and can be
+// used directly in a subscription filter.
+type EventTopic struct {
+	Name      string // local element name, e.g. "Motion"
+	Namespace string // namespace URI of the root topic
+	Path      string
+	IsTopic   bool                     // events are raised on this node itself
+	Message   *EventMessageDescription // nil when the node only groups children
+	Children  []EventTopic
+}
+
+// EventProperties describes what a camera's Events service can raise and how
+// subscriptions to it may be filtered.
+type EventProperties struct {
+	TopicNamespaceLocations      []string
+	FixedTopicSet                bool
+	Topics                       []EventTopic
+	TopicExpressionDialects      []string
+	MessageContentFilterDialects []string
+	MessageContentSchemaLocation []string
+}
+
+// EventSubscription is an active event subscription on a camera. The same
+// type covers PullPoint and push (Subscribe) subscriptions; both are renewed
+// and cancelled through their subscription manager at Address.
+type EventSubscription struct {
+	Address             string    // subscription manager endpoint
+	ReferenceParameters string    // raw wsa:ReferenceParameters, echoed back as SOAP headers
+	CurrentTime         time.Time // device clock at the last create/renew/pull
+	TerminationTime     time.Time // device clock time at which the subscription expires
+}
+
+// PullPointOptions configures a PullEvents loop.
+type PullPointOptions struct {
+	Filter          string        // ConcreteSet topic expression; "" receives every topic
+	TerminationTime time.Duration // subscription lifetime, renewed before expiry (default 60s)
+	PullTimeout     time.Duration // how long the camera may hold each PullMessages (default 5s)
+	MessageLimit    int           // maximum messages per PullMessages (default 100)
+}
+
+// SubscribeOptions configures a push (WS-BaseNotification Subscribe)
+// subscription managed by PushEvents.
+type SubscribeOptions struct {
+	Filter          string        // ConcreteSet topic expression; "" receives every topic
+	TerminationTime time.Duration // subscription lifetime, renewed before expiry (default 60s)
+}
+
+// EventConsumer is an http.Handler that receives WS-BaseNotification Notify
+// messages pushed by cameras and dispatches them to the handler registered
+// for the request path. Mount it on a subtree (e.g. "/onvif/notify/") of a
+// server the cameras can reach.
+type EventConsumer struct {
+	// BaseURL is the externally reachable URL of the mount point (e.g.
+	// "http://10.0.0.5:8080/onvif/notify"); each registration is served at
+	// BaseURL/<key>.
+	BaseURL string
+
+	mu       sync.RWMutex
+	handlers map[string]*consumerRegistration
 }
 
 // StreamUpdateConfig specifies target configuration for stream updates
@@ -188,7 +955,12 @@ type StreamUpdateConfig struct {
 	Resolution Resolution
 	Framerate  int
 	Bitrate    int
-	Encoding   string
+	Encoding   string // "JPEG", "MPEG4", "H264"; "H265" needs Media2 (an error on Media1)
+
+	Profile         string           // codec profile, e.g. "High" or "Main10"
+	GovLength       int              // I-frame interval in frames
+	ConstantBitRate *bool            // CBR when true, VBR when false (Media2 only; an error on Media1)
+	Multicast       *MulticastConfig // replaces the multicast settings
 }
 
 // Resolution represents video resolution
@@ -1,6 +1,7 @@
 package onvif
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strings"
@@ -8,10 +9,15 @@ import (
 
 // GetUsers retrieves all users from the camera
 func (c *Client) GetUsers(camera *Camera) ([]User, error) {
+	return c.GetUsersContext(context.Background(), camera)
+}
+
+// GetUsersContext is like GetUsers but uses ctx for cancellation and deadlines.
+func (c *Client) GetUsersContext(ctx context.Context, camera *Camera) ([]User, error) {
 	address := getFirstAddress(camera.Address)
 
 	body := `<tds:GetUsers/>`
-	resp, err := c.sendSOAPRequest(address,
+	resp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/GetUsers", body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users: %v", err)
@@ -43,6 +49,12 @@ func (c *Client) GetUsers(camera *Camera) ([]User, error) {
 
 // CreateUsers creates multiple users on the camera
 func (c *Client) CreateUsers(camera *Camera, users []User) error {
+	return c.CreateUsersContext(context.Background(), camera, users)
+}
+
+// CreateUsersContext is like CreateUsers but uses ctx for cancellation and
+// deadlines.
+func (c *Client) CreateUsersContext(ctx context.Context, camera *Camera, users []User) error {
 	address := getFirstAddress(camera.Address)
 
 	var usersXML strings.Builder
@@ -62,7 +74,7 @@ func (c *Client) CreateUsers(camera *Camera, users []User) error {
 	}
 	usersXML.WriteString("</tds:CreateUsers>")
 
-	resp, err := c.sendSOAPRequest(address,
+	resp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/CreateUsers", usersXML.String())
 	if err != nil {
 		return fmt.Errorf("failed to create users: %v", err)
@@ -77,7 +89,13 @@ func (c *Client) CreateUsers(camera *Camera, users []User) error {
 
 // CreateUser creates a single user on the camera (convenience wrapper)
 func (c *Client) CreateUser(camera *Camera, username, password string, level UserLevel) error {
-	return c.CreateUsers(camera, []User{{
+	return c.CreateUserContext(context.Background(), camera, username, password, level)
+}
+
+// CreateUserContext is like CreateUser but uses ctx for cancellation and
+// deadlines.
+func (c *Client) CreateUserContext(ctx context.Context, camera *Camera, username, password string, level UserLevel) error {
+	return c.CreateUsersContext(ctx, camera, []User{{
 		Username:  username,
 		Password:  password,
 		UserLevel: level,
@@ -86,6 +104,11 @@ func (c *Client) CreateUser(camera *Camera, username, password string, level Use
 
 // SetUser modifies an existing user's password and/or level
 func (c *Client) SetUser(camera *Camera, user User) error {
+	return c.SetUserContext(context.Background(), camera, user)
+}
+
+// SetUserContext is like SetUser but uses ctx for cancellation and deadlines.
+func (c *Client) SetUserContext(ctx context.Context, camera *Camera, user User) error {
 	address := getFirstAddress(camera.Address)
 
 	body := fmt.Sprintf(`<tds:SetUser>
@@ -99,7 +122,7 @@ func (c *Client) SetUser(camera *Camera, user User) error {
 		escapeXML(user.Password),
 		string(user.UserLevel))
 
-	resp, err := c.sendSOAPRequest(address,
+	resp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/SetUser", body)
 	if err != nil {
 		return fmt.Errorf("failed to set user: %v", err)
@@ -115,8 +138,14 @@ func (c *Client) SetUser(camera *Camera, user User) error {
 // SetUserPassword changes a user's password (convenience wrapper)
 // Note: This requires knowing the user's current level
 func (c *Client) SetUserPassword(camera *Camera, username, newPassword string) error {
+	return c.SetUserPasswordContext(context.Background(), camera, username, newPassword)
+}
+
+// SetUserPasswordContext is like SetUserPassword but uses ctx for cancellation
+// and deadlines.
+func (c *Client) SetUserPasswordContext(ctx context.Context, camera *Camera, username, newPassword string) error {
 	// First get the user's current level
-	users, err := c.GetUsers(camera)
+	users, err := c.GetUsersContext(ctx, camera)
 	if err != nil {
 		return fmt.Errorf("failed to get current user info: %v", err)
 	}
@@ -135,7 +164,7 @@ func (c *Client) SetUserPassword(camera *Camera, username, newPassword string) e
 		return fmt.Errorf("user '%s' not found", username)
 	}
 
-	return c.SetUser(camera, User{
+	return c.SetUserContext(ctx, camera, User{
 		Username:  username,
 		Password:  newPassword,
 		UserLevel: userLevel,
@@ -144,6 +173,12 @@ func (c *Client) SetUserPassword(camera *Camera, username, newPassword string) e
 
 // DeleteUsers deletes multiple users from the camera
 func (c *Client) DeleteUsers(camera *Camera, usernames []string) error {
+	return c.DeleteUsersContext(context.Background(), camera, usernames)
+}
+
+// DeleteUsersContext is like DeleteUsers but uses ctx for cancellation and
+// deadlines.
+func (c *Client) DeleteUsersContext(ctx context.Context, camera *Camera, usernames []string) error {
 	address := getFirstAddress(camera.Address)
 
 	var usernamesXML strings.Builder
@@ -155,7 +190,7 @@ func (c *Client) DeleteUsers(camera *Camera, usernames []string) error {
 	}
 	usernamesXML.WriteString("</tds:DeleteUsers>")
 
-	resp, err := c.sendSOAPRequest(address,
+	resp, err := c.sendSOAPRequest(ctx, address,
 		"http://www.onvif.org/ver10/device/wsdl/DeleteUsers", usernamesXML.String())
 	if err != nil {
 		return fmt.Errorf("failed to delete users: %v", err)
@@ -170,7 +205,13 @@ func (c *Client) DeleteUsers(camera *Camera, usernames []string) error {
 
 // DeleteUser deletes a single user from the camera (convenience wrapper)
 func (c *Client) DeleteUser(camera *Camera, username string) error {
-	return c.DeleteUsers(camera, []string{username})
+	return c.DeleteUserContext(context.Background(), camera, username)
+}
+
+// DeleteUserContext is like DeleteUser but uses ctx for cancellation and
+// deadlines.
+func (c *Client) DeleteUserContext(ctx context.Context, camera *Camera, username string) error {
+	return c.DeleteUsersContext(ctx, camera, []string{username})
 }
 
 // escapeXML escapes special XML characters in a string
@@ -0,0 +1,299 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// intRangeXML is the parsed form of a tt:IntRange.
+type intRangeXML struct {
+	Min int `xml:"Min"`
+	Max int `xml:"Max"`
+}
+
+func (r intRangeXML) intRange() IntRange {
+	return IntRange{Min: r.Min, Max: r.Max}
+}
+
+// encodingOptionsXML is the parsed form of a Media1 tt:JpegOptions,
+// tt:Mpeg4Options or tt:H264Options element.
+type encodingOptionsXML struct {
+	Resolutions      []resolutionXML `xml:"ResolutionsAvailable"`
+	FrameRate        intRangeXML     `xml:"FrameRateRange"`
+	EncodingInterval intRangeXML     `xml:"EncodingIntervalRange"`
+	GovLength        intRangeXML     `xml:"GovLengthRange"`
+	Mpeg4Profiles    []string        `xml:"Mpeg4ProfilesSupported"`
+	H264Profiles     []string        `xml:"H264ProfilesSupported"`
+}
+
+type resolutionXML struct {
+	Width  int `xml:"Width"`
+	Height int `xml:"Height"`
+}
+
+// videoEncoderOptionsXML is the parsed form of a Media1
+// tt:VideoEncoderConfigurationOptions. Bitrate ranges live in the extension.
+type videoEncoderOptionsXML struct {
+	Quality struct {
+		Min float64 `xml:"Min"`
+		Max float64 `xml:"Max"`
+	} `xml:"QualityRange"`
+	JPEG      *encodingOptionsXML `xml:"JPEG"`
+	MPEG4     *encodingOptionsXML `xml:"MPEG4"`
+	H264      *encodingOptionsXML `xml:"H264"`
+	Extension struct {
+		JPEG  intRangeXML `xml:"JPEG>BitrateRange"`
+		MPEG4 intRangeXML `xml:"MPEG4>BitrateRange"`
+		H264  intRangeXML `xml:"H264>BitrateRange"`
+	} `xml:"Extension"`
+}
+
+func (o videoEncoderOptionsXML) options() *VideoEncoderOptions {
+	quality := FloatRange{Min: o.Quality.Min, Max: o.Quality.Max}
+	opts := &VideoEncoderOptions{}
+	add := func(encoding string, e *encodingOptionsXML, bitrate intRangeXML) {
+		if e == nil {
+			return
+		}
+		profiles := e.H264Profiles
+		if encoding == "MPEG4" {
+			profiles = e.Mpeg4Profiles
+		}
+		opts.Encodings = append(opts.Encodings, VideoEncodingOptions{
+			Encoding:              encoding,
+			Resolutions:           resolutions(e.Resolutions),
+			QualityRange:          quality,
+			FrameRateRange:        e.FrameRate.intRange(),
+			EncodingIntervalRange: e.EncodingInterval.intRange(),
+			BitrateRange:          bitrate.intRange(),
+			GovLengthRange:        e.GovLength.intRange(),
+			Profiles:              trimAll(profiles),
+		})
+	}
+	add("JPEG", o.JPEG, o.Extension.JPEG)
+	add("MPEG4", o.MPEG4, o.Extension.MPEG4)
+	add("H264", o.H264, o.Extension.H264)
+	return opts
+}
+
+// videoEncoder2OptionsXML is the parsed form of one Media2
+// tt:VideoEncoder2ConfigurationOptions entry. The list-valued attributes are
+// space separated.
+type videoEncoder2OptionsXML struct {
+	GovLengthRange           string `xml:"GovLengthRange,attr"`
+	FrameRatesSupported      string `xml:"FrameRatesSupported,attr"`
+	ProfilesSupported        string `xml:"ProfilesSupported,attr"`
+	ConstantBitRateSupported bool   `xml:"ConstantBitRateSupported,attr"`
+	Encoding                 string `xml:"Encoding"`
+	Quality                  struct {
+		Min float64 `xml:"Min"`
+		Max float64 `xml:"Max"`
+	} `xml:"QualityRange"`
+	Resolutions []resolutionXML `xml:"ResolutionsAvailable"`
+	Bitrate     intRangeXML     `xml:"BitrateRange"`
+}
+
+func (o videoEncoder2OptionsXML) options() VideoEncodingOptions {
+	opts := VideoEncodingOptions{
+		Encoding:                 strings.TrimSpace(o.Encoding),
+		Resolutions:              resolutions(o.Resolutions),
+		QualityRange:             FloatRange{Min: o.Quality.Min, Max: o.Quality.Max},
+		BitrateRange:             o.Bitrate.intRange(),
+		Profiles:                 strings.Fields(o.ProfilesSupported),
+		ConstantBitRateSupported: o.ConstantBitRateSupported,
+	}
+	if gov := strings.Fields(o.GovLengthRange); len(gov) == 2 {
+		opts.GovLengthRange.Min, _ = strconv.Atoi(gov[0])
+		opts.GovLengthRange.Max, _ = strconv.Atoi(gov[1])
+	}
+	for _, f := range strings.Fields(o.FrameRatesSupported) {
+		if rate, err := strconv.ParseFloat(f, 64); err == nil {
+			opts.FrameRates = append(opts.FrameRates, rate)
+		}
+	}
+	if len(opts.FrameRates) > 0 {
+		min, max := opts.FrameRates[0], opts.FrameRates[0]
+		for _, r := range opts.FrameRates[1:] {
+			min, max = math.Min(min, r), math.Max(max, r)
+		}
+		opts.FrameRateRange = IntRange{Min: int(math.Round(min)), Max: int(math.Round(max))}
+	}
+	return opts
+}
+
+func resolutions(in []resolutionXML) []Resolution {
+	out := make([]Resolution, 0, len(in))
+	for _, r := range in {
+		out = append(out, Resolution{Width: r.Width, Height: r.Height})
+	}
+	return out
+}
+
+// getVideoEncoderOptions fetches the Media1 options for an encoder
+// configuration and/or profile.
+func (c *Client) getVideoEncoderOptions(ctx context.Context, camera *Camera, configToken, profileToken string) (*VideoEncoderOptions, error) {
+	resp, err := c.mediaCall(ctx, camera, "GetVideoEncoderConfigurationOptions",
+		buildOptionsBody("trt", "GetVideoEncoderConfigurationOptions", configToken, profileToken))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Options videoEncoderOptionsXML `xml:"Body>GetVideoEncoderConfigurationOptionsResponse>Options"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse video encoder options: %v", err)
+	}
+	return parsed.Options.options(), nil
+}
+
+// getVideoEncoderOptionsMedia2 fetches the Media2 options for an encoder
+// configuration and/or profile.
+func (c *Client) getVideoEncoderOptionsMedia2(ctx context.Context, camera *Camera, configToken, profileToken string) (*VideoEncoderOptions, error) {
+	resp, err := c.media2Call(ctx, camera, "GetVideoEncoderConfigurationOptions",
+		buildOptionsBody("tr2", "GetVideoEncoderConfigurationOptions", configToken, profileToken))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Options []videoEncoder2OptionsXML `xml:"Body>GetVideoEncoderConfigurationOptionsResponse>Options"`
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse video encoder options: %v", err)
+	}
+	opts := &VideoEncoderOptions{}
+	for _, o := range parsed.Options {
+		opts.Encodings = append(opts.Encodings, o.options())
+	}
+	return opts, nil
+}
+
+// GetVideoEncoderConfigurationOptions returns the encodings, resolutions and
+// ranges accepted by a video encoder configuration. Either token may be empty:
+// configToken narrows to one configuration, profileToken to what is
+// compatible with that profile. Media2 is used when the camera offers it.
+func (c *Client) GetVideoEncoderConfigurationOptions(camera *Camera, configToken, profileToken string) (*VideoEncoderOptions, error) {
+	return c.GetVideoEncoderConfigurationOptionsContext(context.Background(), camera, configToken, profileToken)
+}
+
+// GetVideoEncoderConfigurationOptionsContext is like
+// GetVideoEncoderConfigurationOptions but uses ctx for cancellation and
+// deadlines.
+func (c *Client) GetVideoEncoderConfigurationOptionsContext(ctx context.Context, camera *Camera, configToken, profileToken string) (*VideoEncoderOptions, error) {
+	if c.useMedia2(ctx, camera) {
+		return c.getVideoEncoderOptionsMedia2(ctx, camera, configToken, profileToken)
+	}
+	return c.getVideoEncoderOptions(ctx, camera, configToken, profileToken)
+}
+
+// Encoding returns the options for the named encoding (case-insensitive), or
+// nil if it is not supported.
+func (o *VideoEncoderOptions) Encoding(name string) *VideoEncodingOptions {
+	for i := range o.Encodings {
+		if strings.EqualFold(o.Encodings[i].Encoding, name) {
+			return &o.Encodings[i]
+		}
+	}
+	return nil
+}
+
+// NearestResolution returns the available resolution closest to r, or r
+// itself when the camera listed none.
+func (o *VideoEncodingOptions) NearestResolution(r Resolution) Resolution {
+	best, bestDist := r, -1
+	for _, a := range o.Resolutions {
+		dw, dh := a.Width-r.Width, a.Height-r.Height
+		if d := dw*dw + dh*dh; bestDist < 0 || d < bestDist {
+			best, bestDist = a, d
+		}
+	}
+	return best
+}
+
+// NearestFrameRate returns the listed frame rate closest to rate, or rate
+// clamped to FrameRateRange when the camera listed no discrete rates. Only
+// whole rates are candidates, since a stream update's Framerate is an
+// integer.
+func (o *VideoEncodingOptions) NearestFrameRate(rate int) int {
+	best, bestDist := 0, -1.0
+	for _, r := range o.FrameRates {
+		if r != math.Trunc(r) {
+			continue
+		}
+		if d := math.Abs(r - float64(rate)); bestDist < 0 || d < bestDist {
+			best, bestDist = int(r), d
+		}
+	}
+	if bestDist < 0 {
+		return clampInt(rate, o.FrameRateRange)
+	}
+	return best
+}
+
+// Validate fits a stream update to these options: the resolution and
+// framerate are snapped to the nearest available ones and bitrate and GOV
+// length are clamped to their ranges. Zero fields in config are left alone.
+func (o *VideoEncodingOptions) Validate(config StreamUpdateConfig) StreamUpdateConfig {
+	if config.Resolution.Width > 0 && config.Resolution.Height > 0 {
+		config.Resolution = o.NearestResolution(config.Resolution)
+	}
+	if config.Framerate > 0 {
+		config.Framerate = o.NearestFrameRate(config.Framerate)
+	}
+	if config.Bitrate > 0 {
+		config.Bitrate = clampInt(config.Bitrate, o.BitrateRange)
+	}
+	if config.GovLength > 0 {
+		config.GovLength = clampInt(config.GovLength, o.GovLengthRange)
+	}
+	return config
+}
+
+// fit validates config against the options of the encoding it will use
+// (config.Encoding, or current when unset). Options that list no encodings
+// are ignored; an unsupported encoding or codec profile is an error.
+func (o *VideoEncoderOptions) fit(config StreamUpdateConfig, current, encoderToken string) (StreamUpdateConfig, error) {
+	if len(o.Encodings) == 0 {
+		return config, nil
+	}
+	encoding := config.Encoding
+	if encoding == "" {
+		encoding = current
+	}
+	enc := o.Encoding(encoding)
+	if enc == nil {
+		return config, fmt.Errorf("encoding %q is not supported by video encoder configuration %q", encoding, encoderToken)
+	}
+	if config.Profile != "" && len(enc.Profiles) > 0 && !containsFold(enc.Profiles, config.Profile) {
+		return config, fmt.Errorf("%s profile %q is not supported by video encoder configuration %q", encoding, config.Profile, encoderToken)
+	}
+	return enc.Validate(config), nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// clampInt limits v to r, ignoring an unreported (zero) range.
+func clampInt(v int, r IntRange) int {
+	if r.Max == 0 {
+		return v
+	}
+	if v < r.Min {
+		return r.Min
+	}
+	if v > r.Max {
+		return r.Max
+	}
+	return v
+}
@@ -0,0 +1,127 @@
+package onvif
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const media1EncoderOptions = `<trt:GetVideoEncoderConfigurationOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:Options>
+<tt:QualityRange><tt:Min>1</tt:Min><tt:Max>10</tt:Max></tt:QualityRange>
+<tt:JPEG><tt:ResolutionsAvailable><tt:Width>640</tt:Width><tt:Height>480</tt:Height></tt:ResolutionsAvailable>
+<tt:FrameRateRange><tt:Min>1</tt:Min><tt:Max>15</tt:Max></tt:FrameRateRange><tt:EncodingIntervalRange><tt:Min>1</tt:Min><tt:Max>1</tt:Max></tt:EncodingIntervalRange></tt:JPEG>
+<tt:H264>
+<tt:ResolutionsAvailable><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:ResolutionsAvailable>
+<tt:ResolutionsAvailable><tt:Width>1280</tt:Width><tt:Height>720</tt:Height></tt:ResolutionsAvailable>
+<tt:ResolutionsAvailable><tt:Width>704</tt:Width><tt:Height>576</tt:Height></tt:ResolutionsAvailable>
+<tt:GovLengthRange><tt:Min>1</tt:Min><tt:Max>150</tt:Max></tt:GovLengthRange>
+<tt:FrameRateRange><tt:Min>1</tt:Min><tt:Max>25</tt:Max></tt:FrameRateRange>
+<tt:EncodingIntervalRange><tt:Min>1</tt:Min><tt:Max>1</tt:Max></tt:EncodingIntervalRange>
+<tt:H264ProfilesSupported>Baseline</tt:H264ProfilesSupported><tt:H264ProfilesSupported>Main</tt:H264ProfilesSupported>
+</tt:H264>
+<tt:Extension><tt:H264><tt:BitrateRange><tt:Min>32</tt:Min><tt:Max>8192</tt:Max></tt:BitrateRange></tt:H264></tt:Extension>
+</trt:Options></trt:GetVideoEncoderConfigurationOptionsResponse>`
+
+func TestApplyStreamConfiguration(t *testing.T) {
+	var gotBody string
+	c := fakeSOAPClient(func(action, body string) string {
+		gotBody = body
+		switch action {
+		case "http://www.onvif.org/ver10/media/wsdl/GetProfiles":
+			return `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+<trt:Profiles token="sub"><tt:Name>Sub</tt:Name>
+<tt:VideoEncoderConfiguration token="ve1"><tt:Name>VE1</tt:Name><tt:Encoding>H264</tt:Encoding>
+<tt:Resolution><tt:Width>704</tt:Width><tt:Height>576</tt:Height></tt:Resolution><tt:Quality>5</tt:Quality>
+<tt:RateControl><tt:FrameRateLimit>15</tt:FrameRateLimit><tt:EncodingInterval>1</tt:EncodingInterval><tt:BitrateLimit>1024</tt:BitrateLimit></tt:RateControl>
+</tt:VideoEncoderConfiguration></trt:Profiles></trt:GetProfilesResponse>`
+		case "http://www.onvif.org/ver10/media/wsdl/GetVideoEncoderConfigurationOptions":
+			return media1EncoderOptions
+		}
+		return `<trt:Response xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+	})
+	camera := &Camera{MediaURL: "http://192.0.2.10/onvif/media"}
+
+	opts, err := c.GetVideoEncoderConfigurationOptions(camera, "ve1", "")
+	if err != nil {
+		t.Fatalf("GetVideoEncoderConfigurationOptions() error = %v", err)
+	}
+	h264 := opts.Encoding("h264")
+	if h264 == nil || len(h264.Resolutions) != 3 || h264.BitrateRange != (IntRange{32, 8192}) ||
+		h264.GovLengthRange != (IntRange{1, 150}) || h264.QualityRange.Max != 10 ||
+		!reflect.DeepEqual(h264.Profiles, []string{"Baseline", "Main"}) {
+		t.Errorf("H264 options = %+v", h264)
+	}
+	if jpeg := opts.Encoding("JPEG"); jpeg == nil || jpeg.FrameRateRange.Max != 15 || jpeg.BitrateRange.Max != 0 {
+		t.Errorf("JPEG options = %+v", jpeg)
+	}
+	if opts.Encoding("H265") != nil {
+		t.Error("H265 reported as supported")
+	}
+
+	applied, err := c.ApplyStreamConfiguration(camera, "ve1", StreamUpdateConfig{
+		Resolution: Resolution{Width: 1280, Height: 700},
+		Framerate:  30,
+		Bitrate:    1024000,
+	})
+	if err != nil {
+		t.Fatalf("ApplyStreamConfiguration() error = %v", err)
+	}
+	if applied.Multicast == nil || applied.Multicast.Address != "" {
+		t.Errorf("applied multicast = %+v", applied.Multicast)
+	}
+	applied.Multicast = nil
+	want := StreamUpdateConfig{Resolution: Resolution{1280, 720}, Framerate: 25, Bitrate: 8192, Encoding: "H264", Profile: "Main", GovLength: 30}
+	if applied != want {
+		t.Errorf("ApplyStreamConfiguration() = %+v, want %+v", applied, want)
+	}
+	for _, w := range []string{`<tt:Width>1280</tt:Width>`, `<tt:Height>720</tt:Height>`, `<tt:FrameRateLimit>25</tt:FrameRateLimit>`, `<tt:BitrateLimit>8192</tt:BitrateLimit>`} {
+		if !strings.Contains(gotBody, w) {
+			t.Errorf("SetVideoEncoderConfiguration body missing %q: %s", w, gotBody)
+		}
+	}
+
+	if err := c.UpdateStreamConfiguration(camera, "ve1", StreamUpdateConfig{Encoding: "MPEG4"}); err == nil ||
+		!strings.Contains(err.Error(), "not supported") {
+		t.Errorf("UpdateStreamConfiguration(MPEG4) error = %v", err)
+	}
+	cbr := true
+	for _, media2Only := range []StreamUpdateConfig{{Encoding: "H265"}, {ConstantBitRate: &cbr}} {
+		if err := c.UpdateStreamConfiguration(camera, "ve1", media2Only); err == nil || !strings.Contains(err.Error(), "requires Media2") {
+			t.Errorf("UpdateStreamConfiguration(%+v) on Media1 error = %v", media2Only, err)
+		}
+	}
+}
+
+func TestParseMedia2EncoderOptions(t *testing.T) {
+	data := `<Options GovLengthRange="1 300" FrameRatesSupported="30 25 12.5 6.25" ProfilesSupported="Main Main10" ConstantBitRateSupported="true">
+<Encoding>H265</Encoding><QualityRange><Min>0</Min><Max>100</Max></QualityRange>
+<ResolutionsAvailable><Width>3840</Width><Height>2160</Height></ResolutionsAvailable>
+<BitrateRange><Min>256</Min><Max>16384</Max></BitrateRange></Options>`
+	var o videoEncoder2OptionsXML
+	if err := xml.Unmarshal([]byte(data), &o); err != nil {
+		t.Fatal(err)
+	}
+	got := o.options()
+	want := VideoEncodingOptions{
+		Encoding:                 "H265",
+		Resolutions:              []Resolution{{3840, 2160}},
+		QualityRange:             FloatRange{Min: 0, Max: 100},
+		FrameRateRange:           IntRange{6, 30},
+		FrameRates:               []float64{30, 25, 12.5, 6.25},
+		BitrateRange:             IntRange{256, 16384},
+		GovLengthRange:           IntRange{1, 300},
+		Profiles:                 []string{"Main", "Main10"},
+		ConstantBitRateSupported: true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("options() = %+v\nwant %+v", got, want)
+	}
+
+	// Media2 lists discrete rates: a rate between them snaps to the nearest.
+	for _, tc := range []struct{ in, want int }{{20, 25}, {28, 30}, {60, 30}, {25, 25}} {
+		if fitted := got.Validate(StreamUpdateConfig{Framerate: tc.in}); fitted.Framerate != tc.want {
+			t.Errorf("Validate(Framerate %d) = %d, want %d", tc.in, fitted.Framerate, tc.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package onvif
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strings"
@@ -10,17 +11,23 @@ import (
 // camera supports. A mode carries the sensor resolution (hence aspect ratio),
 // max framerate and supported encodings, and is selected by token.
 func (c *Client) GetVideoSourceModes(camera *Camera) ([]VideoSourceMode, error) {
-	token, err := c.getVideoSourceToken(camera)
+	return c.GetVideoSourceModesContext(context.Background(), camera)
+}
+
+// GetVideoSourceModesContext is like GetVideoSourceModes but uses ctx for
+// cancellation and deadlines.
+func (c *Client) GetVideoSourceModesContext(ctx context.Context, camera *Camera) ([]VideoSourceMode, error) {
+	token, err := c.getVideoSourceToken(ctx, camera)
 	if err != nil {
 		return nil, err
 	}
-	mediaURL := c.resolveMediaURL(camera)
+	mediaURL := c.resolveMediaURL(ctx, camera)
 
 	body := fmt.Sprintf(`<trt:GetVideoSourceModes>
 		<trt:VideoSourceToken>%s</trt:VideoSourceToken>
 	</trt:GetVideoSourceModes>`, token)
 
-	resp, err := c.sendSOAPRequest(mediaURL,
+	resp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/GetVideoSourceModes", body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get video source modes: %v", err)
@@ -66,18 +73,24 @@ func (c *Client) GetVideoSourceModes(camera *Camera) ([]VideoSourceMode, error)
 // SetVideoSourceMode selects the sensor capture mode with the given token. It
 // returns whether the camera needs to reboot to apply the change.
 func (c *Client) SetVideoSourceMode(camera *Camera, modeToken string) (bool, error) {
-	token, err := c.getVideoSourceToken(camera)
+	return c.SetVideoSourceModeContext(context.Background(), camera, modeToken)
+}
+
+// SetVideoSourceModeContext is like SetVideoSourceMode but uses ctx for
+// cancellation and deadlines.
+func (c *Client) SetVideoSourceModeContext(ctx context.Context, camera *Camera, modeToken string) (bool, error) {
+	token, err := c.getVideoSourceToken(ctx, camera)
 	if err != nil {
 		return false, err
 	}
-	mediaURL := c.resolveMediaURL(camera)
+	mediaURL := c.resolveMediaURL(ctx, camera)
 
 	body := fmt.Sprintf(`<trt:SetVideoSourceMode>
 		<trt:VideoSourceToken>%s</trt:VideoSourceToken>
 		<trt:VideoSourceModeToken>%s</trt:VideoSourceModeToken>
 	</trt:SetVideoSourceMode>`, token, modeToken)
 
-	resp, err := c.sendSOAPRequest(mediaURL,
+	resp, err := c.sendSOAPRequest(ctx, mediaURL,
 		"http://www.onvif.org/ver10/media/wsdl/SetVideoSourceMode", body)
 	if err != nil {
 		return false, fmt.Errorf("failed to set video source mode: %v", err)